@@ -0,0 +1,114 @@
+package market
+
+import (
+	"context"
+	"fmt"
+)
+
+// PriceContext 最新成交价、标记价格与指数价格的关系
+// 永续合约长期偏离指数（基差）会体现在下一次资金费率中，最新价与标记价格的偏离往往先于资金费率变化
+type PriceContext struct {
+	LastPrice   float64 `json:"last_price"` // 即CurrentPrice
+	MarkPrice   float64 `json:"mark_price"`
+	IndexPrice  float64 `json:"index_price"`
+	Basis       float64 `json:"basis"`         // 标记价格 − 指数价格
+	BasisBps    float64 `json:"basis_bps"`     // Basis/指数价格，单位基点（万分之一），指数价格为0时为0
+	LastMarkBps float64 `json:"last_mark_bps"` // (最新价 − 标记价格)/标记价格，单位基点，标记价格为0时为0
+}
+
+// newPriceContext 由最新价与premiumIndex计算基差，premium为nil时返回nil
+func newPriceContext(lastPrice float64, premium *PremiumIndex) *PriceContext {
+	if premium == nil {
+		return nil
+	}
+	pc := &PriceContext{
+		LastPrice:  lastPrice,
+		MarkPrice:  premium.MarkPrice,
+		IndexPrice: premium.IndexPrice,
+	}
+	if pc.MarkPrice > 0 && pc.IndexPrice > 0 {
+		pc.Basis = pc.MarkPrice - pc.IndexPrice
+		pc.BasisBps = pc.Basis / pc.IndexPrice * 1e4
+	}
+	if pc.MarkPrice > 0 {
+		pc.LastMarkBps = (lastPrice - pc.MarkPrice) / pc.MarkPrice * 1e4
+	}
+	return pc
+}
+
+// 基差历史：5m溢价指数K线最近60根（5小时）
+const (
+	basisHistoryInterval = "5m"
+	basisHistoryBars     = 60
+)
+
+// PremiumIndexKlineSource 可以获取溢价指数K线的数据源，币安REST数据源与FakeSource实现了该接口
+// 溢价指数K线的价格字段是（永续价格−指数价格）/指数价格，成交量字段为0
+type PremiumIndexKlineSource interface {
+	// PremiumIndexKlines 获取最近limit根溢价指数K线，按时间升序
+	PremiumIndexKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error)
+}
+
+// BasisHistory 溢价指数K线收盘值的统计，用于判断永续合约是否持续高于（或低于）指数
+type BasisHistory struct {
+	Interval      string    `json:"interval"`
+	Current       float64   `json:"current_bps"`    // 最后一根的溢价，单位基点
+	Mean          float64   `json:"mean_bps"`       // 窗口内平均溢价
+	Slope         float64   `json:"slope_bps"`      // 溢价的回归斜率（每根K线），正值表示溢价在扩大
+	Percentile    float64   `json:"percentile"`     // 当前溢价在窗口内的百分位（0~100）
+	PositiveShare float64   `json:"positive_share"` // 溢价为正的K线占比，接近1为持续升水、接近0为持续贴水
+	Values        []float64 `json:"values_bps"`     // 全部溢价，从旧到新
+}
+
+// CalculateBasisHistory 由溢价指数K线计算基差统计，没有K线时返回nil
+func CalculateBasisHistory(interval string, klines []Kline) *BasisHistory {
+	if len(klines) == 0 {
+		return nil
+	}
+	values := make([]float64, len(klines))
+	positive := 0
+	var sum float64
+	for i, k := range klines {
+		values[i] = k.Close * 1e4
+		sum += values[i]
+		if values[i] > 0 {
+			positive++
+		}
+	}
+	current := values[len(values)-1]
+	return &BasisHistory{
+		Interval:      interval,
+		Current:       current,
+		Mean:          sum / float64(len(values)),
+		Slope:         fitLine(nil, values).slope,
+		Percentile:    PercentileRank(values, current),
+		PositiveShare: float64(positive) / float64(len(values)),
+		Values:        values,
+	}
+}
+
+// getBasisHistory 获取5m溢价指数K线并计算基差统计，数据源不支持时返回错误
+func (c *Client) getBasisHistory(ctx context.Context, o getOptions, symbol string) (*BasisHistory, error) {
+	src, ok := c.source().(PremiumIndexKlineSource)
+	if !ok {
+		return nil, fmt.Errorf("数据源不支持溢价指数K线")
+	}
+	key := fmt.Sprintf("premiumKlines|%s|%s|%d", symbol, basisHistoryInterval, basisHistoryBars)
+	klines, err := cachedFetch(c, key, c.config().cacheTTL(basisHistoryInterval), o.bypassCache, func() ([]Kline, error) {
+		return src.PremiumIndexKlines(ctx, symbol, basisHistoryInterval, basisHistoryBars)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CalculateBasisHistory(basisHistoryInterval, klines), nil
+}
+
+// getPremiumIndexKlines 请求溢价指数K线，格式与普通K线相同
+func (c *Client) getPremiumIndexKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	url := fmt.Sprintf("%s?symbol=%s&interval=%s&limit=%d", c.apiEndpoint("/premiumIndexKlines"), symbol, interval, limit)
+	var rawData [][]interface{}
+	if err := c.getJSON(ctx, url, klinesWeight(limit), &rawData); err != nil {
+		return nil, err
+	}
+	return parseKlineRows(url, rawData, false)
+}
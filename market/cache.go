@@ -0,0 +1,160 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL 各周期K线（及同周期OI历史）的默认缓存时间，未收盘的K线仍在变化，周期越短缓存越短
+var defaultCacheTTL = map[string]time.Duration{
+	"1m":  10 * time.Second,
+	"3m":  20 * time.Second,
+	"5m":  30 * time.Second,
+	"15m": time.Minute,
+	"1h":  2 * time.Minute,
+	"4h":  5 * time.Minute,
+}
+
+// fundingHistoryTTL 资金费率历史每8小时才结算一次
+const fundingHistoryTTL = 5 * time.Minute
+
+// maxCacheEntries 超过该数量时写入前清理过期项
+const maxCacheEntries = 4096
+
+// CacheStats 缓存命中统计
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// ttlCache 并发安全的TTL缓存，缓存的切片会被多个调用方共享，只读使用
+type ttlCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	hits    int64
+	misses  int64
+	now     func() time.Time
+}
+
+func newTTLCache() *ttlCache {
+	return &ttlCache{
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && c.now().Before(entry.expires) {
+		c.hits++
+		return entry.value, true
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *ttlCache) set(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+}
+
+func (c *ttlCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// cacheTTL 返回周期对应的缓存时间，未配置的周期不缓存
+func (cfg clientConfig) cacheTTL(interval string) time.Duration {
+	if cfg.cacheDisabled {
+		return 0
+	}
+	return cfg.cacheTTLs[interval]
+}
+
+// cachedFetch 先查缓存，未命中（或bypass）时调用fetch并写回缓存
+func cachedFetch[T any](c *Client, key string, ttl time.Duration, bypass bool, fetch func() (T, error)) (T, error) {
+	if ttl > 0 && !bypass {
+		if v, ok := c.cache.get(key); ok {
+			return v.(T), nil
+		}
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	c.cache.set(key, value, ttl)
+	return value, nil
+}
+
+// cachedKlines 带缓存的K线获取
+func (c *Client) cachedKlines(ctx context.Context, o getOptions, symbol, interval string, limit int) ([]Kline, error) {
+	if klines, ok := c.liveKlines(symbol, interval, limit); ok {
+		return klines, nil
+	}
+	key := fmt.Sprintf("klines|%s|%s|%d", symbol, interval, limit)
+	cfg := c.config()
+	return cachedFetch(c, key, cfg.cacheTTL(interval), o.bypassCache, func() ([]Kline, error) {
+		if cfg.incremental {
+			return c.incrementalKlines(ctx, symbol, interval, limit, o.bypassCache)
+		}
+		return c.source().Klines(ctx, symbol, interval, limit)
+	})
+}
+
+// cachedOpenInterestHistory 带缓存的OI历史获取，按period使用同周期K线的缓存时间
+func (c *Client) cachedOpenInterestHistory(ctx context.Context, o getOptions, symbol, period string, limit int) ([]OIPoint, error) {
+	key := fmt.Sprintf("oiHist|%s|%s|%d", symbol, period, limit)
+	cfg := c.config()
+	return cachedFetch(c, key, cfg.cacheTTL(period), o.bypassCache, func() ([]OIPoint, error) {
+		if cfg.incremental {
+			return c.incrementalOpenInterestHistory(ctx, symbol, period, limit, o.bypassCache)
+		}
+		return c.source().OpenInterestHistory(ctx, symbol, period, limit)
+	})
+}
+
+// cachedFundingRateHistory 带缓存的资金费率历史获取
+func (c *Client) cachedFundingRateHistory(ctx context.Context, o getOptions, symbol string, limit int) ([]FundingRatePoint, error) {
+	key := fmt.Sprintf("funding|%s|%d", symbol, limit)
+	cfg := c.config()
+	ttl := fundingHistoryTTL
+	if cfg.cacheDisabled {
+		ttl = 0
+	}
+	return cachedFetch(c, key, ttl, o.bypassCache, func() ([]FundingRatePoint, error) {
+		if cfg.incremental {
+			return c.incrementalFundingRateHistory(ctx, symbol, limit, o.bypassCache)
+		}
+		return c.source().FundingRateHistory(ctx, symbol, limit)
+	})
+}
@@ -0,0 +1,268 @@
+package market
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Client 行情数据客户端
+// 每个Client持有独立的配置（根地址、HTTP客户端等），同一进程中可以并存多个不同配置的Client
+type Client struct {
+	mu      sync.RWMutex
+	cfg     clientConfig
+	limiter *weightLimiter
+	cache   *ttlCache
+	flight  singleflight.Group // 合并同一symbol的并发Get
+	series  *seriesStore       // 增量刷新保存的序列
+
+	streamMu     sync.RWMutex
+	klineStreams []*KlineStream // 实时K线订阅，Get优先从中取K线
+	depthStreams []*DepthStream // 盘口订阅，Get优先使用平滑后的OBI/MicroPrice
+	tradeStreams []*TradeStream // 成交流订阅，Get优先使用累加的CVD/OFI
+	// liquidationStreams 强平订阅，Get从中取Data.Liquidations
+	liquidationStreams []*LiquidationStream
+}
+
+// clientConfig 客户端配置，请求时按值取快照，避免持锁发起网络请求
+type clientConfig struct {
+	httpClient           *http.Client // nil表示使用带超时的专用Transport
+	timeouts             Timeouts
+	maxResponseBytes     int64        // 响应体（解压后）大小上限，<=0表示不限制
+	client               *http.Client // 叠加代理与拨号设置后实际使用的客户端，由buildHTTPClient生成
+	proxy                func(*http.Request) (*url.URL, error)
+	dialContext          DialContextFunc
+	baseURL              string // 空表示市场默认地址
+	market               MarketType
+	rateLimitRetries     int           // 遇到429时的最大重试次数
+	rateLimitMaxWait     time.Duration // 单次限频等待的上限，Retry-After超过该值时直接返回错误
+	retry                RetryPolicy
+	weightLimit          int // 每分钟请求权重上限，0表示不限流
+	limitMode            LimitMode
+	cacheTTLs            map[string]time.Duration // 按周期的缓存时间，写入时整体替换，不原地修改
+	cacheDisabled        bool
+	source               Source // nil表示币安REST接口
+	recorder             *recorder
+	replayer             *replayer
+	metrics              Metrics // nil表示不埋点
+	logger               Logger
+	slowRequest          time.Duration // 慢请求告警阈值，0表示不检查
+	tracer               Tracer        // nil表示不追踪
+	streamURL            string        // 空表示市场默认地址
+	incremental          bool          // 增量刷新K线、OI历史与资金费率历史
+	intervals            []string      // Get默认拉取的K线周期
+	exchangeInfoTTL      time.Duration
+	skipSymbolValidation bool
+}
+
+// RetryPolicy 瞬时错误（连接错误、超时、5xx）的重试策略，仅用于幂等的GET请求
+type RetryPolicy struct {
+	MaxAttempts int           // 总尝试次数（含首次），<=1表示不重试
+	BaseDelay   time.Duration // 首次重试前的等待，之后每次翻倍
+	MaxDelay    time.Duration // 单次等待上限，0表示不设上限
+	Jitter      float64       // 随机抖动比例，取值[0,1]，等待时间在 delay×(1±Jitter) 内随机
+}
+
+// DefaultRetryPolicy 默认重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.2,
+}
+
+// Timeouts HTTP请求的超时设置，0表示不限制
+type Timeouts struct {
+	Request        time.Duration // 单次请求的总时长（含读取响应体），每次重试单独计时
+	Dial           time.Duration // 建立TCP连接
+	TLSHandshake   time.Duration // TLS握手
+	ResponseHeader time.Duration // 请求发出后等待响应头
+}
+
+// DefaultTimeouts 默认超时，避免连接挂起时Get永远阻塞
+var DefaultTimeouts = Timeouts{
+	Request:        10 * time.Second,
+	Dial:           5 * time.Second,
+	TLSHandshake:   5 * time.Second,
+	ResponseHeader: 10 * time.Second,
+}
+
+// ClientOption 客户端配置项
+type ClientOption func(*clientConfig)
+
+// WithHTTPClient 指定HTTP客户端（可配置代理、连接池等），nil表示使用客户端自带的默认配置
+// 自定义客户端的Transport不会被设置连接/TLS/响应头超时，单次请求的总超时（WithTimeouts）依然生效
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.httpClient = hc
+	}
+}
+
+// WithTimeouts 设置HTTP请求的超时（默认见DefaultTimeouts），字段为0表示该项不限制
+func WithTimeouts(t Timeouts) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.timeouts = t
+	}
+}
+
+// WithBaseURL 指定REST API根地址（如测试网 https://testnet.binancefuture.com 或内部缓存代理），空字符串表示所选市场的默认地址
+// 根地址可以带路径前缀，/fapi/v1（币本位为 /dapi/v1）与 /futures/data 会拼接在其后
+func WithBaseURL(u string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.baseURL = strings.TrimRight(strings.TrimSpace(u), "/")
+	}
+}
+
+// WithRateLimitRetry 配置遇到HTTP 429时的重试策略：最多重试attempts次，每次按Retry-After等待
+// Retry-After超过maxWait时不再等待，直接返回*RateLimitError；attempts为0表示不重试
+// HTTP 418（IP已被封禁）永远不会重试
+func WithRateLimitRetry(attempts int, maxWait time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		if attempts < 0 {
+			attempts = 0
+		}
+		cfg.rateLimitRetries = attempts
+		cfg.rateLimitMaxWait = maxWait
+	}
+}
+
+// WithRetry 配置瞬时错误的重试策略，传RetryPolicy{}表示关闭重试
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(cfg *clientConfig) {
+		if policy.Jitter < 0 {
+			policy.Jitter = 0
+		}
+		if policy.Jitter > 1 {
+			policy.Jitter = 1
+		}
+		cfg.retry = policy
+	}
+}
+
+// WithWeightLimit 配置每分钟请求权重预算（默认2400，0表示关闭限流）及预算用尽时的处理方式
+func WithWeightLimit(perMinute int, mode LimitMode) ClientOption {
+	return func(cfg *clientConfig) {
+		if perMinute < 0 {
+			perMinute = 0
+		}
+		cfg.weightLimit = perMinute
+		cfg.limitMode = mode
+	}
+}
+
+// WithDefaultIntervals 设置Get默认拉取的K线周期（默认 1m/3m/15m/1h/4h），单次调用可用WithIntervals覆盖
+// 不支持的周期在Get时返回ErrUnsupportedInterval；传空列表恢复默认
+func WithDefaultIntervals(intervals ...string) ClientOption {
+	return func(cfg *clientConfig) {
+		if len(intervals) == 0 {
+			cfg.intervals = defaultIntervals
+			return
+		}
+		cfg.intervals = append([]string(nil), intervals...)
+	}
+}
+
+// WithCacheTTL 设置某个周期K线（及同周期OI历史）的缓存时间，ttl<=0表示该周期不缓存
+func WithCacheTTL(interval string, ttl time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		ttls := make(map[string]time.Duration, len(cfg.cacheTTLs)+1)
+		for k, v := range cfg.cacheTTLs {
+			ttls[k] = v
+		}
+		if ttl > 0 {
+			ttls[interval] = ttl
+		} else {
+			delete(ttls, interval)
+		}
+		cfg.cacheTTLs = ttls
+	}
+}
+
+// WithoutClientCache 关闭客户端的全部缓存
+func WithoutClientCache() ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.cacheDisabled = true
+	}
+}
+
+// NewClient 创建行情数据客户端
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		cfg: clientConfig{
+			timeouts:         DefaultTimeouts,
+			maxResponseBytes: DefaultMaxResponseBytes,
+			market:           USDTM,
+			exchangeInfoTTL:  defaultExchangeInfoTTL,
+			intervals:        defaultIntervals,
+			rateLimitRetries: 2,
+			rateLimitMaxWait: 10 * time.Second,
+			retry:            DefaultRetryPolicy,
+			weightLimit:      DefaultWeightLimit,
+			limitMode:        LimitBlock,
+			cacheTTLs:        defaultCacheTTL,
+			logger:           nopLogger{},
+			slowRequest:      defaultSlowRequestThreshold,
+		},
+		limiter: newWeightLimiter(),
+		cache:   newTTLCache(),
+		series:  newSeriesStore(),
+	}
+	for _, opt := range opts {
+		opt(&c.cfg)
+	}
+	c.cfg.buildHTTPClient()
+	return c
+}
+
+// defaultClient 包级函数（Get、GetWithContext等）使用的默认客户端
+var defaultClient = NewClient()
+
+// SetHTTPClient 设置默认客户端使用的HTTP客户端，传nil恢复默认配置
+func SetHTTPClient(client *http.Client) {
+	defaultClient.apply(WithHTTPClient(client))
+}
+
+// SetBaseURL 设置默认客户端的REST API根地址，传空字符串恢复默认地址
+func SetBaseURL(u string) {
+	defaultClient.apply(WithBaseURL(u))
+}
+
+// apply 在锁保护下修改已创建客户端的配置
+func (c *Client) apply(opts ...ClientOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old, oldHTTPClient := c.cfg.client, c.cfg.httpClient
+	for _, opt := range opts {
+		opt(&c.cfg)
+	}
+	c.cfg.buildHTTPClient()
+	// 替换掉的专用Transport不再使用，释放其空闲连接（调用方传入的客户端不做处理）
+	if old != nil && old != c.cfg.client && old != oldHTTPClient {
+		old.CloseIdleConnections()
+	}
+}
+
+// config 返回当前配置的快照
+func (c *Client) config() clientConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// Stats 客户端运行统计
+type Stats struct {
+	Weight WeightStats
+	Cache  CacheStats
+}
+
+// Stats 返回当前的运行统计
+func (c *Client) Stats() Stats {
+	return Stats{
+		Weight: c.limiter.stats(c.config().weightLimit),
+		Cache:  c.cache.stats(),
+	}
+}
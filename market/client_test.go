@@ -0,0 +1,43 @@
+package market
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithBaseURLRoutesEveryRequest(t *testing.T) {
+	const prefix = "/mirror/binance"
+	fake := &fakeBinance{}
+	var stray []string
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			stray = append(stray, r.URL.Path)
+			http.Error(w, "unexpected path", http.StatusNotFound)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+
+	// 开启symbol校验，让exchangeInfo也经过根地址
+	c := NewClient(WithBaseURL(srv.URL+prefix), WithRetry(RetryPolicy{}))
+	data, err := c.Get(context.Background(), "BTCUSDT", WithStrict())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(stray) > 0 {
+		t.Fatalf("requests outside the base URL: %v", stray)
+	}
+	if data.SymbolInfo == nil {
+		t.Error("SymbolInfo = nil, exchangeInfo was not served through the base URL")
+	}
+	for _, endpoint := range []string{
+		"/fapi/v1/exchangeInfo", "/fapi/v1/klines", "/fapi/v1/openInterest", "/futures/data/openInterestHist",
+		"/fapi/v1/premiumIndex", "/fapi/v1/fundingRate", "/fapi/v1/aggTrades", "/fapi/v1/depth",
+	} {
+		if fake.Count(prefix+endpoint) == 0 {
+			t.Errorf("no request to %s%s", prefix, endpoint)
+		}
+	}
+}
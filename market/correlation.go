@@ -0,0 +1,150 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// 与BTC的相关性与beta：使用1h K线最近100个对数收益率
+const (
+	benchmarkBase       = "BTC"
+	benchmarkInterval   = "1h"
+	benchmarkReturns    = 100
+	benchmarkMinReturns = 20 // 对齐后的收益率少于该数量时不计算
+)
+
+// CorrelationBeta 按开盘时间对齐klines与benchmark，用最近n个双方都有的相邻K线对数收益率计算
+// Pearson相关系数与beta（cov(symbol, benchmark) / var(benchmark)），返回实际使用的收益率数量
+// 对齐后少于20个收益率时返回包装了ErrInsufficientData的错误，benchmark收益率方差为0时同样返回错误
+func CorrelationBeta(klines, benchmark []Kline, n int) (correlation, beta float64, returns int, err error) {
+	benchClose := make(map[int64]float64, len(benchmark))
+	for _, k := range benchmark {
+		benchClose[k.OpenTime] = k.Close
+	}
+
+	var xs, ys []float64 // xs为benchmark收益率，ys为symbol收益率
+	for i := 1; i < len(klines); i++ {
+		prev, cur := klines[i-1], klines[i]
+		benchPrev, ok1 := benchClose[prev.OpenTime]
+		benchCur, ok2 := benchClose[cur.OpenTime]
+		if !ok1 || !ok2 || prev.Close <= 0 || cur.Close <= 0 || benchPrev <= 0 || benchCur <= 0 {
+			continue
+		}
+		xs = append(xs, math.Log(benchCur/benchPrev))
+		ys = append(ys, math.Log(cur.Close/prev.Close))
+	}
+	if len(xs) > n {
+		xs, ys = xs[len(xs)-n:], ys[len(ys)-n:]
+	}
+	if len(xs) < benchmarkMinReturns {
+		return 0, 0, len(xs), fmt.Errorf("%w: 与基准对齐的收益率只有%d个，至少需要%d个", ErrInsufficientData, len(xs), benchmarkMinReturns)
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 {
+		return 0, 0, len(xs), fmt.Errorf("基准收益率方差为0，无法计算beta")
+	}
+	beta = cov / varX
+	if varY > 0 {
+		correlation = cov / math.Sqrt(varX*varY)
+	}
+	return correlation, beta, len(xs), nil
+}
+
+// benchmarkSymbol 当前市场与计价资产下BTC的交易对
+func (o getOptions) benchmarkSymbol() string {
+	symbol, err := o.normalize(benchmarkBase)
+	if err != nil {
+		return ""
+	}
+	return symbol
+}
+
+// getBenchmarkStats 计算symbol与BTC的1h收益率相关性与beta；两者的1h K线都走缓存，
+// 同一批次（GetMany）的其它币种共用BTC的K线。symbol本身为BTC时返回nil, nil
+func (c *Client) getBenchmarkStats(ctx context.Context, o getOptions, symbol string, klines1h []Kline) (correlation, beta *float64, err error) {
+	benchmark := o.benchmarkSymbol()
+	if benchmark == "" || benchmark == symbol {
+		return nil, nil, nil
+	}
+	limit := o.klineLimit(benchmarkInterval)
+	if klines1h == nil {
+		if klines1h, err = c.cachedKlines(ctx, o, symbol, benchmarkInterval, limit); err != nil {
+			return nil, nil, fmt.Errorf("获取%s K线失败: %w", benchmarkInterval, err)
+		}
+	}
+	benchKlines, err := c.cachedKlines(ctx, o, benchmark, benchmarkInterval, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取%s %s K线失败: %w", benchmark, benchmarkInterval, err)
+	}
+	corr, b, _, err := CorrelationBeta(klines1h, benchKlines, benchmarkReturns)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &corr, &b, nil
+}
+
+// relativeStrengthBases 计算相对强弱的基准资产
+var relativeStrengthBases = []string{"BTC", "ETH"}
+
+// RelativeStrength 相对强弱：symbol的涨跌幅减去基准的涨跌幅（百分点），均由1h K线计算
+type RelativeStrength struct {
+	VsBTC1h  float64 `json:"vs_btc_1h"`
+	VsBTC4h  float64 `json:"vs_btc_4h"`
+	VsBTC24h float64 `json:"vs_btc_24h"`
+	VsETH1h  float64 `json:"vs_eth_1h"`
+	VsETH4h  float64 `json:"vs_eth_4h"`
+	VsETH24h float64 `json:"vs_eth_24h"`
+}
+
+// getRelativeStrength 计算symbol相对BTC与ETH在1h/4h/24h内的涨跌幅之差，K线走缓存（GetMany中各币种共用基准K线）
+func (c *Client) getRelativeStrength(ctx context.Context, o getOptions, symbol string, klines1h []Kline) (*RelativeStrength, error) {
+	limit := o.klineLimit(benchmarkInterval)
+	if klines1h == nil {
+		var err error
+		if klines1h, err = c.cachedKlines(ctx, o, symbol, benchmarkInterval, limit); err != nil {
+			return nil, fmt.Errorf("获取%s K线失败: %w", benchmarkInterval, err)
+		}
+	}
+
+	rs := &RelativeStrength{}
+	targets := [][]*float64{
+		{&rs.VsBTC1h, &rs.VsBTC4h, &rs.VsBTC24h},
+		{&rs.VsETH1h, &rs.VsETH4h, &rs.VsETH24h},
+	}
+	for i, base := range relativeStrengthBases {
+		benchmark, err := o.normalize(base)
+		if err != nil {
+			return nil, err
+		}
+		benchKlines, err := c.cachedKlines(ctx, o, benchmark, benchmarkInterval, limit)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s %s K线失败: %w", benchmark, benchmarkInterval, err)
+		}
+		for j, hours := range []int{1, 4, 24} {
+			*targets[i][j] = ROC(klines1h, hours) - ROC(benchKlines, hours)
+		}
+	}
+	return rs, nil
+}
+
+// formatRelativeStrength Format中的相对强弱，每个基准一行
+func formatRelativeStrength(rs *RelativeStrength) string {
+	return fmt.Sprintf("RS vs BTC (1h/4h/24h): %+.2f%% / %+.2f%% / %+.2f%%\n\nRS vs ETH (1h/4h/24h): %+.2f%% / %+.2f%% / %+.2f%%\n\n",
+		rs.VsBTC1h, rs.VsBTC4h, rs.VsBTC24h, rs.VsETH1h, rs.VsETH4h, rs.VsETH24h)
+}
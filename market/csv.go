@@ -0,0 +1,159 @@
+package market
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// CSVColumn ExportCSV可选的指标列
+type CSVColumn string
+
+const (
+	CSVEMA20 CSVColumn = "ema_20"
+	CSVEMA60 CSVColumn = "ema_60"
+	CSVRSI7  CSVColumn = "rsi_7"
+	CSVRSI14 CSVColumn = "rsi_14"
+	CSVMACD  CSVColumn = "macd"
+	CSVATR14 CSVColumn = "atr_14"
+)
+
+// DefaultCSVColumns ExportCSV默认输出的指标列
+var DefaultCSVColumns = []CSVColumn{CSVEMA20, CSVRSI7, CSVRSI14, CSVMACD}
+
+// CSVOptions ExportCSV配置
+type CSVOptions struct {
+	Columns    []CSVColumn // 指标列，为空时使用DefaultCSVColumns
+	TimeFormat string      // open_time的时间格式（如time.RFC3339，UTC），为空时输出毫秒时间戳
+}
+
+// csvIndicators 各指标列的序列计算，预热区为NaN
+var csvIndicators = map[CSVColumn]func(klines []Kline) []float64{
+	CSVEMA20: func(k []Kline) []float64 { return EMASeries(k, 20) },
+	CSVEMA60: func(k []Kline) []float64 { return EMASeries(k, 60) },
+	CSVRSI7:  func(k []Kline) []float64 { return RSISeries(k, 7) },
+	CSVRSI14: func(k []Kline) []float64 { return RSISeries(k, 14) },
+	CSVMACD: func(k []Kline) []float64 {
+		macd, _, _ := MACDSeries(k, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+		return macd
+	},
+	CSVATR14: func(k []Kline) []float64 { return ATRSeries(k, 14) },
+}
+
+// ExportCSV 将K线及逐根计算的指标写为CSV
+// 指标在预热期内（数据不足）输出空单元格而不是0
+func ExportCSV(w io.Writer, klines []Kline, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	for _, col := range columns {
+		if _, ok := csvIndicators[col]; !ok {
+			return fmt.Errorf("不支持的CSV指标列: %s", col)
+		}
+	}
+
+	series := make([][]float64, len(columns))
+	for j, col := range columns {
+		series[j] = csvIndicators[col](klines)
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{"open_time", "open", "high", "low", "close", "volume"}
+	for _, col := range columns {
+		header = append(header, string(col))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for i, k := range klines {
+		openTime := strconv.FormatInt(k.OpenTime, 10)
+		if opts.TimeFormat != "" {
+			openTime = time.UnixMilli(k.OpenTime).UTC().Format(opts.TimeFormat)
+		}
+		record := []string{
+			openTime,
+			formatCSVFloat(k.Open),
+			formatCSVFloat(k.High),
+			formatCSVFloat(k.Low),
+			formatCSVFloat(k.Close),
+			formatCSVFloat(k.Volume),
+		}
+		for j := range columns {
+			if math.IsNaN(series[j][i]) {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, formatCSVFloat(series[j][i]))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// IntradayCSV 将日内序列（3分钟）写为CSV，各序列按最新一根右对齐，缺失的早期值为空单元格
+func (d *Data) IntradayCSV(w io.Writer) error {
+	if d.IntradaySeries == nil {
+		return fmt.Errorf("%s 没有日内序列数据", d.Symbol)
+	}
+	series := d.IntradaySeries
+
+	columns := []struct {
+		name   string
+		values []float64
+	}{
+		{"mid_price", series.MidPrices},
+		{"ema_20", series.EMA20Values},
+		{"macd", series.MACDValues},
+		{"macd_signal", series.MACDSignalValues},
+		{"macd_histogram", series.MACDHistogramValues},
+		{"rsi_7", series.RSI7Values},
+		{"rsi_14", series.RSI14Values},
+	}
+
+	rows := 0
+	for _, col := range columns {
+		if len(col.values) > rows {
+			rows = len(col.values)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{"index"}
+	for _, col := range columns {
+		header = append(header, col.name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < rows; i++ {
+		record := []string{strconv.Itoa(i)}
+		for _, col := range columns {
+			offset := rows - len(col.values)
+			if i < offset {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, formatCSVFloat(col.values[i-offset]))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -1,11 +1,13 @@
 package market
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"math"
-	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,459 +15,1129 @@ import (
 )
 
 // Data 市场数据结构
+// Client.Get 可能把同一个*Data交给多个并发调用方，使用方不应修改其内容
+// JSON字段名（snake_case）是对外契约，其他服务依赖该格式：只允许新增字段，不要改名或改变含义；
+// 可选分区（OI、资金费率、微结构、日内与长期序列）未获取时省略
 type Data struct {
-	Symbol            string
-	CurrentPrice      float64
-	PriceChange1h     float64 // 1小时价格变化百分比
-	PriceChange4h     float64 // 4小时价格变化百分比
-	CurrentEMA20      float64
-	CurrentMACD       float64
-	CurrentRSI7       float64
-	OpenInterest      *OIData
-	Funding           *FundingData
-	Timeframes        map[string]*TimeframeMetrics
-	Microstructure    *MicrostructureData
-	IntradaySeries    *IntradayData
-	LongerTermContext *LongerTermData
+	Symbol        string       `json:"symbol"`                // 交易所使用的交易对，如1000SHIBUSDT
+	Alias         string       `json:"alias,omitempty"`       // 调用方使用的名称（如SHIBUSDT），与Symbol相同时为空
+	Multiplier    float64      `json:"multiplier,omitempty"`  // 一个合约单位对应的标的数量，1000SHIBUSDT为1000，价格为Multiplier个标的的价格
+	Market        MarketType   `json:"market,omitempty"`      // 数据所属市场，空表示U本位合约
+	SymbolInfo    *SymbolInfo  `json:"symbol_info,omitempty"` // 交易对元数据（最小价格/数量单位等），exchangeInfo不可用时为nil
+	CurrentPrice  float64      `json:"current_price"`
+	PriceChange1h float64      `json:"price_change_1h"` // 1小时价格变化百分比（优先用1m计算，未请求时退回其它周期）
+	PriceChange4h float64      `json:"price_change_4h"` // 4小时价格变化百分比（优先用1h计算，未请求时退回其它周期）
+	CurrentEMA20  float64      `json:"current_ema_20"`
+	CurrentMACD   float64      `json:"current_macd"`
+	CurrentRSI7   float64      `json:"current_rsi_7"`
+	OpenInterest  *OIData      `json:"open_interest,omitempty"`
+	Funding       *FundingData `json:"funding,omitempty"`
+	DailyPivots   *Pivots      `json:"daily_pivots,omitempty"`  // 上一个UTC日的枢轴点，1h/4h/15m K线不足以覆盖时为nil
+	WeeklyPivots  *Pivots      `json:"weekly_pivots,omitempty"` // 上一个UTC周（周一起）的枢轴点
+	// Ticker24h 最近24小时的滚动行情统计（/ticker/24hr），见WithTicker24h；未指定或数据源不支持时为nil
+	Ticker24h *Ticker24h `json:"ticker_24h,omitempty"`
+	// PriceContext 标记价格、指数价格与基差，来自资金费率所用的premiumIndex，未获取资金费率时为nil
+	PriceContext *PriceContext `json:"price_context,omitempty"`
+	// BasisHistory 最近60根5m溢价指数K线的基差统计，见WithBasisHistory
+	BasisHistory *BasisHistory `json:"basis_history,omitempty"`
+	// Sentiment 全部账户与大户的多空比及全市场主动买卖量比，见WithSentiment
+	Sentiment *SentimentData `json:"sentiment,omitempty"`
+	// CorrelationBTC 与BetaBTC 最近100根1h K线对数收益率相对BTC的Pearson相关系数与beta，见WithBTCCorrelation
+	CorrelationBTC *float64 `json:"correlation_btc,omitempty"`
+	BetaBTC        *float64 `json:"beta_btc,omitempty"`
+	// Regime 各周期趋势状态按√(周期分钟数)加权的综合状态，RegimeScore为加权分值（−2强下跌 ~ 2强上涨）
+	Regime      Regime  `json:"regime,omitempty"`
+	RegimeScore float64 `json:"regime_score"`
+	// RelativeStrength 相对BTC、ETH在1h/4h/24h内的涨跌幅之差，见WithRelativeStrength
+	RelativeStrength *RelativeStrength `json:"relative_strength,omitempty"`
+	// CandlePatterns 15m/1h/4h最近3根已收盘K线上识别出的形态
+	CandlePatterns []PatternHit `json:"candle_patterns,omitempty"`
+	// VolumeProfile 最近24小时（见WithVolumeProfile）1m或3m K线的成交量分布剖面，两个周期都未获取时为nil
+	VolumeProfile     *VolumeProfile               `json:"volume_profile,omitempty"`
+	Timeframes        map[string]*TimeframeMetrics `json:"timeframes"`
+	Microstructure    *MicrostructureData          `json:"microstructure,omitempty"`
+	IntradaySeries    *IntradayData                `json:"intraday_series,omitempty"`
+	LongerTermContext *LongerTermData              `json:"longer_term_context,omitempty"`
+	// Liquidations 最近5m/15m/1h的强平统计，只在该symbol有LiquidationStream订阅时存在
+	Liquidations *LiquidationData `json:"liquidations,omitempty"`
+	// RawKlines 本次获取的各周期K线（key为周期），只在WithRawKlines时附带；与其它调用方共享，只读
+	RawKlines map[string][]Kline `json:"raw_klines,omitempty"`
+	// UnavailableSections 获取失败的分区，对应字段为零值（或nil），不代表真实数值
+	UnavailableSections []Section `json:"unavailable_sections,omitempty"`
+
+	klines           map[string][]Kline // 本次获取的K线，不序列化；从快照加载时取自RawKlines（未附带时为空）
+	swingATRMultiple float64            // FibRetracement的显著波段阈值，见WithSwingThreshold
+}
+
+// baseTimeframe CurrentPrice等字段所用周期的指标：优先3m，否则为最短的周期；没有时返回nil
+func (d *Data) baseTimeframe() *TimeframeMetrics {
+	if tf, ok := d.Timeframes[baseInterval]; ok {
+		return tf
+	}
+	intervals := make([]string, 0, len(d.Timeframes))
+	for interval := range d.Timeframes {
+		intervals = append(intervals, interval)
+	}
+	return d.Timeframes[finestInterval(intervals)]
+}
+
+// AnchoredVWAP 用本次获取的interval周期K线计算从锚点（毫秒时间戳）起的VWAP
+// 未获取该周期（或Data来自不带RawKlines的快照）时返回错误，锚点超出K线范围时返回ErrAnchorOutOfRange
+func (d *Data) AnchoredVWAP(interval string, anchorMs int64) (float64, error) {
+	klines, ok := d.klines[interval]
+	if !ok {
+		return 0, fmt.Errorf("%s 没有%s周期的K线", d.Symbol, interval)
+	}
+	return AnchoredVWAP(klines, anchorMs)
+}
+
+// DisplaySymbol 便于阅读的名称：有别名时为 "SHIBUSDT (1000SHIBUSDT)"，否则为Symbol
+func (d *Data) DisplaySymbol() string {
+	if d.Alias == "" || d.Alias == d.Symbol {
+		return d.Symbol
+	}
+	return d.Alias + " (" + d.Symbol + ")"
+}
+
+// UnderlyingPrice 将合约价格换算为单个标的资产的价格（1000SHIBUSDT的价格除以1000）
+// Multiplier未设置时（如从旧快照加载）原样返回
+func (d *Data) UnderlyingPrice(price float64) float64 {
+	if d.Multiplier <= 0 {
+		return price
+	}
+	return price / d.Multiplier
 }
 
 // FundingData 资金费率与斜率数据
 type FundingData struct {
-	Rate       float64
-	Slope      float64
-	NextTimeMs int64
+	Rate          float64 `json:"rate"`     // 即PredictedRate，保留以兼容旧的调用方
+	Slope         float64 `json:"slope"`    // 最近8次结算费率的最小二乘回归斜率（每小时）
+	SlopeR2       float64 `json:"slope_r2"` // 回归的决定系数（0~1），越接近1趋势越可信；费率全部相同或不足2次结算时为0
+	NextTimeMs    int64   `json:"next_funding_time_ms"`
+	APR           float64 `json:"apr"`            // 年化费率（小数），按实际结算周期换算，8小时结算即 Rate×3×365
+	Percentile30d float64 `json:"percentile_30d"` // Rate在最近30天结算费率中的百分位（0~100），没有历史时为0
+	History30d    int     `json:"history_30d"`    // 最近30天的结算次数，即Percentile30d的样本数
+	// Stats 统计窗口（默认30天，见WithFundingWindow）内已结算费率的均值、标准差与极值，没有历史时为nil
+	Stats *FundingStats `json:"stats,omitempty"`
+	// Streak 最近连续同号的结算次数，正费率为正、负费率为负
+	Streak int `json:"streak"`
+	// PredictedRate 下次结算的实时预测费率（premiumIndex的lastFundingRate），结算前会随溢价持续变化
+	PredictedRate float64 `json:"predicted_rate"`
+	// InterestRate 预测费率中的利率部分（premiumIndex的interestRate），其余为溢价部分
+	InterestRate float64 `json:"interest_rate"`
+	// LastSettledRate 最近一次已结算的费率，LastSettledTimeMs为其结算时间，没有历史时两者都为0
+	LastSettledRate   float64 `json:"last_settled_rate"`
+	LastSettledTimeMs int64   `json:"last_settled_time_ms"`
 }
 
 // OIData Open Interest数据
 type OIData struct {
-	Latest        float64
-	Average       float64
-	Delta5m       float64
-	Delta15m      float64
-	Delta1h       float64
-	Delta4h       float64
-	PriceDelta5m  float64
-	PriceDelta15m float64
-	PriceDelta1h  float64
-	PriceDelta4h  float64
-	TimestampMs   int64
+	Latest        float64 `json:"latest"`
+	Average       float64 `json:"average"` // 本次获取的4h历史（最多30天）的平均值，没有历史时等于Latest
+	Delta5m       float64 `json:"delta_5m"`
+	Delta15m      float64 `json:"delta_15m"`
+	Delta1h       float64 `json:"delta_1h"`
+	Delta4h       float64 `json:"delta_4h"`
+	PriceDelta5m  float64 `json:"price_delta_5m"`
+	PriceDelta15m float64 `json:"price_delta_15m"`
+	PriceDelta1h  float64 `json:"price_delta_1h"`
+	PriceDelta4h  float64 `json:"price_delta_4h"`
+	TimestampMs   int64   `json:"timestamp_ms"`
+	// 以下为美元名义价值，便于跨symbol比较；Average与各Delta按当前价格换算
+	LatestUSD   float64 `json:"latest_usd"`
+	AverageUSD  float64 `json:"average_usd"`
+	Delta5mUSD  float64 `json:"delta_5m_usd"`
+	Delta15mUSD float64 `json:"delta_15m_usd"`
+	Delta1hUSD  float64 `json:"delta_1h_usd"`
+	Delta4hUSD  float64 `json:"delta_4h_usd"`
+	// 最后一步OI变化相对前值的百分比，以及同一时间段（两个OI历史点的时间戳之间）的价格变化百分比
+	Delta5mPct       float64 `json:"delta_5m_pct"`
+	Delta15mPct      float64 `json:"delta_15m_pct"`
+	Delta1hPct       float64 `json:"delta_1h_pct"`
+	Delta4hPct       float64 `json:"delta_4h_pct"`
+	PriceDelta5mPct  float64 `json:"price_delta_5m_pct"`
+	PriceDelta15mPct float64 `json:"price_delta_15m_pct"`
+	PriceDelta1hPct  float64 `json:"price_delta_1h_pct"`
+	PriceDelta4hPct  float64 `json:"price_delta_4h_pct"`
+	// Regimes 各窗口（5m/15m/1h/4h）的OI与价格组合状态，阈值见WithOIRegimeThresholds；价格不可用的窗口缺省
+	Regimes map[string]OIRegime `json:"regimes,omitempty"`
+	// Volume24h 最近24小时成交量（基础资产数量），取自Data.Ticker24h（WithTicker24h）；未获取ticker时由K线汇总，周期不足以覆盖24小时时为0
+	Volume24h float64 `json:"volume_24h"`
+	// OIToVolume24h 持仓量÷24小时成交量，数值越大说明持仓相对成交越拥挤；Volume24h为0时为0
+	OIToVolume24h float64 `json:"oi_to_volume_24h"`
+	// OIPerPricePct4h 最近4h的OI变化（美元）÷同期价格变化百分比，即推动价格变动1%所伴随的持仓变化；
+	// 价格变化低于OI状态的价格噪声阈值或价格不可用时为0
+	OIPerPricePct4h float64 `json:"oi_per_price_pct_4h"`
+	// Percentile30d Latest在最近30天4h持仓量中的百分位（0~100），接近100表示处于30天高位；没有历史时为0
+	Percentile30d float64 `json:"percentile_30d"`
+	// 各周期的持仓量历史（基础资产数量，按时间升序），获取失败时为空
+	History5m  []OIPoint `json:"history_5m,omitempty"`
+	History15m []OIPoint `json:"history_15m,omitempty"`
+	History1h  []OIPoint `json:"history_1h,omitempty"`
+	History4h  []OIPoint `json:"history_4h,omitempty"`
 }
 
 // TimeframeMetrics 多周期指标
 type TimeframeMetrics struct {
-	Interval       string
-	Close          float64
-	RSI7           float64
-	RSI14          float64
-	MACD           float64
-	EMA20          float64
-	EMA60          float64
-	BollingerWidth float64
-	ATR14          float64
-	RealizedVol20  float64
-	CurrentVolume  float64
-	AverageVolume  float64
+	Interval                string          `json:"interval"`
+	Close                   float64         `json:"close"`
+	ROC                     map[int]float64 `json:"roc"` // 按回看K线数的变动率（%），默认5/10/20根，见WithROCLookbacks
+	RSI7                    float64         `json:"rsi_7"`
+	RSI14                   float64         `json:"rsi_14"`
+	MACD                    float64         `json:"macd"`
+	MACDSignal              float64         `json:"macd_signal"`    // MACD线的9期EMA
+	MACDHistogram           float64         `json:"macd_histogram"` // MACD − Signal
+	EMA20                   float64         `json:"ema_20"`
+	EMA60                   float64         `json:"ema_60"`
+	WarmingUp               []string        `json:"warming_up,omitempty"`     // K线不足、仍处于预热区的字段（rsi_7/rsi_14/macd/ema_20/ema_60/atr_14），这些字段的0不是有效值
+	Crosses                 CrossState      `json:"crosses"`                  // EMA20/EMA60、MACD/信号线、MACD/零轴的位置与最近一次交叉
+	HeikinAshiStreak        int             `json:"heikin_ashi_streak"`       // 连续同色HA K线的根数，阳线为正、阴线为负
+	HeikinAshiStrong        int             `json:"heikin_ashi_strong"`       // 最后一根HA：无下影阳线为+1，无上影阴线为−1，否则为0
+	CandleStats             *CandleStats    `json:"candle_stats,omitempty"`   // 最近20根K线的实体/影线统计
+	HMA20                   float64         `json:"hma_20"`                   // 赫尔移动平均(20)，比EMA20滞后小，K线少于23根时为0
+	DEMA20                  float64         `json:"dema_20"`                  // 双重EMA(20)，K线少于39根时为0
+	TEMA20                  float64         `json:"tema_20"`                  // 三重EMA(20)，K线少于58根时为0
+	BollingerWidth          float64         `json:"bollinger_width"`          // 同Bollinger.Width，保留以兼容旧字段
+	Bollinger               BollingerBands  `json:"bollinger"`                // 20期、2倍标准差
+	KeltnerUpper            float64         `json:"keltner_upper"`            // EMA20 + 1.5×ATR20
+	KeltnerLower            float64         `json:"keltner_lower"`            // EMA20 − 1.5×ATR20
+	SqueezeOn               bool            `json:"squeeze_on"`               // 布林带完全落在肯特纳通道内（TTM挤压）
+	SqueezeBars             int             `json:"squeeze_bars"`             // 截至最后一根已连续挤压的K线数，未挤压时为0
+	StochK                  float64         `json:"stoch_k"`                  // 随机指标%K（14/3/3）
+	StochD                  float64         `json:"stoch_d"`                  // 随机指标%D（14/3/3）
+	ADX14                   float64         `json:"adx_14"`                   // 趋势强度，K线少于28根时为0
+	PlusDI                  float64         `json:"plus_di"`                  // +DI(14)
+	MinusDI                 float64         `json:"minus_di"`                 // −DI(14)
+	WilliamsR14             float64         `json:"williams_r_14"`            // 威廉指标%R，−100~0，数据不足时为0
+	CCI20                   float64         `json:"cci_20"`                   // 顺势指标（0.015×平均绝对偏差缩放）
+	UltimateOscillator      float64         `json:"ultimate_oscillator"`      // 终极振荡指标(7/14/28)，0~100
+	ForceIndex13            float64         `json:"force_index_13"`           // Elder强力指数：(收盘−前收盘)×成交量的EMA13
+	ElderImpulse            ImpulseColor    `json:"elder_impulse,omitempty"`  // Elder动力系统：green/red/blue，数据不足时为空
+	AroonUp                 float64         `json:"aroon_up"`                 // 阿隆上线(25)，100表示当前K线创新高
+	AroonDown               float64         `json:"aroon_down"`               // 阿隆下线(25)，100表示当前K线创新低
+	AroonOscillator         float64         `json:"aroon_oscillator"`         // AroonUp − AroonDown
+	OBV                     float64         `json:"obv"`                      // 能量潮（从本次K线的第一根起累计）
+	OBVSlope                float64         `json:"obv_slope"`                // 最近20根OBV的回归斜率（每根K线），与价格方向相反时为背离
+	Choppiness14            float64         `json:"choppiness_14"`            // 震荡指数(14)，接近100为横盘、接近0为单边
+	Regime                  Regime          `json:"regime,omitempty"`         // 趋势状态（见ClassifyRegime），数据不足时为空
+	TrendSlope              float64         `json:"trend_slope"`              // 最近20根收盘价的回归斜率（每根K线的价格变化）
+	TrendR2                 float64         `json:"trend_r2"`                 // 最近20根收盘价回归的R²
+	Trend                   LinReg          `json:"trend"`                    // 最近20根收盘价的完整回归结果（含%斜率与±2倍标准误通道）
+	HurstExponent           *HurstEstimate  `json:"hurst_exponent,omitempty"` // 只在1h与4h周期计算，K线少于100根时为nil
+	Renko                   *RenkoSummary   `json:"renko,omitempty"`          // 以ATR14为砖块大小的砖形图摘要，仅1m/3m
+	CMF20                   float64         `json:"cmf_20"`                   // 蔡金资金流(20)，−1~1，正值表示资金流入
+	VWAP                    float64         `json:"vwap"`                     // UTC当日会话VWAP
+	VWAPDistancePct         float64         `json:"vwap_distance_pct"`        // 收盘价相对会话VWAP的偏离百分比
+	RollingVWAP20           float64         `json:"rolling_vwap_20"`          // 最近20根K线的VWAP
+	PriceZScoreEMA20        float64         `json:"price_z_score_ema20"`      // (收盘−EMA20)/最近20根收盘价标准差，横盘时为0
+	PriceZScoreVWAP         float64         `json:"price_z_score_vwap"`       // (收盘−会话VWAP)/最近20根收盘价标准差，横盘时为0
+	ATR14                   float64         `json:"atr_14"`
+	ATRPercent              float64         `json:"atr_percent"`                 // ATR14占收盘价的百分比，可跨币种比较
+	ATRPercentile           float64         `json:"atr_percentile"`              // 当前ATR14在本次全部K线的ATR14序列中的百分位（0~100）
+	SuperTrend              float64         `json:"super_trend"`                 // 超级趋势（ATR10、3倍）的当前价位
+	SuperTrendDirection     int             `json:"super_trend_direction"`       // +1多头、−1空头，数据不足时为0
+	SuperTrendBarsSinceFlip int             `json:"super_trend_bars_since_flip"` // 自上次方向翻转以来的K线数
+	DonchianUpper           float64         `json:"donchian_upper"`              // 最近20根K线的最高价
+	DonchianLower           float64         `json:"donchian_lower"`              // 最近20根K线的最低价
+	DonchianMid             float64         `json:"donchian_mid"`
+	DonchianUpperDistATR    float64         `json:"donchian_upper_dist_atr"` // 收盘价距上轨的ATR14倍数，0表示处于20根新高
+	DonchianLowerDistATR    float64         `json:"donchian_lower_dist_atr"` // 收盘价距下轨的ATR14倍数，0表示处于20根新低
+	RealizedVol20           float64         `json:"realized_vol_20"`         // 最近20根K线对数收益率的标准差（每根K线，未年化）
+	RealizedVolAnnualized   float64         `json:"realized_vol_annualized"` // RealizedVol20按周期年化（×√每年K线数，全年365天）
+	RVPercentile            float64         `json:"rv_percentile"`           // 当前RealizedVol20在本次全部K线的滚动序列中的百分位（0~100）
+	ParkinsonVol            float64         `json:"parkinson_vol"`           // 最近20根K线的Parkinson波动率（高低价），已年化
+	GKVol                   float64         `json:"gk_vol"`                  // 最近20根K线的Garman-Klass波动率（开高低收），已年化
+	CurrentVolume           float64         `json:"current_volume"`          // 最后一根K线（可能尚未收盘）的成交量
+	AverageVolume           float64         `json:"average_volume"`          // 之前20根已完成K线的平均成交量
+	VolumeZScore            float64         `json:"volume_z_score"`          // 当前成交量相对之前20根已完成K线的z-score
+	VolumeSpike             bool            `json:"volume_spike"`            // VolumeZScore达到放量阈值（默认2.5，见WithVolumeSpikeThreshold）
 }
 
 // MicrostructureData 微结构指标
 type MicrostructureData struct {
-	CVD1m      float64
-	CVD3m      float64
-	CVD15m     float64
-	OFI1m      float64
-	OFI3m      float64
-	OFI15m     float64
-	OBI10      float64
-	MicroPrice float64
+	CVD1m      float64 `json:"cvd_1m"`
+	CVD3m      float64 `json:"cvd_3m"`
+	CVD15m     float64 `json:"cvd_15m"`
+	OFI1m      float64 `json:"ofi_1m"`
+	OFI3m      float64 `json:"ofi_3m"`
+	OFI15m     float64 `json:"ofi_15m"`
+	OBI10      float64 `json:"obi_10"`
+	MicroPrice float64 `json:"micro_price"`
+	// BookWindowMs OBI10与MicroPrice的平滑窗口（毫秒），来自盘口订阅的指数移动平均；0表示单次REST快照
+	BookWindowMs int64 `json:"book_window_ms"`
 }
 
 // IntradayData 日内数据(3分钟间隔)
 type IntradayData struct {
-	MidPrices   []float64
-	EMA20Values []float64
-	MACDValues  []float64
-	RSI7Values  []float64
-	RSI14Values []float64
+	MidPrices           []float64 `json:"mid_prices"`
+	EMA20Values         []float64 `json:"ema_20_values"`
+	DEMA20Values        []float64 `json:"dema_20_values"` // 双重EMA(20)
+	TEMA20Values        []float64 `json:"tema_20_values"` // 三重EMA(20)
+	MACDValues          []float64 `json:"macd_values"`
+	MACDSignalValues    []float64 `json:"macd_signal_values"`
+	MACDHistogramValues []float64 `json:"macd_histogram_values"`
+	RSI7Values          []float64 `json:"rsi_7_values"`
+	RSI14Values         []float64 `json:"rsi_14_values"`
+	// BollingerValues 最近的20期、2倍标准差布林带（跳过预热区）
+	BollingerValues  []BollingerBands `json:"bollinger_values"`
+	StochKValues     []float64        `json:"stoch_k_values"` // 随机指标%K（14/3/3）
+	StochDValues     []float64        `json:"stoch_d_values"` // 随机指标%D（14/3/3）
+	OBVValues        []float64        `json:"obv_values"`     // 能量潮
+	ADValues         []float64        `json:"ad_values"`      // 累积/派发线
+	ATR14Values      []float64        `json:"atr_14_values"`
+	ATRPercentValues []float64        `json:"atr_percent_values"` // ATR14占收盘价的百分比
 }
 
 // LongerTermData 长期数据(4小时时间框架)
 type LongerTermData struct {
-	EMA20         float64
-	EMA50         float64
-	ATR3          float64
-	ATR14         float64
-	CurrentVolume float64
-	AverageVolume float64
-	MACDValues    []float64
-	RSI14Values   []float64
+	EMA20                float64   `json:"ema_20"`
+	EMA50                float64   `json:"ema_50"`
+	ATR3                 float64   `json:"atr_3"`
+	ATR14                float64   `json:"atr_14"`
+	ATRPercent           float64   `json:"atr_percent"`      // ATR14占收盘价的百分比
+	ADX14                float64   `json:"adx_14"`           // 趋势强度，K线少于28根时为0
+	AroonUp              float64   `json:"aroon_up"`         // 阿隆上线(25)
+	AroonDown            float64   `json:"aroon_down"`       // 阿隆下线(25)
+	AroonOscillator      float64   `json:"aroon_oscillator"` // AroonUp − AroonDown
+	DonchianUpper        float64   `json:"donchian_upper"`   // 最近20根K线的最高价
+	DonchianLower        float64   `json:"donchian_lower"`   // 最近20根K线的最低价
+	DonchianMid          float64   `json:"donchian_mid"`
+	DonchianUpperDistATR float64   `json:"donchian_upper_dist_atr"` // 收盘价距上轨的ATR14倍数
+	DonchianLowerDistATR float64   `json:"donchian_lower_dist_atr"` // 收盘价距下轨的ATR14倍数
+	CurrentVolume        float64   `json:"current_volume"`          // 最后一根K线（可能尚未收盘）的成交量
+	AverageVolume        float64   `json:"average_volume"`          // 之前全部已完成K线的平均成交量
+	MACDValues           []float64 `json:"macd_values"`
+	MACDSignalValues     []float64 `json:"macd_signal_values"`
+	MACDHistogramValues  []float64 `json:"macd_histogram_values"`
+	RSI14Values          []float64 `json:"rsi_14_values"`
+	WarmingUp            []string  `json:"warming_up,omitempty"` // K线不足、仍处于预热区的字段（ema_20/ema_50/atr_3/atr_14），这些字段的0不是有效值
 }
 
 // Kline K线数据
 type Kline struct {
-	OpenTime  int64
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Volume    float64
-	CloseTime int64
+	OpenTime  int64   `json:"open_time"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+	CloseTime int64   `json:"close_time"`
+}
+
+// Get 获取指定代币的市场数据（使用默认客户端）
+func Get(symbol string, opts ...GetOption) (*Data, error) {
+	return GetWithContext(context.Background(), symbol, opts...)
+}
+
+// GetWithContext 获取指定代币的市场数据（使用默认客户端），ctx取消或超时后立即返回ctx.Err()
+func GetWithContext(ctx context.Context, symbol string, opts ...GetOption) (*Data, error) {
+	return defaultClient.Get(ctx, symbol, opts...)
+}
+
+// Get 获取指定代币的市场数据，ctx取消或超时后立即返回ctx.Err()
+// 同一symbol的并发调用会合并为一次拉取，所有调用方拿到同一个*Data，返回值应视为只读
+// OI、资金费率、微结构等分区失败时默认容忍（见Data.UnavailableSections），使用WithStrict()则直接返回错误
+func (c *Client) Get(ctx context.Context, symbol string, opts ...GetOption) (*Data, error) {
+	o := c.newGetOptions(opts)
+	symbol, err := o.normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	data, report, err := c.getWithReport(ctx, symbol, o)
+	if err != nil {
+		return nil, err
+	}
+	if o.strict {
+		if err := report.strictErr(); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// fetchResult 合并请求共享的结果
+type fetchResult struct {
+	data   *Data
+	report *FetchReport
 }
 
-// Get 获取指定代币的市场数据
-func Get(symbol string) (*Data, error) {
-	// 标准化symbol
-	symbol = Normalize(symbol)
+// getWithReport 合并同一symbol的并发请求并拉取数据
+func (c *Client) getWithReport(ctx context.Context, symbol string, o getOptions) (data *Data, report *FetchReport, err error) {
+	ctx, span := c.config().startSpan(ctx, "market.Get", Attribute{Key: "symbol", Value: symbol})
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	if err := validateIntervals(o.intervals); err != nil {
+		return nil, nil, err
+	}
+	alias := symbol
+	symbol, err = c.resolveForGet(ctx, symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		ch := c.flight.DoChan(o.flightKey(symbol), func() (interface{}, error) {
+			logger := c.config().logger
+			report := &FetchReport{Symbol: symbol, logger: logger}
+			start := time.Now()
+			data, err := c.fetch(ctx, symbol, o, report)
+			if err != nil {
+				logger.Debugf("获取失败 symbol=%s duration=%s err=%v", symbol, time.Since(start), err)
+				return nil, err
+			}
+			logger.Debugf("获取完成 symbol=%s duration=%s warnings=%d", symbol, time.Since(start), len(report.Warnings))
+			return fetchResult{data: data, report: report}, nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				// 合并的拉取可能因发起方的ctx被取消而失败，自身ctx仍有效时重新发起
+				if res.Shared && isContextError(res.Err) && ctx.Err() == nil {
+					continue
+				}
+				return nil, nil, res.Err
+			}
+			result := res.Val.(fetchResult)
+			if alias != symbol {
+				// 合并的拉取结果可能被其它调用方共享，复制后再设置别名
+				aliased := *result.data
+				aliased.Alias = alias
+				return &aliased, result.report, nil
+			}
+			return result.data, result.report, nil
+		}
+	}
+}
 
-	intervals := []string{"1m", "3m", "15m", "1h", "4h"}
+// fetch 拉取并计算单个symbol的市场数据
+func (c *Client) fetch(ctx context.Context, symbol string, o getOptions, report *FetchReport) (*Data, error) {
+	intervals := o.intervals
 	klinesByInterval := make(map[string][]Kline, len(intervals))
 
 	for _, interval := range intervals {
-		limit := 200
-		if interval == "4h" {
-			limit = 120
-		}
-		klines, err := getKlines(symbol, interval, limit)
+		limit := o.klineLimit(interval)
+		klines, err := c.cachedKlines(ctx, o, symbol, interval, limit)
 		if err != nil {
-			return nil, fmt.Errorf("获取%s K线失败: %v", interval, err)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("获取%s K线失败: %w", interval, err)
+		}
+		klines = c.repairKlines(ctx, report, o.gapPolicy, symbol, interval, klines)
+		if len(klines) < o.minKlines {
+			return nil, &InsufficientDataError{Interval: interval, Got: len(klines), Want: o.minKlines}
 		}
 		klinesByInterval[interval] = klines
 	}
 
-	// 基准使用3分钟周期
-	klines3m := klinesByInterval["3m"]
-	currentPrice := klines3m[len(klines3m)-1].Close
+	// 基准优先使用3分钟周期，未请求时退回到最短的周期
+	base := baseInterval
+	if !o.hasInterval(base) {
+		base = finestInterval(intervals)
+	}
+	klinesBase := klinesByInterval[base]
+	currentPrice := klinesBase[len(klinesBase)-1].Close
+
+	// 去掉进行中的K线后再计算指标，CurrentPrice仍取其最新收盘价
+	nowMs := time.Now().UnixMilli()
+	if o.closedOnly {
+		for interval, klines := range klinesByInterval {
+			klinesByInterval[interval] = closedKlines(klines, nowMs)
+		}
+	}
 
 	timeframeMetrics := make(map[string]*TimeframeMetrics, len(intervals))
 	for _, interval := range intervals {
-		metrics := calculateTimeframeMetrics(interval, klinesByInterval[interval])
+		metrics := calculateTimeframeMetrics(interval, klinesByInterval[interval], o)
 		timeframeMetrics[interval] = metrics
 	}
+	regime, regimeScore := blendRegimes(timeframeMetrics)
 
-	currentEMA20 := timeframeMetrics["3m"].EMA20
-	currentMACD := timeframeMetrics["3m"].MACD
-	currentRSI7 := timeframeMetrics["3m"].RSI7
+	currentEMA20 := timeframeMetrics[base].EMA20
+	currentMACD := timeframeMetrics[base].MACD
+	currentRSI7 := timeframeMetrics[base].RSI7
 
-	priceChange1h := percentageChangeFromSeries(klinesByInterval["1m"], 60)
-	priceChange4h := percentageChangeFromSeries(klinesByInterval["1h"], 4)
+	priceChange1h := priceChangeOver(klinesByInterval, time.Hour)
+	priceChange4h := priceChangeOver(klinesByInterval, 4*time.Hour)
 
-	oiData, err := getOpenInterestData(symbol,
-		klinesByInterval["1m"],
-		klinesByInterval["15m"],
-		klinesByInterval["1h"],
-		klinesByInterval["4h"],
-	)
-	if err != nil {
-		oiData = &OIData{}
+	var ticker *Ticker24h
+	if o.ticker {
+		var err error
+		ticker, err = c.cachedTicker24h(ctx, o, symbol)
+		if err != nil && !errors.Is(err, ErrTickerUnsupported) {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取24小时行情失败: %w", err)
+			}
+			report.fail(SectionTicker, "ticker/24hr", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var oiData *OIData
+	if !o.skipOpenInterest {
+		var err error
+		// 优先使用24h ticker的成交量，没有时由K线汇总
+		volume24h, _ := volumeOver(klinesByInterval, 24*time.Hour)
+		if ticker != nil && ticker.Volume > 0 {
+			volume24h = ticker.Volume
+		}
+		oiData, err = c.getOpenInterestData(ctx, o, report, symbol, currentPrice, volume24h,
+			klinesByInterval["1m"],
+			klinesByInterval["15m"],
+			klinesByInterval["1h"],
+			klinesByInterval["4h"],
+		)
+		if err != nil {
+			// 限频必须向上暴露，否则调用方会在封禁期间继续高频请求
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取OI数据失败: %w", err)
+			}
+			// 保持零值结构，调用方通过UnavailableSections区分"真实为0"与"获取失败"
+			report.fail(SectionOpenInterest, "openInterest", err)
+			oiData = &OIData{}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var fundingData *FundingData
+	var priceContext *PriceContext
+	if !o.skipFunding {
+		var premium *PremiumIndex
+		var err error
+		fundingData, premium, err = c.getFundingData(ctx, o, report, symbol)
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取资金费率失败: %w", err)
+			}
+			report.fail(SectionFunding, "premiumIndex", err)
+		}
+		priceContext = newPriceContext(currentPrice, premium)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var basisHistory *BasisHistory
+	if o.basisHistory {
+		var err error
+		basisHistory, err = c.getBasisHistory(ctx, o, symbol)
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取溢价指数K线失败: %w", err)
+			}
+			report.warn(SectionFunding, "premiumIndexKlines "+basisHistoryInterval, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var microstructure *MicrostructureData
+	if !o.skipMicrostructure {
+		var err error
+		microstructure, err = c.getMicrostructureData(ctx, report, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取微结构数据失败: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var correlationBTC, betaBTC *float64
+	if o.btcCorrelation {
+		var err error
+		correlationBTC, betaBTC, err = c.getBenchmarkStats(ctx, o, symbol, klinesByInterval[benchmarkInterval])
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取BTC相关性失败: %w", err)
+			}
+			report.fail(SectionBenchmark, benchmarkInterval, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var relativeStrength *RelativeStrength
+	if o.relativeStrength {
+		var err error
+		relativeStrength, err = c.getRelativeStrength(ctx, o, symbol, klinesByInterval[benchmarkInterval])
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取相对强弱失败: %w", err)
+			}
+			report.fail(SectionBenchmark, "relative strength", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 	}
 
-	fundingData, _ := getFundingData(symbol)
+	var sentiment *SentimentData
+	if o.sentiment {
+		var err error
+		sentiment, err = c.getSentimentData(ctx, o, report, symbol)
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, fmt.Errorf("获取多空比失败: %w", err)
+			}
+			report.fail(SectionSentiment, "longShortRatio", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
 
-	microstructure := getMicrostructureData(symbol)
+	var intradayData *IntradayData
+	if klines3m, ok := klinesByInterval["3m"]; ok {
+		intradayData = calculateIntradaySeries(klines3m, o.smoothing)
+	}
+	var longerTermData *LongerTermData
+	if klines4h, ok := klinesByInterval["4h"]; ok {
+		longerTermData = calculateLongerTermData(klines4h, o.smoothing)
+	}
+
+	var rawKlines map[string][]Kline
+	if o.rawKlines {
+		rawKlines = klinesByInterval
+	}
 
-	intradayData := calculateIntradaySeries(klines3m)
-	longerTermData := calculateLongerTermData(klinesByInterval["4h"])
+	// 现货的1000SATS等是独立资产而非合约倍数
+	multiplier := 1.0
+	if o.market != SPOT {
+		multiplier = symbolMultiplier(symbol)
+	}
 
 	return &Data{
-		Symbol:            symbol,
-		CurrentPrice:      currentPrice,
-		PriceChange1h:     priceChange1h,
-		PriceChange4h:     priceChange4h,
-		CurrentEMA20:      currentEMA20,
-		CurrentMACD:       currentMACD,
-		CurrentRSI7:       currentRSI7,
-		OpenInterest:      oiData,
-		Funding:           fundingData,
-		Timeframes:        timeframeMetrics,
-		Microstructure:    microstructure,
-		IntradaySeries:    intradayData,
-		LongerTermContext: longerTermData,
+		Symbol:              symbol,
+		Multiplier:          multiplier,
+		Market:              o.market,
+		SymbolInfo:          c.symbolInfo(ctx, symbol),
+		CurrentPrice:        currentPrice,
+		PriceChange1h:       priceChange1h,
+		PriceChange4h:       priceChange4h,
+		CurrentEMA20:        currentEMA20,
+		CurrentMACD:         currentMACD,
+		CurrentRSI7:         currentRSI7,
+		OpenInterest:        oiData,
+		Funding:             fundingData,
+		Ticker24h:           ticker,
+		PriceContext:        priceContext,
+		BasisHistory:        basisHistory,
+		Sentiment:           sentiment,
+		DailyPivots:         calculatePivots(klinesByInterval, PeriodDay),
+		WeeklyPivots:        calculatePivots(klinesByInterval, PeriodWeek),
+		CandlePatterns:      calculateCandlePatterns(klinesByInterval, nowMs),
+		CorrelationBTC:      correlationBTC,
+		BetaBTC:             betaBTC,
+		RelativeStrength:    relativeStrength,
+		Regime:              regime,
+		RegimeScore:         regimeScore,
+		VolumeProfile:       calculateVolumeProfile(klinesByInterval, o.profileLookback, o.profileDistribution),
+		Timeframes:          timeframeMetrics,
+		Microstructure:      microstructure,
+		Liquidations:        c.liveLiquidations(symbol),
+		IntradaySeries:      intradayData,
+		LongerTermContext:   longerTermData,
+		RawKlines:           rawKlines,
+		UnavailableSections: report.unavailableSections(),
+		klines:              klinesByInterval,
+		swingATRMultiple:    o.swingATRMultiple,
 	}, nil
 }
 
-// getKlines 从Binance获取K线数据
-func getKlines(symbol, interval string, limit int) ([]Kline, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/klines?symbol=%s&interval=%s&limit=%d",
-		symbol, interval, limit)
+// getKlines 获取最近limit根K线，超过单次请求上限（1500）时按endTime向前分页拉取后拼接
+func (c *Client) getKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	if limit <= maxKlinesPerRequest {
+		return c.getKlinesPage(ctx, symbol, interval, limit, 0, 0)
+	}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+	var klines []Kline
+	endTime := int64(0)
+	for len(klines) < limit {
+		pageLimit := limit - len(klines)
+		if pageLimit > maxKlinesPerRequest {
+			pageLimit = maxKlinesPerRequest
+		}
+		page, err := c.getKlinesPage(ctx, symbol, interval, pageLimit, 0, endTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break // 已到上市时间，没有更早的K线
+		}
+		klines = append(page, klines...)
+		endTime = page[0].OpenTime - 1
+		if len(page) < pageLimit {
+			break
+		}
 	}
-	defer resp.Body.Close()
+	return klines, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// getKlinesPage 单次请求K线，startTime/endTime>0时只返回开盘时间在该范围内的K线
+func (c *Client) getKlinesPage(ctx context.Context, symbol, interval string, limit int, startTime, endTime int64) ([]Kline, error) {
+	url := fmt.Sprintf("%s?symbol=%s&interval=%s&limit=%d",
+		c.apiEndpoint("/klines"), symbol, interval, limit)
+	if startTime > 0 {
+		url += fmt.Sprintf("&startTime=%d", startTime)
+	}
+	if endTime > 0 {
+		url += fmt.Sprintf("&endTime=%d", endTime)
 	}
 
 	var rawData [][]interface{}
-	if err := json.Unmarshal(body, &rawData); err != nil {
+	if err := c.getJSON(ctx, url, klinesWeight(limit), &rawData); err != nil {
 		return nil, err
 	}
+	return parseKlineRows(url, rawData, c.config().market == COINM)
+}
 
+// parseKlineRows 解析K线接口的数组格式，coinM为true时成交量取第8列的基础资产成交量
+func parseKlineRows(url string, rawData [][]interface{}, coinM bool) ([]Kline, error) {
 	klines := make([]Kline, len(rawData))
 	for i, item := range rawData {
-		openTime := int64(item[0].(float64))
+		if len(item) < 7 {
+			return nil, newRequestError(url, fmt.Errorf("第%d根K线字段不足: %v", i, item))
+		}
+		openTime, ok1 := item[0].(float64)
+		closeTime, ok2 := item[6].(float64)
+		if !ok1 || !ok2 {
+			return nil, newRequestError(url, fmt.Errorf("第%d根K线时间格式错误: %v", i, item))
+		}
 		open, _ := parseFloat(item[1])
 		high, _ := parseFloat(item[2])
 		low, _ := parseFloat(item[3])
 		close, _ := parseFloat(item[4])
 		volume, _ := parseFloat(item[5])
-		closeTime := int64(item[6].(float64))
+		if coinM && len(item) > 7 {
+			// 币本位第6列为合约张数，第8列为基础资产成交量
+			volume, _ = parseFloat(item[7])
+		}
 
 		klines[i] = Kline{
-			OpenTime:  openTime,
+			OpenTime:  int64(openTime),
 			Open:      open,
 			High:      high,
 			Low:       low,
 			Close:     close,
 			Volume:    volume,
-			CloseTime: closeTime,
+			CloseTime: int64(closeTime),
 		}
 	}
 
 	return klines, nil
 }
 
-// calculateEMA 计算EMA
-func calculateEMA(klines []Kline, period int) float64 {
-	if len(klines) < period {
-		return 0
-	}
+// calculateEMA 计算EMA；K线不足period根时返回(0, false)，0不是有效的EMA
+func calculateEMA(klines []Kline, period int) (float64, bool) {
+	return lastValid(EMASeries(klines, period))
+}
+
+// warmup 记录仍处于预热区（K线不足、值无效）的指标字段名，即TimeframeMetrics/LongerTermData的WarmingUp
+type warmup []string
 
-	// 计算SMA作为初始EMA
-	sum := 0.0
-	for i := 0; i < period; i++ {
-		sum += klines[i].Close
+// mark ok为false时记下field
+func (w *warmup) mark(field string, ok bool) {
+	if !ok {
+		*w = append(*w, field)
 	}
-	ema := sum / float64(period)
+}
 
-	// 计算EMA
-	multiplier := 2.0 / float64(period+1)
-	for i := period; i < len(klines); i++ {
-		ema = (klines[i].Close-ema)*multiplier + ema
+// track 返回一个接收(value, ok)的函数，记下无效的field并原样返回value，便于直接接住calculateXXX的两个返回值
+func (w *warmup) track(field string) func(float64, bool) float64 {
+	return func(v float64, ok bool) float64 {
+		w.mark(field, ok)
+		return v
 	}
+}
+
+// Ready 指标字段（json名，如 "rsi_14"、"macd"）是否已脱离预热区；nil或未跟踪的字段视为有效
+func (m *TimeframeMetrics) Ready(field string) bool {
+	return m == nil || !slices.Contains(m.WarmingUp, field)
+}
 
-	return ema
+// Ready 指标字段（json名，如 "ema_50"、"atr_3"）是否已脱离预热区；nil或未跟踪的字段视为有效
+func (l *LongerTermData) Ready(field string) bool {
+	return l == nil || !slices.Contains(l.WarmingUp, field)
 }
 
+// MACD参数
+const (
+	macdFastPeriod   = 12
+	macdSlowPeriod   = 26
+	macdSignalPeriod = 9
+)
+
 // calculateMACD 计算MACD
 func calculateMACD(klines []Kline) float64 {
-	if len(klines) < 26 {
-		return 0
-	}
+	macd, _, _, _ := calculateMACDSignal(klines)
+	return macd
+}
 
-	// 计算12期和26期EMA
-	ema12 := calculateEMA(klines, 12)
-	ema26 := calculateEMA(klines, 26)
+// calculateMACDSignal 计算最后一根K线的MACD线、信号线与柱状图
+// 不足26根时全部为0，不足34根（26+9-1）时信号线与柱状图为0；ok表示三者都已脱离预热区
+func calculateMACDSignal(klines []Kline) (macd, signal, histogram float64, ok bool) {
+	macdSeries, signalSeries, histogramSeries := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	histogram, ok = lastValid(histogramSeries)
+	return lastValue(macdSeries), lastValue(signalSeries), histogram, ok
+}
 
-	// MACD = EMA12 - EMA26
-	return ema12 - ema26
+// calculateRSI 按cfg的周期与平滑方式计算RSI；K线不足period+1根时返回(0, false)，此时的0不代表超卖
+func calculateRSI(klines []Kline, cfg IndicatorConfig) (float64, bool) {
+	return lastValid(RSISeriesWith(klines, cfg))
 }
 
-// calculateRSI 计算RSI
-func calculateRSI(klines []Kline, period int) float64 {
-	if len(klines) <= period {
+// calculateATR 按cfg的周期与平滑方式计算ATR；K线不足period+1根时返回(0, false)
+func calculateATR(klines []Kline, cfg IndicatorConfig) (float64, bool) {
+	return lastValid(ATRSeriesWith(klines, cfg))
+}
+
+// atrPercent ATR占收盘价的百分比，便于跨币种比较；价格为0时为0
+func atrPercent(atr, close float64) float64 {
+	if close == 0 {
 		return 0
 	}
+	return atr / close * 100
+}
 
-	gains := 0.0
-	losses := 0.0
-
-	// 计算初始平均涨跌幅
-	for i := 1; i <= period; i++ {
-		change := klines[i].Close - klines[i-1].Close
-		if change > 0 {
-			gains += change
-		} else {
-			losses += -change
-		}
+// atrPercentSeries 逐根K线的ATR占收盘价百分比，与atr对齐，预热区为NaN
+func atrPercentSeries(klines []Kline, atr []float64) []float64 {
+	result := make([]float64, len(atr))
+	for i, v := range atr {
+		result[i] = atrPercent(v, klines[i].Close)
 	}
+	return result
+}
 
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
+// 随机指标默认参数
+const (
+	stochKPeriod   = 14
+	stochDPeriod   = 3
+	stochSmoothing = 3
+)
 
-	// 使用Wilder平滑方法计算后续RSI
-	for i := period + 1; i < len(klines); i++ {
-		change := klines[i].Close - klines[i-1].Close
-		if change > 0 {
-			avgGain = (avgGain*float64(period-1) + change) / float64(period)
-			avgLoss = (avgLoss * float64(period-1)) / float64(period)
-		} else {
-			avgGain = (avgGain * float64(period-1)) / float64(period)
-			avgLoss = (avgLoss*float64(period-1) + (-change)) / float64(period)
-		}
-	}
+// calculateStochastic 计算最后一根K线的随机指标%K与%D，数据不足时为0
+func calculateStochastic(klines []Kline, kPeriod, dPeriod, smoothing int) (k, d float64) {
+	kSeries, dSeries := StochasticSeries(klines, kPeriod, dPeriod, smoothing)
+	return lastValue(kSeries), lastValue(dSeries)
+}
 
-	if avgLoss == 0 {
-		return 100
+// calculateADX 计算最后一根K线的ADX与±DI，K线少于2×period根时全部为0
+func calculateADX(klines []Kline, period int) (adx, plusDI, minusDI float64) {
+	if period <= 0 || len(klines) < 2*period {
+		return 0, 0, 0
 	}
+	adxSeries, plusSeries, minusSeries := ADXSeries(klines, period)
+	return lastValue(adxSeries), lastValue(plusSeries), lastValue(minusSeries)
+}
 
-	rs := avgGain / avgLoss
-	rsi := 100 - (100 / (1 + rs))
-
-	return rsi
+// LinReg 收盘价对K线序号的线性回归（x为窗口内的序号，第一根为0）
+type LinReg struct {
+	Slope     float64 `json:"slope"`     // 每根K线的价格变化
+	SlopePct  float64 `json:"slope_pct"` // Slope占最后收盘价的百分比（每根K线）
+	Intercept float64 `json:"intercept"` // 回归线在窗口第一根K线处的值
+	R2        float64 `json:"r2"`        // 决定系数，0~1，越接近1趋势越线性
+	Upper     float64 `json:"upper"`     // 回归线在最后一根K线处 + 2倍残差标准误
+	Lower     float64 `json:"lower"`     // 回归线在最后一根K线处 − 2倍残差标准误
 }
 
-// calculateATR 计算ATR
-func calculateATR(klines []Kline, period int) float64 {
-	if len(klines) <= period {
-		return 0
-	}
+// trendPeriod TrendSlope/TrendR2回归使用的K线数量
+const trendPeriod = 20
 
-	trs := make([]float64, len(klines))
-	for i := 1; i < len(klines); i++ {
-		high := klines[i].High
-		low := klines[i].Low
-		prevClose := klines[i-1].Close
+// calculateLinReg 最近period根收盘价的线性回归及±2倍标准误的回归通道，K线不足period根（或period<2）时为零值
+func calculateLinReg(klines []Kline, period int) LinReg {
+	if period < 2 || len(klines) < period {
+		return LinReg{}
+	}
+	closes := Klines(klines[len(klines)-period:]).Closes()
+	fit := fitLine(nil, closes)
+	fitted := fit.intercept + fit.slope*float64(period-1)
+	reg := LinReg{
+		Slope:     fit.slope,
+		Intercept: fit.intercept,
+		R2:        fit.r2,
+		Upper:     fitted + 2*fit.stdErr,
+		Lower:     fitted - 2*fit.stdErr,
+	}
+	if last := closes[period-1]; last != 0 {
+		reg.SlopePct = fit.slope / last * 100
+	}
+	return reg
+}
 
-		tr1 := high - low
-		tr2 := math.Abs(high - prevClose)
-		tr3 := math.Abs(low - prevClose)
+// obvSlopeBars OBVSlope回归使用的K线数量
+const obvSlopeBars = 20
 
-		trs[i] = math.Max(tr1, math.Max(tr2, tr3))
+// calculateOBV 计算最后一根K线的OBV及最近n根OBV的回归斜率，K线不足n根时用全部K线
+func calculateOBV(klines []Kline, n int) (obv, slope float64) {
+	if len(klines) == 0 {
+		return 0, 0
 	}
-
-	// 计算初始ATR
-	sum := 0.0
-	for i := 1; i <= period; i++ {
-		sum += trs[i]
+	series := OBVSeries(klines)
+	start := len(series) - n
+	if start < 0 {
+		start = 0
 	}
-	atr := sum / float64(period)
+	return series[len(series)-1], linearSlope(series[start:])
+}
 
-	// Wilder平滑
-	for i := period + 1; i < len(klines); i++ {
-		atr = (atr*float64(period-1) + trs[i]) / float64(period)
-	}
+// 肯特纳通道与挤压参数：EMA20 ± 1.5×ATR20，布林带为20期、2倍标准差
+const (
+	keltnerPeriod     = 20
+	keltnerMultiplier = 1.5
+)
 
-	return atr
+// calculateKeltner 计算最后一根K线的肯特纳通道上下轨，数据不足时为0
+func calculateKeltner(klines []Kline, period int, multiplier float64) (upper, lower float64) {
+	upperSeries, _, lowerSeries := KeltnerSeries(klines, period, multiplier)
+	return lastValue(upperSeries), lastValue(lowerSeries)
 }
 
-func calculateBollingerWidth(klines []Kline, period int, multiplier float64) float64 {
-	if len(klines) < period {
-		return 0
+// calculateSqueeze 最后一根K线是否处于挤压状态，以及截至最后一根已连续挤压的K线数
+func calculateSqueeze(klines []Kline, period int, bbMultiplier, kcMultiplier float64) (on bool, bars int) {
+	series := SqueezeSeries(klines, period, bbMultiplier, kcMultiplier)
+	for i := len(series) - 1; i >= 0 && series[i]; i-- {
+		bars++
 	}
+	return bars > 0, bars
+}
 
-	closes := make([]float64, period)
-	for i := 0; i < period; i++ {
-		closes[i] = klines[len(klines)-period+i].Close
-	}
+// 超级趋势参数：ATR10、3倍
+const (
+	superTrendPeriod     = 10
+	superTrendMultiplier = 3
+)
 
-	mean := 0.0
-	for _, v := range closes {
-		mean += v
+// calculateSuperTrend 最后一根K线的超级趋势价位、方向（+1/−1）以及自上次翻转以来的K线数
+// 翻转发生在最后一根K线时bars为0；序列内从未翻转时为第一个有效值以来的K线数；数据不足时均为0
+func calculateSuperTrend(klines []Kline, period int, multiplier float64) (level float64, direction, bars int) {
+	levels, directions := SuperTrendSeries(klines, period, multiplier)
+	last := len(directions) - 1
+	if last < 0 || directions[last] == 0 {
+		return 0, 0, 0
 	}
-	mean /= float64(period)
-
-	variance := 0.0
-	for _, v := range closes {
-		diff := v - mean
-		variance += diff * diff
+	for i := last - 1; i >= 0 && directions[i] == directions[last]; i-- {
+		bars++
 	}
-	variance /= float64(period)
+	return levels[last], directions[last], bars
+}
 
-	stddev := math.Sqrt(variance)
-	if mean == 0 {
-		return 0
+// aroonPeriod 阿隆指标的回看K线数
+const aroonPeriod = 25
+
+// calculateAroon 阿隆指标：在最近period+1根K线（含当前K线）中找最高价与最低价出现的位置，
+// AroonUp = (period − 距最高价的K线数)/period×100，AroonDown同理，Oscillator = Up − Down；
+// 极值在多根K线上并列时取最近的一根（新高/新低被重复触及视为仍在延续）；K线不足period+1根时均为0
+func calculateAroon(klines []Kline, period int) (up, down, oscillator float64) {
+	if period <= 0 || len(klines) <= period {
+		return 0, 0, 0
+	}
+	window := klines[len(klines)-period-1:]
+	highIdx, lowIdx := 0, 0
+	for i, k := range window {
+		if k.High >= window[highIdx].High {
+			highIdx = i
+		}
+		if k.Low <= window[lowIdx].Low {
+			lowIdx = i
+		}
 	}
-
-	upper := mean + multiplier*stddev
-	lower := mean - multiplier*stddev
-	width := (upper - lower) / mean
-	return width
+	last := len(window) - 1
+	up = float64(period-(last-highIdx)) / float64(period) * 100
+	down = float64(period-(last-lowIdx)) / float64(period) * 100
+	return up, down, up - down
 }
 
-func calculateRealizedVol(klines []Kline, period int) float64 {
-	if len(klines) <= period {
-		return 0
+// donchianPeriod 唐奇安通道的回看K线数
+const donchianPeriod = 20
+
+// calculateDonchian 最近period根K线（含当前K线）的最高价、最低价及二者的中值，K线不足period根时为0
+func calculateDonchian(klines []Kline, period int) (upper, lower, mid float64) {
+	if period <= 0 || len(klines) < period {
+		return 0, 0, 0
+	}
+	window := klines[len(klines)-period:]
+	upper, lower = window[0].High, window[0].Low
+	for _, k := range window[1:] {
+		upper = math.Max(upper, k.High)
+		lower = math.Min(lower, k.Low)
 	}
+	return upper, lower, (upper + lower) / 2
+}
 
-	returns := make([]float64, 0, period)
-	for i := len(klines) - period; i < len(klines); i++ {
-		if i == 0 {
-			continue
-		}
-		prev := klines[i-1].Close
-		if prev <= 0 {
-			continue
-		}
-		r := math.Log(klines[i].Close / prev)
-		returns = append(returns, r)
+// donchianDistanceATR 收盘价到唐奇安上轨、下轨的距离（以ATR为单位，均为非负），通道或ATR为0时为0
+func donchianDistanceATR(close, upper, lower, atr float64) (toUpper, toLower float64) {
+	if atr <= 0 || upper == 0 {
+		return 0, 0
 	}
+	return (upper - close) / atr, (close - lower) / atr
+}
 
-	if len(returns) == 0 {
+// closeStdDev 最近period根K线收盘价的总体标准差，K线不足时为0
+func closeStdDev(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period {
 		return 0
 	}
-
+	window := klines[len(klines)-period:]
 	mean := 0.0
-	for _, v := range returns {
-		mean += v
+	for _, k := range window {
+		mean += k.Close
 	}
-	mean /= float64(len(returns))
-
+	mean /= float64(period)
 	variance := 0.0
-	for _, v := range returns {
-		diff := v - mean
+	for _, k := range window {
+		diff := k.Close - mean
 		variance += diff * diff
 	}
-	variance /= float64(len(returns))
+	return math.Sqrt(variance / float64(period))
+}
 
-	return math.Sqrt(variance)
+// priceZScore (价格−参考值)/标准差；标准差为0（横盘）或参考值为0（未计算出）时为0
+func priceZScore(price, reference, stddev float64) float64 {
+	if stddev == 0 || reference == 0 {
+		return 0
+	}
+	return (price - reference) / stddev
 }
 
-func calculateAverageVolume(klines []Kline, period int) (float64, float64) {
-	if len(klines) == 0 {
-		return 0, 0
+// calculateBollinger 计算最后一根K线的布林带，数据不足时为零值
+func calculateBollinger(klines []Kline, period int, multiplier float64) BollingerBands {
+	if period <= 0 || len(klines) < period {
+		return BollingerBands{}
 	}
+	return bollingerAt(klines[len(klines)-period:], multiplier)
+}
 
-	current := klines[len(klines)-1].Volume
+// volumeBaselineBars 成交量基准（均值与标准差）使用的已完成K线数量
+const volumeBaselineBars = 20
 
-	if len(klines) < period {
-		sum := 0.0
-		for _, k := range klines {
-			sum += k.Volume
-		}
-		return current, sum / float64(len(klines))
+// calculateVolumeStats 最后一根K线（可能尚未收盘）的成交量，以及它之前最多period根已完成K线成交量的均值与z-score
+// 进行中的K线成交量不完整，不计入基准；没有已完成K线时均值为0，标准差为0时z-score为0
+func calculateVolumeStats(klines []Kline, period int) (current, average, zScore float64) {
+	if len(klines) == 0 {
+		return 0, 0, 0
 	}
-
-	sum := 0.0
-	for i := len(klines) - period; i < len(klines); i++ {
-		sum += klines[i].Volume
+	current = klines[len(klines)-1].Volume
+	baseline := klines[max(len(klines)-1-period, 0) : len(klines)-1]
+	if len(baseline) == 0 {
+		return current, 0, 0
 	}
 
-	return current, sum / float64(period)
+	for _, k := range baseline {
+		average += k.Volume
+	}
+	average /= float64(len(baseline))
+	variance := 0.0
+	for _, k := range baseline {
+		diff := k.Volume - average
+		variance += diff * diff
+	}
+	if stddev := math.Sqrt(variance / float64(len(baseline))); stddev > 0 {
+		zScore = (current - average) / stddev
+	}
+	return current, average, zScore
 }
 
-func calculateTimeframeMetrics(interval string, klines []Kline) *TimeframeMetrics {
-	metrics := &TimeframeMetrics{Interval: interval}
+func calculateTimeframeMetrics(interval string, klines []Kline, o getOptions) *TimeframeMetrics {
+	metrics := &TimeframeMetrics{Interval: interval, ROC: make(map[int]float64, len(o.rocLookbacks))}
 	if len(klines) == 0 {
 		return metrics
 	}
 
 	metrics.Close = klines[len(klines)-1].Close
-	metrics.RSI7 = calculateRSI(klines, 7)
-	metrics.RSI14 = calculateRSI(klines, 14)
-	metrics.MACD = calculateMACD(klines)
-	metrics.EMA20 = calculateEMA(klines, 20)
-	metrics.EMA60 = calculateEMA(klines, 60)
-	metrics.BollingerWidth = calculateBollingerWidth(klines, 20, 2)
-	metrics.ATR14 = calculateATR(klines, 14)
-	metrics.RealizedVol20 = calculateRealizedVol(klines, 20)
-	metrics.CurrentVolume, metrics.AverageVolume = calculateAverageVolume(klines, 20)
+	for _, n := range o.rocLookbacks {
+		metrics.ROC[n] = ROC(klines, n)
+	}
+	var ready warmup
+	metrics.RSI7 = ready.track("rsi_7")(calculateRSI(klines, IndicatorConfig{Period: 7, Smoothing: o.smoothing}))
+	metrics.RSI14 = ready.track("rsi_14")(calculateRSI(klines, IndicatorConfig{Period: 14, Smoothing: o.smoothing}))
+	var macdOK bool
+	metrics.MACD, metrics.MACDSignal, metrics.MACDHistogram, macdOK = calculateMACDSignal(klines)
+	ready.mark("macd", macdOK)
+	metrics.EMA20 = ready.track("ema_20")(calculateEMA(klines, 20))
+	metrics.EMA60 = ready.track("ema_60")(calculateEMA(klines, 60))
+	metrics.Crosses = calculateCrossState(klines)
+	metrics.HeikinAshiStreak, metrics.HeikinAshiStrong = heikinAshiTrend(klines)
+	metrics.CandleStats = calculateCandleStats(klines, candleStatsBars)
+	metrics.HMA20 = lastValue(MovingAverage(klines, 20, MAHMA))
+	metrics.DEMA20 = lastValue(MovingAverage(klines, 20, MADEMA))
+	metrics.TEMA20 = lastValue(MovingAverage(klines, 20, MATEMA))
+	metrics.Bollinger = calculateBollinger(klines, 20, 2)
+	metrics.BollingerWidth = metrics.Bollinger.Width
+	metrics.KeltnerUpper, metrics.KeltnerLower = calculateKeltner(klines, keltnerPeriod, keltnerMultiplier)
+	metrics.SqueezeOn, metrics.SqueezeBars = calculateSqueeze(klines, keltnerPeriod, 2, keltnerMultiplier)
+	metrics.StochK, metrics.StochD = calculateStochastic(klines, stochKPeriod, stochDPeriod, stochSmoothing)
+	metrics.ADX14, metrics.PlusDI, metrics.MinusDI = calculateADX(klines, 14)
+	metrics.WilliamsR14 = lastValue(WilliamsRSeries(klines, 14))
+	metrics.AroonUp, metrics.AroonDown, metrics.AroonOscillator = calculateAroon(klines, aroonPeriod)
+	metrics.CCI20 = lastValue(CCISeries(klines, 20))
+	metrics.UltimateOscillator = lastValue(UltimateOscillatorSeries(klines, 7, 14, 28))
+	metrics.ForceIndex13 = lastValue(ForceIndexSeries(klines, elderPeriod))
+	metrics.ElderImpulse = ElderImpulse(klines)
+	metrics.OBV, metrics.OBVSlope = calculateOBV(klines, obvSlopeBars)
+	metrics.Trend = calculateLinReg(klines, trendPeriod)
+	metrics.TrendSlope, metrics.TrendR2 = metrics.Trend.Slope, metrics.Trend.R2
+	metrics.Choppiness14 = lastValue(ChoppinessSeries(klines, choppinessPeriod))
+	metrics.Regime = ClassifyRegime(metrics, o.regime)
+	if hurstIntervals[interval] {
+		if hurst, err := HurstExponent(klines); err == nil {
+			metrics.HurstExponent = &hurst
+		}
+	}
+	if renkoIntervals[interval] {
+		metrics.Renko = calculateRenko(klines)
+	}
+	metrics.CMF20 = lastValue(CMFSeries(klines, 20))
+	metrics.VWAP = calculateVWAP(klines, VWAPSession, 0)
+	metrics.VWAPDistancePct = vwapDistancePct(metrics.Close, metrics.VWAP)
+	metrics.RollingVWAP20 = calculateVWAP(klines, VWAPRolling, 20)
+	stddev := closeStdDev(klines, 20)
+	metrics.PriceZScoreEMA20 = priceZScore(metrics.Close, metrics.EMA20, stddev)
+	metrics.PriceZScoreVWAP = priceZScore(metrics.Close, metrics.VWAP, stddev)
+	atrSeries := ATRSeriesWith(klines, IndicatorConfig{Period: 14, Smoothing: o.smoothing})
+	metrics.ATR14 = ready.track("atr_14")(lastValid(atrSeries))
+	metrics.ATRPercent = atrPercent(metrics.ATR14, metrics.Close)
+	metrics.ATRPercentile = PercentileRank(atrSeries, atrSeries[len(atrSeries)-1])
+	metrics.SuperTrend, metrics.SuperTrendDirection, metrics.SuperTrendBarsSinceFlip = calculateSuperTrend(
+		klines, superTrendPeriod, superTrendMultiplier)
+	metrics.DonchianUpper, metrics.DonchianLower, metrics.DonchianMid = calculateDonchian(klines, donchianPeriod)
+	metrics.DonchianUpperDistATR, metrics.DonchianLowerDistATR = donchianDistanceATR(
+		metrics.Close, metrics.DonchianUpper, metrics.DonchianLower, metrics.ATR14)
+	rvSeries := RealizedVolSeries(klines, volatilityWindow)
+	metrics.RealizedVol20 = lastValue(rvSeries)
+	annualize := annualizationFactor(interval)
+	metrics.RealizedVolAnnualized = metrics.RealizedVol20 * annualize
+	metrics.RVPercentile = PercentileRank(rvSeries, rvSeries[len(rvSeries)-1])
+	metrics.ParkinsonVol = calculateParkinsonVol(klines, volatilityWindow) * annualize
+	metrics.GKVol = calculateGarmanKlassVol(klines, volatilityWindow) * annualize
+	metrics.CurrentVolume, metrics.AverageVolume, metrics.VolumeZScore = calculateVolumeStats(klines, volumeBaselineBars)
+	metrics.VolumeSpike = metrics.VolumeZScore >= o.volumeSpikeZ
+	metrics.WarmingUp = ready
 	return metrics
 }
 
-func percentageChangeFromSeries(klines []Kline, barsBack int) float64 {
-	if len(klines) == 0 || barsBack <= 0 {
-		return 0
-	}
-
-	if len(klines) <= barsBack {
+// ROC 变动率：最后一根K线收盘价相对nBars根之前收盘价的变化百分比，K线不足或基准价为0时为0
+func ROC(klines []Kline, nBars int) float64 {
+	if nBars <= 0 || len(klines) <= nBars {
 		return 0
 	}
 
 	latest := klines[len(klines)-1].Close
-	reference := klines[len(klines)-1-barsBack].Close
+	reference := klines[len(klines)-1-nBars].Close
 	if reference == 0 {
 		return 0
 	}
@@ -473,179 +1145,274 @@ func percentageChangeFromSeries(klines []Kline, barsBack int) float64 {
 	return ((latest - reference) / reference) * 100
 }
 
-func priceDeltaFromKlines(klines []Kline, barsBack int) float64 {
-	if len(klines) == 0 || barsBack <= 0 {
-		return 0
-	}
+// oiStep 最后两个OI历史点之间的变化
+type oiStep struct {
+	oi, oiPct       float64 // OI变化量（基础资产数量）与相对前值的百分比
+	price, pricePct float64 // 同一时间段的价格变化，K线未覆盖任一端点时为0
+	priced          bool    // K线覆盖了两个端点，price有效
+}
 
-	if len(klines) <= barsBack {
-		return 0
+// oiStepDelta 计算最后两个OI历史点之间的OI变化，价格按两个点的时间戳从klines中取值，保证两者的时间窗口一致
+// 历史不足两个点时返回false
+func oiStepDelta(history []OIPoint, klines []Kline) (oiStep, bool) {
+	if len(history) < 2 {
+		return oiStep{}, false
+	}
+	prev, last := history[len(history)-2], history[len(history)-1]
+	step := oiStep{oi: last.Value - prev.Value}
+	if prev.Value != 0 {
+		step.oiPct = step.oi / prev.Value * 100
+	}
+	from, okFrom := priceAt(klines, prev.Timestamp)
+	to, okTo := priceAt(klines, last.Timestamp)
+	if okFrom && okTo {
+		step.priced = true
+		step.price = to - from
+		if from != 0 {
+			step.pricePct = step.price / from * 100
+		}
 	}
+	return step, true
+}
 
-	latest := klines[len(klines)-1].Close
-	reference := klines[len(klines)-1-barsBack].Close
-	return latest - reference
+// priceAt 时间戳ts时刻的价格：取在ts之前收盘、且与ts相距不超过一根K线的那根K线的收盘价
+func priceAt(klines []Kline, ts int64) (float64, bool) {
+	i := sort.Search(len(klines), func(i int) bool { return klines[i].CloseTime >= ts })
+	if i == 0 {
+		return 0, false
+	}
+	k := klines[i-1]
+	if ts-k.CloseTime > k.CloseTime-k.OpenTime+1 {
+		return 0, false
+	}
+	return k.Close, true
 }
 
-// calculateIntradaySeries 计算日内系列数据
-func calculateIntradaySeries(klines []Kline) *IntradayData {
-	data := &IntradayData{
-		MidPrices:   make([]float64, 0, 10),
-		EMA20Values: make([]float64, 0, 10),
-		MACDValues:  make([]float64, 0, 10),
-		RSI7Values:  make([]float64, 0, 10),
-		RSI14Values: make([]float64, 0, 10),
+// calculateIntradaySeries 计算日内系列数据，没有K线时返回nil
+func calculateIntradaySeries(klines []Kline, smoothing Smoothing) *IntradayData {
+	if len(klines) == 0 {
+		return nil
+	}
+
+	// 每个指标只计算一次完整序列，再取最近10个点（跳过预热区）
+	macd, signal, histogram := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	stochK, stochD := StochasticSeries(klines, stochKPeriod, stochDPeriod, stochSmoothing)
+	atr := ATRSeriesWith(klines, IndicatorConfig{Period: 14, Smoothing: smoothing})
+	return &IntradayData{
+		MidPrices:           recentValues(Klines(klines).Closes(), seriesPoints),
+		EMA20Values:         recentValues(EMASeries(klines, 20), seriesPoints),
+		DEMA20Values:        recentValues(MovingAverage(klines, 20, MADEMA), seriesPoints),
+		TEMA20Values:        recentValues(MovingAverage(klines, 20, MATEMA), seriesPoints),
+		MACDValues:          recentValues(macd, seriesPoints),
+		MACDSignalValues:    recentValues(signal, seriesPoints),
+		MACDHistogramValues: recentValues(histogram, seriesPoints),
+		RSI7Values:          recentValues(RSISeriesWith(klines, IndicatorConfig{Period: 7, Smoothing: smoothing}), seriesPoints),
+		RSI14Values:         recentValues(RSISeriesWith(klines, IndicatorConfig{Period: 14, Smoothing: smoothing}), seriesPoints),
+		BollingerValues:     recentBollinger(BollingerSeries(klines, 20, 2), seriesPoints),
+		StochKValues:        recentValues(stochK, seriesPoints),
+		StochDValues:        recentValues(stochD, seriesPoints),
+		OBVValues:           recentValues(OBVSeries(klines), seriesPoints),
+		ADValues:            recentValues(ADSeries(klines), seriesPoints),
+		ATR14Values:         recentValues(atr, seriesPoints),
+		ATRPercentValues:    recentValues(atrPercentSeries(klines, atr), seriesPoints),
 	}
+}
 
-	// 获取最近10个数据点
-	start := len(klines) - 10
+// seriesPoints IntradayData与LongerTermData中序列保留的点数
+const seriesPoints = 10
+
+// recentBollinger 最后n个点中已过预热区的布林带，结果不为nil
+func recentBollinger(series []BollingerBands, n int) []BollingerBands {
+	start := len(series) - n
 	if start < 0 {
 		start = 0
 	}
-
-	for i := start; i < len(klines); i++ {
-		data.MidPrices = append(data.MidPrices, klines[i].Close)
-
-		// 计算每个点的EMA20
-		if i >= 19 {
-			ema20 := calculateEMA(klines[:i+1], 20)
-			data.EMA20Values = append(data.EMA20Values, ema20)
-		}
-
-		// 计算每个点的MACD
-		if i >= 25 {
-			macd := calculateMACD(klines[:i+1])
-			data.MACDValues = append(data.MACDValues, macd)
+	values := make([]BollingerBands, 0, n)
+	for _, b := range series[start:] {
+		if !math.IsNaN(b.Middle) {
+			values = append(values, b)
 		}
+	}
+	return values
+}
 
-		// 计算每个点的RSI
-		if i >= 7 {
-			rsi7 := calculateRSI(klines[:i+1], 7)
-			data.RSI7Values = append(data.RSI7Values, rsi7)
-		}
-		if i >= 14 {
-			rsi14 := calculateRSI(klines[:i+1], 14)
-			data.RSI14Values = append(data.RSI14Values, rsi14)
+// recentValues 序列最后n个点中已过预热区（非NaN）的值，结果不为nil
+func recentValues(series []float64, n int) []float64 {
+	start := len(series) - n
+	if start < 0 {
+		start = 0
+	}
+	values := make([]float64, 0, n)
+	for _, v := range series[start:] {
+		if !math.IsNaN(v) {
+			values = append(values, v)
 		}
 	}
-
-	return data
+	return values
 }
 
-// calculateLongerTermData 计算长期数据
-func calculateLongerTermData(klines []Kline) *LongerTermData {
-	data := &LongerTermData{
-		MACDValues:  make([]float64, 0, 10),
-		RSI14Values: make([]float64, 0, 10),
+// calculateLongerTermData 计算长期数据，没有K线时返回nil
+func calculateLongerTermData(klines []Kline, smoothing Smoothing) *LongerTermData {
+	if len(klines) == 0 {
+		return nil
 	}
+	data := &LongerTermData{}
 
 	// 计算EMA
-	data.EMA20 = calculateEMA(klines, 20)
-	data.EMA50 = calculateEMA(klines, 50)
+	var ready warmup
+	data.EMA20 = ready.track("ema_20")(calculateEMA(klines, 20))
+	data.EMA50 = ready.track("ema_50")(calculateEMA(klines, 50))
 
 	// 计算ATR
-	data.ATR3 = calculateATR(klines, 3)
-	data.ATR14 = calculateATR(klines, 14)
+	data.ATR3 = ready.track("atr_3")(calculateATR(klines, IndicatorConfig{Period: 3, Smoothing: smoothing}))
+	data.ATR14 = ready.track("atr_14")(calculateATR(klines, IndicatorConfig{Period: 14, Smoothing: smoothing}))
+	data.WarmingUp = ready
+	data.ATRPercent = atrPercent(data.ATR14, klines[len(klines)-1].Close)
+	data.ADX14, _, _ = calculateADX(klines, 14)
 
-	// 计算成交量
-	if len(klines) > 0 {
-		data.CurrentVolume = klines[len(klines)-1].Volume
-		// 计算平均成交量
-		sum := 0.0
-		for _, k := range klines {
-			sum += k.Volume
-		}
-		data.AverageVolume = sum / float64(len(klines))
-	}
+	data.AroonUp, data.AroonDown, data.AroonOscillator = calculateAroon(klines, aroonPeriod)
 
-	// 计算MACD和RSI序列
-	start := len(klines) - 10
-	if start < 0 {
-		start = 0
-	}
+	// 计算唐奇安通道
+	data.DonchianUpper, data.DonchianLower, data.DonchianMid = calculateDonchian(klines, donchianPeriod)
+	data.DonchianUpperDistATR, data.DonchianLowerDistATR = donchianDistanceATR(
+		klines[len(klines)-1].Close, data.DonchianUpper, data.DonchianLower, data.ATR14)
 
-	for i := start; i < len(klines); i++ {
-		if i >= 25 {
-			macd := calculateMACD(klines[:i+1])
-			data.MACDValues = append(data.MACDValues, macd)
-		}
-		if i >= 14 {
-			rsi14 := calculateRSI(klines[:i+1], 14)
-			data.RSI14Values = append(data.RSI14Values, rsi14)
-		}
-	}
+	// 计算成交量：平均值只计已完成的K线，与TimeframeMetrics一致
+	data.CurrentVolume, data.AverageVolume, _ = calculateVolumeStats(klines, len(klines))
+
+	// 计算MACD和RSI序列
+	macd, signal, histogram := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	data.MACDValues = recentValues(macd, seriesPoints)
+	data.MACDSignalValues = recentValues(signal, seriesPoints)
+	data.MACDHistogramValues = recentValues(histogram, seriesPoints)
+	data.RSI14Values = recentValues(RSISeriesWith(klines, IndicatorConfig{Period: 14, Smoothing: smoothing}), seriesPoints)
 
 	return data
 }
 
-// getOpenInterestData 获取OI数据
-type oiHistoryPoint struct {
-	Value     float64
-	Timestamp int64
+// OIPoint 某一时刻的持仓量
+type OIPoint struct {
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp_ms"`
 }
 
-func getOpenInterestData(symbol string, klines1m, klines15m, klines1h, klines4h []Kline) (*OIData, error) {
-	latest, ts, err := getLatestOpenInterest(symbol)
+// 持仓量历史的获取条数：/openInterestHist只提供最近30天，4h周期取满30天（180根）用于百分位
+const (
+	oiHistoryPoints   = 20
+	oiHistory4hPoints = 180
+)
+
+// getOpenInterestData 获取OI数据
+// currentPrice 用于将币本位合约的持仓张数换算为基础资产数量，以及换算美元名义价值；volume24h为0表示不可用
+func (c *Client) getOpenInterestData(ctx context.Context, o getOptions, report *FetchReport, symbol string, currentPrice, volume24h float64, klines1m, klines15m, klines1h, klines4h []Kline) (*OIData, error) {
+	current, err := c.source().OpenInterest(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
+	latest, ts := current.Value, current.Timestamp
+	// 当前持仓量的名义价值按原始单位换算：币本位为张数×面值，不受价格精度影响
+	latestValue := contractValue(baseValue)
+	if c.config().market == COINM {
+		latest = contractsToBase(symbol, latest, currentPrice)
+		latestValue = coinMContractValue(symbol)
+	}
 
-	history5m, _ := getOpenInterestHistory(symbol, "5m", 20)
-	history15m, _ := getOpenInterestHistory(symbol, "15m", 20)
-	history1h, _ := getOpenInterestHistory(symbol, "1h", 20)
-	history4h, _ := getOpenInterestHistory(symbol, "4h", 20)
+	histories := make(map[string][]OIPoint, 4)
+	for _, period := range []string{"5m", "15m", "1h", "4h"} {
+		limit := oiHistoryPoints
+		if period == "4h" {
+			limit = oiHistory4hPoints
+		}
+		points, err := c.cachedOpenInterestHistory(ctx, o, symbol, period, limit)
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, err
+			}
+			report.warn(SectionOpenInterest, "openInterestHist "+period, err)
+		}
+		histories[period] = points
+	}
+	history5m, history15m, history1h, history4h := histories["5m"], histories["15m"], histories["1h"], histories["4h"]
 
-	avg := latest
+	avg, percentile := latest, 0.0
 	if len(history4h) > 0 {
+		values := make([]float64, len(history4h))
 		sum := 0.0
-		for _, pt := range history4h {
+		for i, pt := range history4h {
+			values[i] = pt.Value
 			sum += pt.Value
 		}
 		avg = sum / float64(len(history4h))
+		percentile = PercentileRank(values, latest)
 	}
 
 	data := &OIData{
 		Latest:      latest,
 		Average:     avg,
 		TimestampMs: ts,
+		LatestUSD:   latestValue(current.Value, currentPrice),
+		AverageUSD:  baseValue(avg, currentPrice),
+		// 历史序列来自缓存，复制一份避免调用方修改影响后续请求
+		Percentile30d: percentile,
+		History5m:     slices.Clone(history5m),
+		History15m:    slices.Clone(history15m),
+		History1h:     slices.Clone(history1h),
+		History4h:     slices.Clone(history4h),
+	}
+
+	regimes := make(map[string]OIRegime, len(oiRegimeWindows))
+	if step, ok := oiStepDelta(history5m, klines1m); ok {
+		data.Delta5m, data.Delta5mPct = step.oi, step.oiPct
+		data.PriceDelta5m, data.PriceDelta5mPct = step.price, step.pricePct
+		data.Delta5mUSD = baseValue(step.oi, currentPrice)
+		if step.priced {
+			regimes["5m"] = ClassifyOIRegime(step.oiPct, step.pricePct, o.oiRegime)
+		}
 	}
 
-	if len(history5m) >= 2 {
-		data.Delta5m = history5m[len(history5m)-1].Value - history5m[len(history5m)-2].Value
-		data.PriceDelta5m = priceDeltaFromKlines(klines1m, 5)
+	if step, ok := oiStepDelta(history15m, klines15m); ok {
+		data.Delta15m, data.Delta15mPct = step.oi, step.oiPct
+		data.PriceDelta15m, data.PriceDelta15mPct = step.price, step.pricePct
+		data.Delta15mUSD = baseValue(step.oi, currentPrice)
+		if step.priced {
+			regimes["15m"] = ClassifyOIRegime(step.oiPct, step.pricePct, o.oiRegime)
+		}
 	}
 
-	if len(history15m) >= 2 {
-		data.Delta15m = history15m[len(history15m)-1].Value - history15m[len(history15m)-2].Value
-		data.PriceDelta15m = priceDeltaFromKlines(klines15m, 1)
+	if step, ok := oiStepDelta(history1h, klines1h); ok {
+		data.Delta1h, data.Delta1hPct = step.oi, step.oiPct
+		data.PriceDelta1h, data.PriceDelta1hPct = step.price, step.pricePct
+		data.Delta1hUSD = baseValue(step.oi, currentPrice)
+		if step.priced {
+			regimes["1h"] = ClassifyOIRegime(step.oiPct, step.pricePct, o.oiRegime)
+		}
 	}
 
-	if len(history1h) >= 2 {
-		data.Delta1h = history1h[len(history1h)-1].Value - history1h[len(history1h)-2].Value
-		data.PriceDelta1h = priceDeltaFromKlines(klines1h, 1)
+	if step, ok := oiStepDelta(history4h, klines4h); ok {
+		data.Delta4h, data.Delta4hPct = step.oi, step.oiPct
+		data.PriceDelta4h, data.PriceDelta4hPct = step.price, step.pricePct
+		data.Delta4hUSD = baseValue(step.oi, currentPrice)
+		if step.priced {
+			regimes["4h"] = ClassifyOIRegime(step.oiPct, step.pricePct, o.oiRegime)
+		}
+		if step.priced && math.Abs(step.pricePct) >= o.oiRegime.PricePct {
+			data.OIPerPricePct4h = data.Delta4hUSD / step.pricePct
+		}
 	}
-
-	if len(history4h) >= 2 {
-		data.Delta4h = history4h[len(history4h)-1].Value - history4h[len(history4h)-2].Value
-		data.PriceDelta4h = priceDeltaFromKlines(klines4h, 1)
+	if len(regimes) > 0 {
+		data.Regimes = regimes
+	}
+	if volume24h > 0 {
+		data.Volume24h = volume24h
+		data.OIToVolume24h = latest / volume24h
 	}
 
 	return data, nil
 }
 
-func getLatestOpenInterest(symbol string) (float64, int64, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/openInterest?symbol=%s", symbol)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return 0, 0, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, 0, err
-	}
+// getLatestOpenInterest 获取当前持仓量，币本位合约返回的是合约张数
+func (c *Client) getLatestOpenInterest(ctx context.Context, symbol string) (OIPoint, error) {
+	url := fmt.Sprintf("%s?symbol=%s", c.apiEndpoint("/openInterest"), symbol)
 
 	var result struct {
 		OpenInterest string `json:"openInterest"`
@@ -653,49 +1420,50 @@ func getLatestOpenInterest(symbol string) (float64, int64, error) {
 		Time         int64  `json:"time"`
 	}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, 0, err
+	if err := c.getJSON(ctx, url, weightOpenInterest, &result); err != nil {
+		return OIPoint{}, err
 	}
 
 	oi, err := strconv.ParseFloat(result.OpenInterest, 64)
 	if err != nil {
-		return 0, 0, err
+		return OIPoint{}, newRequestError(url, fmt.Errorf("解析openInterest失败: %w", err))
 	}
 
-	return oi, result.Time, nil
+	return OIPoint{Value: oi, Timestamp: result.Time}, nil
 }
 
-func getOpenInterestHistory(symbol, period string, limit int) ([]oiHistoryPoint, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d", symbol, period, limit)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// getOpenInterestHistory 获取持仓量历史（基础资产数量）
+// 币本位接口按标的交易对与合约类型查询，使用以基础资产计价的sumOpenInterestValue
+func (c *Client) getOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OIPoint, error) {
+	coinM := c.config().market == COINM
+	url := fmt.Sprintf("%s?symbol=%s&period=%s&limit=%d", c.dataEndpoint("/openInterestHist"), symbol, period, limit)
+	if coinM {
+		pair, contractType := coinMPair(symbol)
+		url = fmt.Sprintf("%s?pair=%s&contractType=%s&period=%s&limit=%d", c.dataEndpoint("/openInterestHist"), pair, contractType, period, limit)
 	}
 
 	var raw []struct {
-		Symbol          string `json:"symbol"`
-		SumOpenInterest string `json:"sumOpenInterest"`
-		Timestamp       int64  `json:"timestamp"`
+		Symbol               string `json:"symbol"`
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
 	}
 
-	if err := json.Unmarshal(body, &raw); err != nil {
+	if err := c.getJSON(ctx, url, weightFuturesData, &raw); err != nil {
 		return nil, err
 	}
 
-	points := make([]oiHistoryPoint, 0, len(raw))
+	points := make([]OIPoint, 0, len(raw))
 	for _, item := range raw {
-		value, err := strconv.ParseFloat(item.SumOpenInterest, 64)
+		field := item.SumOpenInterest
+		if coinM {
+			field = item.SumOpenInterestValue
+		}
+		value, err := strconv.ParseFloat(field, 64)
 		if err != nil {
 			continue
 		}
-		points = append(points, oiHistoryPoint{Value: value, Timestamp: item.Timestamp})
+		points = append(points, OIPoint{Value: value, Timestamp: item.Timestamp})
 	}
 
 	sort.Slice(points, func(i, j int) bool {
@@ -705,34 +1473,118 @@ func getOpenInterestHistory(symbol, period string, limit int) ([]oiHistoryPoint,
 	return points, nil
 }
 
-// getFundingData 获取资金费率及变化斜率
-type fundingRatePoint struct {
-	Rate      float64
-	Timestamp int64
+// FundingRatePoint 一次资金费率结算
+type FundingRatePoint struct {
+	Rate      float64 `json:"rate"`
+	Timestamp int64   `json:"timestamp_ms"`
 }
 
-func getFundingData(symbol string) (*FundingData, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol)
+// PremiumIndex 标记价格与资金费率信息（/fapi/v1/premiumIndex）
+type PremiumIndex struct {
+	Symbol          string  `json:"symbol"`
+	MarkPrice       float64 `json:"mark_price"`
+	IndexPrice      float64 `json:"index_price"`
+	LastFundingRate float64 `json:"last_funding_rate"` // 名为last，实际是下次结算的预测费率
+	NextFundingTime int64   `json:"next_funding_time_ms"`
+	Time            int64   `json:"time_ms"`
+	// InterestRate 资金费率中的利率部分
+	InterestRate float64 `json:"interest_rate"`
+}
 
-	resp, err := http.Get(url)
+// getFundingData 获取资金费率及变化斜率，同时返回premiumIndex（标记价格与指数价格用于PriceContext）
+func (c *Client) getFundingData(ctx context.Context, o getOptions, report *FetchReport, symbol string) (*FundingData, *PremiumIndex, error) {
+	premium, err := c.source().PremiumIndex(ctx, symbol)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	rate := premium.LastFundingRate
 
-	body, err := ioutil.ReadAll(resp.Body)
+	history, err := c.cachedFundingRateHistory(ctx, o, symbol, fundingHistoryLimit)
 	if err != nil {
+		if IsRateLimited(err) {
+			return nil, nil, err
+		}
+		report.warn(SectionFunding, "fundingRate", err)
+		history = nil
+	}
+
+	// 对最近8次结算做回归（x为距第一次结算的小时数），比首尾两点的差值更稳健
+	recent := history[max(len(history)-fundingSlopePoints, 0):]
+	hours := make([]float64, len(recent))
+	rates := make([]float64, len(recent))
+	for i, point := range recent {
+		hours[i] = float64(point.Timestamp-recent[0].Timestamp) / float64(time.Hour/time.Millisecond)
+		rates[i] = point.Rate
+	}
+	fit := fitLine(hours, rates)
+
+	window := fundingSince(history, time.Now().Add(-fundingHistoryWindow).UnixMilli())
+	windowRates := make([]float64, len(window))
+	for i, point := range window {
+		windowRates[i] = point.Rate
+	}
+	var percentile float64
+	if len(windowRates) > 0 {
+		percentile = PercentileRank(windowRates, rate)
+	}
+
+	data := &FundingData{
+		Rate:          rate,
+		Slope:         fit.slope,
+		SlopeR2:       fit.r2,
+		NextTimeMs:    premium.NextFundingTime,
+		APR:           annualizeFunding(rate, fundingInterval(history)),
+		Percentile30d: percentile,
+		History30d:    len(window),
+		Stats:         calculateFundingStats(o.fundingWindow, fundingSince(history, time.Now().Add(-o.fundingWindow).UnixMilli())),
+		Streak:        fundingStreak(history),
+		PredictedRate: rate,
+		InterestRate:  premium.InterestRate,
+	}
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		data.LastSettledRate, data.LastSettledTimeMs = last.Rate, last.Timestamp
+	}
+	return data, premium, nil
+}
+
+func (c *Client) getPremiumIndex(ctx context.Context, symbol string) (*PremiumIndex, error) {
+	url := fmt.Sprintf("%s?symbol=%s", c.apiEndpoint("/premiumIndex"), symbol)
+
+	var body json.RawMessage
+	if err := c.getJSON(ctx, url, weightPremiumIndex, &body); err != nil {
 		return nil, err
 	}
 
-	var result struct {
+	type premiumIndexResult struct {
 		Symbol          string `json:"symbol"`
+		MarkPrice       string `json:"markPrice"`
+		IndexPrice      string `json:"indexPrice"`
 		LastFundingRate string `json:"lastFundingRate"`
+		InterestRate    string `json:"interestRate"`
 		NextFundingTime int64  `json:"nextFundingTime"`
+		Time            int64  `json:"time"`
 	}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+	// 币本位接口即使指定了symbol也返回数组
+	var result premiumIndexResult
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var results []premiumIndexResult
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return nil, newRequestError(url, fmt.Errorf("解析响应失败: %w", err))
+		}
+		found := false
+		for _, r := range results {
+			if r.Symbol == symbol {
+				result, found = r, true
+				break
+			}
+		}
+		if !found {
+			return nil, newRequestError(url, fmt.Errorf("响应中没有 %s", symbol))
+		}
+	} else if err := json.Unmarshal(body, &result); err != nil {
+		return nil, newRequestError(url, fmt.Errorf("解析响应失败: %w", err))
 	}
 
 	rate, err := strconv.ParseFloat(result.LastFundingRate, 64)
@@ -740,58 +1592,40 @@ func getFundingData(symbol string) (*FundingData, error) {
 		rate = 0
 	}
 
-	history, err := getFundingRateHistory(symbol, 8)
-	if err != nil {
-		history = nil
-	}
+	markPrice, _ := strconv.ParseFloat(result.MarkPrice, 64)
+	indexPrice, _ := strconv.ParseFloat(result.IndexPrice, 64)
+	interestRate, _ := strconv.ParseFloat(result.InterestRate, 64)
 
-	slope := 0.0
-	if len(history) >= 2 {
-		first := history[0]
-		last := history[len(history)-1]
-		duration := float64(last.Timestamp-first.Timestamp) / float64(time.Hour/time.Millisecond)
-		if duration != 0 {
-			slope = (last.Rate - first.Rate) / duration
-		}
-	}
-
-	return &FundingData{
-		Rate:       rate,
-		Slope:      slope,
-		NextTimeMs: result.NextFundingTime,
+	return &PremiumIndex{
+		Symbol:          result.Symbol,
+		MarkPrice:       markPrice,
+		IndexPrice:      indexPrice,
+		LastFundingRate: rate,
+		NextFundingTime: result.NextFundingTime,
+		Time:            result.Time,
+		InterestRate:    interestRate,
 	}, nil
 }
 
-func getFundingRateHistory(symbol string, limit int) ([]fundingRatePoint, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/fundingRate?symbol=%s&limit=%d", symbol, limit)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) getFundingRateHistory(ctx context.Context, symbol string, limit int) ([]FundingRatePoint, error) {
+	url := fmt.Sprintf("%s?symbol=%s&limit=%d", c.apiEndpoint("/fundingRate"), symbol, limit)
 
 	var raw []struct {
 		FundingRate string `json:"fundingRate"`
 		FundingTime int64  `json:"fundingTime"`
 	}
 
-	if err := json.Unmarshal(body, &raw); err != nil {
+	if err := c.getJSON(ctx, url, weightFundingRate, &raw); err != nil {
 		return nil, err
 	}
 
-	points := make([]fundingRatePoint, 0, len(raw))
+	points := make([]FundingRatePoint, 0, len(raw))
 	for _, item := range raw {
 		rate, err := strconv.ParseFloat(item.FundingRate, 64)
 		if err != nil {
 			continue
 		}
-		points = append(points, fundingRatePoint{Rate: rate, Timestamp: item.FundingTime})
+		points = append(points, FundingRatePoint{Rate: rate, Timestamp: item.FundingTime})
 	}
 
 	sort.Slice(points, func(i, j int) bool {
@@ -802,145 +1636,496 @@ func getFundingRateHistory(symbol string, limit int) ([]fundingRatePoint, error)
 }
 
 // Format 格式化输出市场数据
+// 价格类数值的小数位数由tick size（无SymbolInfo时按有效数字）决定，避免低价币被格式化成0
 func Format(data *Data) string {
 	var sb strings.Builder
+	prec := precisionFor(data)
+
+	base := data.baseTimeframe()
+	sb.WriteString(fmt.Sprintf("current_price = %s, current_ema20 = %s, current_macd = %s, current_rsi (7 period) = %s\n\n",
+		formatFloat(data.CurrentPrice, prec.price), formatIndicator(data.CurrentEMA20, base.Ready("ema_20"), prec.indicator),
+		formatIndicator(data.CurrentMACD, base.Ready("macd"), prec.indicator), formatIndicator(data.CurrentRSI7, base.Ready("rsi_7"), 3)))
+
+	if data.Unavailable(SectionTicker) {
+		sb.WriteString("24h: unavailable\n\n")
+	} else if data.Ticker24h != nil {
+		sb.WriteString(formatTicker24h(data.Ticker24h, prec.price))
+	}
+
+	if tf := data.baseTimeframe(); tf != nil && tf.VWAP != 0 {
+		sb.WriteString(fmt.Sprintf("VWAP (%s, UTC session): %s | distance: %.3f%% | rolling 20‑bar VWAP: %s\n\n",
+			tf.Interval, formatFloat(tf.VWAP, prec.price), tf.VWAPDistancePct, formatFloat(tf.RollingVWAP20, prec.price)))
+	}
+
+	if tf := data.baseTimeframe(); tf != nil && tf.KeltnerUpper != 0 {
+		squeeze := "off"
+		if tf.SqueezeOn {
+			squeeze = fmt.Sprintf("on for %d bars", tf.SqueezeBars)
+		}
+		sb.WriteString(fmt.Sprintf("Keltner (%s, EMA20 ± 1.5×ATR20): %s / %s | squeeze (BB inside Keltner): %s\n\n",
+			tf.Interval, formatFloat(tf.KeltnerUpper, prec.price), formatFloat(tf.KeltnerLower, prec.price), squeeze))
+	}
+
+	if tf := data.baseTimeframe(); tf != nil && tf.Ready("atr_14") && tf.ATR14 != 0 {
+		sb.WriteString(fmt.Sprintf("Volatility (%s): ATR14: %s (p%.0f) | realized vol 20 (annualized): %.2f%% (p%.0f)\n\n",
+			tf.Interval, formatFloat(tf.ATR14, prec.indicator), tf.ATRPercentile, tf.RealizedVolAnnualized*100, tf.RVPercentile))
+	}
+
+	if data.DailyPivots != nil {
+		sb.WriteString(formatPivots("Daily pivots (classic)", data.DailyPivots.Classic, data.CurrentPrice, prec.price))
+		sb.WriteString(formatPivots("Daily pivots (Fibonacci)", data.DailyPivots.Fibonacci, data.CurrentPrice, prec.price))
+	}
+	if data.WeeklyPivots != nil {
+		sb.WriteString(formatPivots("Weekly pivots (classic)", data.WeeklyPivots.Classic, data.CurrentPrice, prec.price))
+		sb.WriteString(formatPivots("Weekly pivots (Fibonacci)", data.WeeklyPivots.Fibonacci, data.CurrentPrice, prec.price))
+	}
+
+	sb.WriteString(formatPatternHits(data.CandlePatterns))
+
+	var spikes []string
+	for _, interval := range []string{"15m", "1h"} {
+		if tf, ok := data.Timeframes[interval]; ok && tf.VolumeSpike {
+			spikes = append(spikes, fmt.Sprintf("%s z=%.2f (volume %.2f vs average %.2f)",
+				interval, tf.VolumeZScore, tf.CurrentVolume, tf.AverageVolume))
+		}
+	}
+	if len(spikes) > 0 {
+		sb.WriteString("Volume spikes: " + strings.Join(spikes, ", ") + "\n\n")
+	}
 
-	sb.WriteString(fmt.Sprintf("current_price = %.2f, current_ema20 = %.3f, current_macd = %.3f, current_rsi (7 period) = %.3f\n\n",
-		data.CurrentPrice, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7))
+	if data.Regime != "" {
+		intervals := make([]string, 0, len(data.Timeframes))
+		for interval, tf := range data.Timeframes {
+			if tf.Regime != "" {
+				intervals = append(intervals, interval)
+			}
+		}
+		sort.Slice(intervals, func(i, j int) bool { return intervalDuration(intervals[i]) < intervalDuration(intervals[j]) })
+		parts := make([]string, len(intervals))
+		for i, interval := range intervals {
+			parts[i] = fmt.Sprintf("%s %s", interval, data.Timeframes[interval].Regime)
+		}
+		sb.WriteString(fmt.Sprintf("Regime: %s (score %.2f) | %s\n\n", data.Regime, data.RegimeScore, strings.Join(parts, ", ")))
+	}
 
-	sb.WriteString(fmt.Sprintf("In addition, here is the latest %s open interest and funding rate for perps:\n\n",
-		data.Symbol))
+	var impulses []string
+	for _, interval := range []string{"1h", "4h"} {
+		if tf, ok := data.Timeframes[interval]; ok && tf.ElderImpulse != "" {
+			impulses = append(impulses, fmt.Sprintf("%s %s (force index 13: %.2f)", interval, tf.ElderImpulse, tf.ForceIndex13))
+		}
+	}
+	if len(impulses) > 0 {
+		sb.WriteString("Elder impulse: " + strings.Join(impulses, " | ") + "\n\n")
+	}
+
+	if data.CorrelationBTC != nil && data.BetaBTC != nil {
+		sb.WriteString(fmt.Sprintf("Vs BTC (1h log returns, up to %d bars): correlation %.3f | beta %.3f\n\n",
+			benchmarkReturns, *data.CorrelationBTC, *data.BetaBTC))
+	}
 
-	if data.OpenInterest != nil {
-		sb.WriteString(fmt.Sprintf("Open Interest: Latest: %.2f Average: %.2f\n\n",
-			data.OpenInterest.Latest, data.OpenInterest.Average))
+	if data.RelativeStrength != nil {
+		sb.WriteString(formatRelativeStrength(data.RelativeStrength))
 	}
 
-	if data.Funding != nil {
-		sb.WriteString(fmt.Sprintf("Funding Rate: %.2e | Slope (per hour): %.2e | Next: %d\n\n",
-			data.Funding.Rate, data.Funding.Slope, data.Funding.NextTimeMs))
+	if vp := data.VolumeProfile; vp != nil {
+		position := "at POC"
+		switch vp.PriceVsPOC {
+		case 1:
+			position = "above POC"
+		case -1:
+			position = "below POC"
+		}
+		if !vp.PriceInValue {
+			position += ", outside value area"
+		}
+		sb.WriteString(fmt.Sprintf("Volume profile (%s, %d bars): POC %s | value area %s – %s | price %s\n\n",
+			vp.Interval, vp.Bars, formatFloat(vp.POC, prec.price), formatFloat(vp.VAL, prec.price),
+			formatFloat(vp.VAH, prec.price), position))
+	}
+
+	// 现货没有持仓量与资金费率，整块省略
+	if data.Market != SPOT {
+		sb.WriteString(fmt.Sprintf("In addition, here is the latest %s open interest and funding rate for perps:\n\n",
+			data.DisplaySymbol()))
+
+		if data.Unavailable(SectionOpenInterest) {
+			sb.WriteString("Open Interest: unavailable\n\n")
+		} else if data.OpenInterest != nil {
+			sb.WriteString(fmt.Sprintf("Open Interest (USD): Latest: %s%s Average: %s%s\n\n",
+				formatUSD(data.OpenInterest.LatestUSD), formatOIPercentile(data.OpenInterest), formatUSD(data.OpenInterest.AverageUSD),
+				formatOITurnover(data.OpenInterest)))
+		}
+
+		if data.Unavailable(SectionFunding) {
+			sb.WriteString("Funding: unavailable\n\n")
+		} else if data.Funding != nil {
+			sb.WriteString(fmt.Sprintf("Funding: %s | Slope (per hour): %.2e (R² %.2f) | Next: %d\n\n",
+				formatFunding(data.Funding), data.Funding.Slope, data.Funding.SlopeR2, data.Funding.NextTimeMs))
+			if data.Funding.LastSettledTimeMs > 0 {
+				sb.WriteString(fmt.Sprintf("Funding predicted vs last settled: %.4f%% / %.4f%% (interest %.4f%%)\n\n",
+					data.Funding.PredictedRate*100, data.Funding.LastSettledRate*100, data.Funding.InterestRate*100))
+			}
+			if data.Funding.Stats != nil {
+				sb.WriteString(formatFundingStats(data.Funding))
+			}
+		}
+
+		if pc := data.PriceContext; pc != nil && pc.MarkPrice > 0 && pc.IndexPrice > 0 {
+			sb.WriteString(fmt.Sprintf("Mark / Index / Basis(bps): %s / %s / %.2f | last vs mark: %.2f bps\n\n",
+				formatFloat(pc.MarkPrice, prec.price), formatFloat(pc.IndexPrice, prec.price), pc.BasisBps, pc.LastMarkBps))
+		}
+
+		if bh := data.BasisHistory; bh != nil {
+			sb.WriteString(fmt.Sprintf("Premium index (%s, %d bars, bps): current %.2f (p%.0f) | mean %.2f | slope %+.3f/bar | above index %.0f%% of bars\n\n",
+				bh.Interval, len(bh.Values), bh.Current, bh.Percentile, bh.Mean, bh.Slope, bh.PositiveShare*100))
+		}
+
+		if data.Unavailable(SectionSentiment) {
+			sb.WriteString("Long/short ratio: unavailable\n\n")
+		} else if data.Sentiment != nil {
+			sb.WriteString(formatSentiment(data.Sentiment))
+		}
 	}
 
-	if data.OpenInterest != nil {
-		sb.WriteString(fmt.Sprintf("OI Δ (5m/15m/1h/4h): %.2f / %.2f / %.2f / %.2f | Price Δ: %.4f / %.4f / %.4f / %.4f\n\n",
-			data.OpenInterest.Delta5m, data.OpenInterest.Delta15m, data.OpenInterest.Delta1h, data.OpenInterest.Delta4h,
-			data.OpenInterest.PriceDelta5m, data.OpenInterest.PriceDelta15m, data.OpenInterest.PriceDelta1h, data.OpenInterest.PriceDelta4h))
+	if data.OpenInterest != nil && !data.Unavailable(SectionOpenInterest) {
+		oi := data.OpenInterest
+		sb.WriteString(fmt.Sprintf("OI Δ (5m/15m/1h/4h): %+.2f%% (%s) / %+.2f%% (%s) / %+.2f%% (%s) / %+.2f%% (%s) | Price Δ over the same windows: %+.2f%% / %+.2f%% / %+.2f%% / %+.2f%%\n\n",
+			oi.Delta5mPct, formatUSD(oi.Delta5mUSD), oi.Delta15mPct, formatUSD(oi.Delta15mUSD),
+			oi.Delta1hPct, formatUSD(oi.Delta1hUSD), oi.Delta4hPct, formatUSD(oi.Delta4hUSD),
+			oi.PriceDelta5mPct, oi.PriceDelta15mPct, oi.PriceDelta1hPct, oi.PriceDelta4hPct))
+		if len(oi.Regimes) > 0 {
+			sb.WriteString(formatOIRegimes(oi.Regimes))
+		}
 	}
 
-	if data.Microstructure != nil {
-		sb.WriteString(fmt.Sprintf("Microstructure → CVD(1m/3m/15m): %.4f / %.4f / %.4f | OFI(1m/3m/15m): %.4f / %.4f / %.4f | OBI10: %.4f | MicroPrice: %.4f\n\n",
+	if data.Unavailable(SectionMicrostructure) {
+		sb.WriteString("Microstructure: unavailable\n\n")
+	} else if data.Microstructure != nil {
+		sb.WriteString(fmt.Sprintf("Microstructure → CVD(1m/3m/15m): %.4f / %.4f / %.4f | OFI(1m/3m/15m): %.4f / %.4f / %.4f | OBI10: %.4f | MicroPrice: %s\n\n",
 			data.Microstructure.CVD1m, data.Microstructure.CVD3m, data.Microstructure.CVD15m,
 			data.Microstructure.OFI1m, data.Microstructure.OFI3m, data.Microstructure.OFI15m,
-			data.Microstructure.OBI10, data.Microstructure.MicroPrice))
+			data.Microstructure.OBI10, formatFloat(data.Microstructure.MicroPrice, prec.delta)))
+	}
+
+	if data.Liquidations != nil {
+		sb.WriteString(formatLiquidations(data.Liquidations))
 	}
 
 	if data.IntradaySeries != nil {
 		sb.WriteString("Intraday series (3‑minute intervals, oldest → latest):\n\n")
 
 		if len(data.IntradaySeries.MidPrices) > 0 {
-			sb.WriteString(fmt.Sprintf("Mid prices: %s\n\n", formatFloatSlice(data.IntradaySeries.MidPrices)))
+			sb.WriteString(fmt.Sprintf("Mid prices: %s\n\n", formatFloatSlice(data.IntradaySeries.MidPrices, prec.price)))
 		}
 
 		if len(data.IntradaySeries.EMA20Values) > 0 {
-			sb.WriteString(fmt.Sprintf("EMA indicators (20‑period): %s\n\n", formatFloatSlice(data.IntradaySeries.EMA20Values)))
+			sb.WriteString(fmt.Sprintf("EMA indicators (20‑period): %s\n\n", formatFloatSlice(data.IntradaySeries.EMA20Values, prec.indicator)))
+		}
+
+		if len(data.IntradaySeries.DEMA20Values) > 0 {
+			sb.WriteString(fmt.Sprintf("DEMA (20‑period): %s\n\n", formatFloatSlice(data.IntradaySeries.DEMA20Values, prec.indicator)))
+		}
+
+		if len(data.IntradaySeries.TEMA20Values) > 0 {
+			sb.WriteString(fmt.Sprintf("TEMA (20‑period): %s\n\n", formatFloatSlice(data.IntradaySeries.TEMA20Values, prec.indicator)))
 		}
 
 		if len(data.IntradaySeries.MACDValues) > 0 {
-			sb.WriteString(fmt.Sprintf("MACD indicators: %s\n\n", formatFloatSlice(data.IntradaySeries.MACDValues)))
+			sb.WriteString(fmt.Sprintf("MACD indicators: %s\n\n", formatFloatSlice(data.IntradaySeries.MACDValues, prec.indicator)))
+		}
+
+		if len(data.IntradaySeries.MACDHistogramValues) > 0 {
+			sb.WriteString(fmt.Sprintf("MACD signal (9‑period): %s\n\n", formatFloatSlice(data.IntradaySeries.MACDSignalValues, prec.indicator)))
+			sb.WriteString(fmt.Sprintf("MACD histogram: %s\n\n", formatFloatSlice(data.IntradaySeries.MACDHistogramValues, prec.indicator)))
 		}
 
 		if len(data.IntradaySeries.RSI7Values) > 0 {
-			sb.WriteString(fmt.Sprintf("RSI indicators (7‑Period): %s\n\n", formatFloatSlice(data.IntradaySeries.RSI7Values)))
+			sb.WriteString(fmt.Sprintf("RSI indicators (7‑Period): %s\n\n", formatFloatSlice(data.IntradaySeries.RSI7Values, 3)))
 		}
 
 		if len(data.IntradaySeries.RSI14Values) > 0 {
-			sb.WriteString(fmt.Sprintf("RSI indicators (14‑Period): %s\n\n", formatFloatSlice(data.IntradaySeries.RSI14Values)))
+			sb.WriteString(fmt.Sprintf("RSI indicators (14‑Period): %s\n\n", formatFloatSlice(data.IntradaySeries.RSI14Values, 3)))
+		}
+
+		if len(data.IntradaySeries.BollingerValues) > 0 {
+			percentB := make([]float64, len(data.IntradaySeries.BollingerValues))
+			for i, b := range data.IntradaySeries.BollingerValues {
+				percentB[i] = b.PercentB
+			}
+			sb.WriteString(fmt.Sprintf("Bollinger %%B (20‑period, 2σ): %s\n\n", formatFloatSlice(percentB, 3)))
+		}
+
+		if len(data.IntradaySeries.ATRPercentValues) > 0 {
+			sb.WriteString(fmt.Sprintf("ATR (14‑period, %% of price): %s\n\n", formatFloatSlice(data.IntradaySeries.ATRPercentValues, 3)))
+		}
+
+		if len(data.IntradaySeries.OBVValues) > 0 {
+			sb.WriteString(fmt.Sprintf("OBV: %s\n\n", formatFloatSlice(data.IntradaySeries.OBVValues, 3)))
+		}
+
+		if len(data.IntradaySeries.ADValues) > 0 {
+			sb.WriteString(fmt.Sprintf("Accumulation/Distribution: %s\n\n", formatFloatSlice(data.IntradaySeries.ADValues, 3)))
+		}
+
+		if tf := data.Timeframes["3m"]; tf != nil && tf.CandleStats != nil {
+			cs := tf.CandleStats
+			sb.WriteString(fmt.Sprintf("Candle stats (last %d bars): avg body %s | avg upper wick %s | avg lower wick %s | body/range %.2f | latest true range p%.0f\n\n",
+				cs.Bars, formatFloat(cs.AvgBody, prec.delta), formatFloat(cs.AvgUpperWick, prec.delta),
+				formatFloat(cs.AvgLowerWick, prec.delta), cs.BodyToRange, cs.TRPercentile))
+		}
+
+		if len(data.IntradaySeries.StochKValues) > 0 {
+			sb.WriteString(fmt.Sprintf("Stochastic %%K / %%D (14,3,3): %s / %s\n\n",
+				formatFloatSlice(data.IntradaySeries.StochKValues, 3), formatFloatSlice(data.IntradaySeries.StochDValues, 3)))
 		}
 	}
 
 	if data.LongerTermContext != nil {
 		sb.WriteString("Longer‑term context (4‑hour timeframe):\n\n")
 
-		sb.WriteString(fmt.Sprintf("20‑Period EMA: %.3f vs. 50‑Period EMA: %.3f\n\n",
-			data.LongerTermContext.EMA20, data.LongerTermContext.EMA50))
+		if tf := data.Timeframes["4h"]; tf != nil {
+			sb.WriteString(fmt.Sprintf("Crosses: %s | %s | %s\n\n",
+				describeCross("EMA20", "EMA60", tf.Crosses.EMA20vsEMA60),
+				describeCross("MACD", "signal", tf.Crosses.MACDvsSignal),
+				describeCross("MACD", "zero", tf.Crosses.MACDvsZero)))
+		}
 
-		sb.WriteString(fmt.Sprintf("3‑Period ATR: %.3f vs. 14‑Period ATR: %.3f\n\n",
-			data.LongerTermContext.ATR3, data.LongerTermContext.ATR14))
+		lt := data.LongerTermContext
+		sb.WriteString(fmt.Sprintf("20‑Period EMA: %s vs. 50‑Period EMA: %s\n\n",
+			formatIndicator(lt.EMA20, lt.Ready("ema_20"), prec.indicator), formatIndicator(lt.EMA50, lt.Ready("ema_50"), prec.indicator)))
+
+		sb.WriteString(fmt.Sprintf("3‑Period ATR: %s vs. 14‑Period ATR: %s (%.3f%% of price)\n\n",
+			formatIndicator(lt.ATR3, lt.Ready("atr_3"), prec.indicator), formatIndicator(lt.ATR14, lt.Ready("atr_14"), prec.indicator),
+			lt.ATRPercent))
+
+		sb.WriteString(fmt.Sprintf("14‑Period ADX: %.3f\n\n", data.LongerTermContext.ADX14))
+
+		if fib, err := data.FibRetracement("4h"); err == nil {
+			direction := "up"
+			if fib.Direction < 0 {
+				direction = "down"
+			}
+			prices := make([]float64, len(fib.Levels))
+			for i, level := range fib.Levels {
+				prices[i] = level.Price
+			}
+			sb.WriteString(fmt.Sprintf("Fibonacci retracement (last %s swing %s → %s, 0.236/0.382/0.5/0.618/0.786): %s | price in %s band\n\n",
+				direction, formatFloat(fib.SwingStart, prec.price), formatFloat(fib.SwingEnd, prec.price),
+				formatFloatSlice(prices, prec.price), fib.Band))
+		}
+
+		if data.LongerTermContext.DonchianUpper != 0 {
+			sb.WriteString(fmt.Sprintf("20‑Period Donchian: upper %s / mid %s / lower %s | distance to upper: %.2f ATR, to lower: %.2f ATR\n\n",
+				formatFloat(data.LongerTermContext.DonchianUpper, prec.price), formatFloat(data.LongerTermContext.DonchianMid, prec.price),
+				formatFloat(data.LongerTermContext.DonchianLower, prec.price),
+				data.LongerTermContext.DonchianUpperDistATR, data.LongerTermContext.DonchianLowerDistATR))
+		}
 
 		sb.WriteString(fmt.Sprintf("Current Volume: %.3f vs. Average Volume: %.3f\n\n",
 			data.LongerTermContext.CurrentVolume, data.LongerTermContext.AverageVolume))
 
 		if len(data.LongerTermContext.MACDValues) > 0 {
-			sb.WriteString(fmt.Sprintf("MACD indicators: %s\n\n", formatFloatSlice(data.LongerTermContext.MACDValues)))
+			sb.WriteString(fmt.Sprintf("MACD indicators: %s\n\n", formatFloatSlice(data.LongerTermContext.MACDValues, prec.indicator)))
+		}
+
+		if len(data.LongerTermContext.MACDHistogramValues) > 0 {
+			sb.WriteString(fmt.Sprintf("MACD signal (9‑period): %s\n\n", formatFloatSlice(data.LongerTermContext.MACDSignalValues, prec.indicator)))
+			sb.WriteString(fmt.Sprintf("MACD histogram: %s\n\n", formatFloatSlice(data.LongerTermContext.MACDHistogramValues, prec.indicator)))
 		}
 
 		if len(data.LongerTermContext.RSI14Values) > 0 {
-			sb.WriteString(fmt.Sprintf("RSI indicators (14‑Period): %s\n\n", formatFloatSlice(data.LongerTermContext.RSI14Values)))
+			sb.WriteString(fmt.Sprintf("RSI indicators (14‑Period): %s\n\n", formatFloatSlice(data.LongerTermContext.RSI14Values, 3)))
 		}
 	}
 
 	return sb.String()
 }
 
-// formatFloatSlice 格式化float64切片为字符串
-func formatFloatSlice(values []float64) string {
+// describeCross 交叉状态的文字描述，如 "EMA20 above EMA60 (crossed 3 bars ago)"
+func describeCross(name, other string, c Cross) string {
+	var position string
+	switch c.Side {
+	case 1:
+		position = "above"
+	case -1:
+		position = "below"
+	default:
+		return fmt.Sprintf("%s vs %s: n/a", name, other)
+	}
+	switch c.BarsSince {
+	case -1:
+		return fmt.Sprintf("%s %s %s (no cross in window)", name, position, other)
+	case 0:
+		return fmt.Sprintf("%s %s %s (crossed this bar)", name, position, other)
+	}
+	return fmt.Sprintf("%s %s %s (crossed %d bars ago)", name, position, other, c.BarsSince)
+}
+
+// formatFloatSlice 格式化float64切片为字符串，保留decimals位小数
+func formatFloatSlice(values []float64, decimals int) string {
 	strValues := make([]string, len(values))
 	for i, v := range values {
-		strValues[i] = fmt.Sprintf("%.3f", v)
+		strValues[i] = formatFloat(v, decimals)
 	}
 	return "[" + strings.Join(strValues, ", ") + "]"
 }
 
-type aggTrade struct {
-	Quantity     float64
-	Price        float64
-	BuyerIsMaker bool
-	Timestamp    int64
+// formatSignificantDigits 没有tick size时价格保留的有效数字位数
+const formatSignificantDigits = 5
+
+// formatPrecision Format中各类数值的小数位数
+type formatPrecision struct {
+	price     int // 价格（当前价、中间价）
+	indicator int // 与价格同量纲的指标（EMA、MACD、ATR）
+	delta     int // 价格变化量与微观价格
 }
 
-type orderBookSnapshot struct {
-	Bids [][2]float64
-	Asks [][2]float64
+// precisionFor 根据交易对tick size确定小数位数，没有SymbolInfo时按当前价的有效数字推算
+// 各项不低于原先的固定精度（价格2位、指标3位、变化量4位），高价币的输出保持不变
+func precisionFor(data *Data) formatPrecision {
+	var decimals int
+	if data.SymbolInfo != nil && data.SymbolInfo.TickSize > 0 {
+		decimals = stepDecimals(data.SymbolInfo.TickSize)
+	} else {
+		decimals = significantDecimals(data.CurrentPrice, formatSignificantDigits)
+	}
+	return formatPrecision{
+		price:     max(2, decimals),
+		indicator: max(3, decimals+2),
+		delta:     max(4, decimals+1),
+	}
 }
 
-func getMicrostructureData(symbol string) *MicrostructureData {
-	data := &MicrostructureData{}
+// significantDecimals 使v保留digits位有效数字所需的小数位数
+func significantDecimals(v float64, digits int) int {
+	v = math.Abs(v)
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	decimals := digits - 1 - int(math.Floor(math.Log10(v)))
+	return min(max(decimals, 0), 16)
+}
 
-	now := time.Now().UnixMilli()
+// formatFloat 按指定小数位数格式化
+func formatFloat(v float64, decimals int) string {
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
 
-	if trades, err := getAggTrades(symbol, now-60*1000); err == nil {
-		data.CVD1m, data.OFI1m = aggregateFlow(trades)
+// formatOIPercentile 如 " (p96 of 30d)"，没有4h历史时为空
+func formatOIPercentile(oi *OIData) string {
+	if len(oi.History4h) == 0 {
+		return ""
 	}
+	return fmt.Sprintf(" (p%.0f of 30d)", oi.Percentile30d)
+}
 
-	if trades, err := getAggTrades(symbol, now-3*60*1000); err == nil {
-		data.CVD3m, data.OFI3m = aggregateFlow(trades)
+// formatOITurnover 如 " | OI/24h volume: 1.85"，24小时成交量不可用时为空
+func formatOITurnover(oi *OIData) string {
+	if oi.Volume24h <= 0 {
+		return ""
 	}
+	return fmt.Sprintf(" | OI/24h volume: %.2f", oi.OIToVolume24h)
+}
 
-	if trades, err := getAggTrades(symbol, now-15*60*1000); err == nil {
-		data.CVD15m, data.OFI15m = aggregateFlow(trades)
-	}
+// formatUSD 美元金额按K/M/B缩写，如 "$1.23B"、"-$456.7K"
+func formatUSD(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%s$%.2fB", sign, v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%s$%.2fM", sign, v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%s$%.1fK", sign, v/1e3)
+	}
+	return fmt.Sprintf("%s$%.0f", sign, v)
+}
 
-	if depth, err := getOrderBook(symbol, 10); err == nil {
-		data.OBI10 = calculateOrderBookImbalance(depth)
-		data.MicroPrice = calculateMicroPrice(depth)
+// formatIndicator 预热区内（ok为false）的指标输出 "n/a"，避免0被误读为有效值
+func formatIndicator(v float64, ok bool, decimals int) string {
+	if !ok {
+		return "n/a"
 	}
+	return formatFloat(v, decimals)
+}
 
-	return data
+// AggTrade 归集成交
+type AggTrade struct {
+	Quantity     float64 `json:"quantity"`
+	Price        float64 `json:"price"`
+	BuyerIsMaker bool    `json:"buyer_is_maker"`
+	Timestamp    int64   `json:"timestamp_ms"`
 }
 
-func getAggTrades(symbol string, startTime int64) ([]aggTrade, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/aggTrades?symbol=%s&startTime=%d&limit=1000", symbol, startTime)
+// OrderBook 盘口快照，每档为 [价格, 数量]，买盘从高到低、卖盘从低到高
+type OrderBook struct {
+	Bids [][2]float64 `json:"bids"`
+	Asks [][2]float64 `json:"asks"`
+}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+// getMicrostructureData 获取微结构指标，单项失败时该项保持为0并记入report，仅限频错误会返回
+func (c *Client) getMicrostructureData(ctx context.Context, report *FetchReport, symbol string) (*MicrostructureData, error) {
+	data := &MicrostructureData{}
+
+	now := time.Now().UnixMilli()
+
+	windows := []struct {
+		name     string
+		window   time.Duration
+		cvd, ofi *float64
+	}{
+		{"1m", time.Minute, &data.CVD1m, &data.OFI1m},
+		{"3m", 3 * time.Minute, &data.CVD3m, &data.OFI3m},
+		{"15m", 15 * time.Minute, &data.CVD15m, &data.OFI15m},
+	}
+	failed := 0
+	for _, w := range windows {
+		if cvd, ofi, ok := c.liveFlow(symbol, w.window); ok {
+			*w.cvd, *w.ofi = cvd, ofi
+			continue
+		}
+		trades, err := c.source().AggTrades(ctx, symbol, now-w.window.Milliseconds())
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, err
+			}
+			report.warn(SectionMicrostructure, "aggTrades "+w.name, err)
+			failed++
+			continue
+		}
+		*w.cvd, *w.ofi = aggregateFlow(trades)
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	if obi, microPrice, window, ok := c.liveBook(symbol); ok {
+		data.OBI10 = obi
+		data.MicroPrice = microPrice
+		data.BookWindowMs = window.Milliseconds()
+		return data, nil
+	}
+
+	depth, err := c.source().Depth(ctx, symbol, 10)
 	if err != nil {
-		return nil, err
+		if IsRateLimited(err) {
+			return nil, err
+		}
+		if failed == len(windows) {
+			report.fail(SectionMicrostructure, "depth", err)
+		} else {
+			report.warn(SectionMicrostructure, "depth", err)
+		}
+		return data, nil
 	}
+	data.OBI10 = calculateOrderBookImbalance(depth)
+	data.MicroPrice = calculateMicroPrice(depth)
+
+	return data, nil
+}
+
+func (c *Client) getAggTrades(ctx context.Context, symbol string, startTime int64) ([]AggTrade, error) {
+	url := fmt.Sprintf("%s?symbol=%s&startTime=%d&limit=1000", c.apiEndpoint("/aggTrades"), symbol, startTime)
 
 	var raw []struct {
 		Price        string `json:"p"`
@@ -949,11 +2134,12 @@ func getAggTrades(symbol string, startTime int64) ([]aggTrade, error) {
 		Timestamp    int64  `json:"T"`
 	}
 
-	if err := json.Unmarshal(body, &raw); err != nil {
+	if err := c.getJSON(ctx, url, weightAggTrades, &raw); err != nil {
 		return nil, err
 	}
 
-	trades := make([]aggTrade, 0, len(raw))
+	coinM := c.config().market == COINM
+	trades := make([]AggTrade, 0, len(raw))
 	for _, item := range raw {
 		qty, err := strconv.ParseFloat(item.Quantity, 64)
 		if err != nil {
@@ -963,7 +2149,10 @@ func getAggTrades(symbol string, startTime int64) ([]aggTrade, error) {
 		if err != nil {
 			continue
 		}
-		trades = append(trades, aggTrade{
+		if coinM {
+			qty = contractsToBase(symbol, qty, price)
+		}
+		trades = append(trades, AggTrade{
 			Quantity:     qty,
 			Price:        price,
 			BuyerIsMaker: item.BuyerIsMaker,
@@ -978,7 +2167,7 @@ func getAggTrades(symbol string, startTime int64) ([]aggTrade, error) {
 	return trades, nil
 }
 
-func aggregateFlow(trades []aggTrade) (float64, float64) {
+func aggregateFlow(trades []AggTrade) (float64, float64) {
 	if len(trades) == 0 {
 		return 0, 0
 	}
@@ -1003,30 +2192,20 @@ func aggregateFlow(trades []aggTrade) (float64, float64) {
 	return cvd, ofi
 }
 
-func getOrderBook(symbol string, limit int) (*orderBookSnapshot, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=%d", symbol, limit)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) getOrderBook(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("%s?symbol=%s&limit=%d", c.apiEndpoint("/depth"), symbol, limit)
 
 	var raw struct {
 		Bids [][]string `json:"bids"`
 		Asks [][]string `json:"asks"`
 	}
 
-	if err := json.Unmarshal(body, &raw); err != nil {
+	if err := c.getJSON(ctx, url, depthWeight(limit), &raw); err != nil {
 		return nil, err
 	}
 
-	snapshot := &orderBookSnapshot{}
+	coinM := c.config().market == COINM
+	snapshot := &OrderBook{}
 	for _, bid := range raw.Bids {
 		if len(bid) < 2 {
 			continue
@@ -1036,6 +2215,9 @@ func getOrderBook(symbol string, limit int) (*orderBookSnapshot, error) {
 		if err1 != nil || err2 != nil {
 			continue
 		}
+		if coinM {
+			qty = contractsToBase(symbol, qty, price)
+		}
 		snapshot.Bids = append(snapshot.Bids, [2]float64{price, qty})
 	}
 
@@ -1048,13 +2230,16 @@ func getOrderBook(symbol string, limit int) (*orderBookSnapshot, error) {
 		if err1 != nil || err2 != nil {
 			continue
 		}
+		if coinM {
+			qty = contractsToBase(symbol, qty, price)
+		}
 		snapshot.Asks = append(snapshot.Asks, [2]float64{price, qty})
 	}
 
 	return snapshot, nil
 }
 
-func calculateOrderBookImbalance(book *orderBookSnapshot) float64 {
+func calculateOrderBookImbalance(book *OrderBook) float64 {
 	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
 		return 0
 	}
@@ -1079,7 +2264,7 @@ func calculateOrderBookImbalance(book *orderBookSnapshot) float64 {
 	return (sumBids - sumAsks) / total
 }
 
-func calculateMicroPrice(book *orderBookSnapshot) float64 {
+func calculateMicroPrice(book *OrderBook) float64 {
 	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
 		return 0
 	}
@@ -1095,12 +2280,13 @@ func calculateMicroPrice(book *orderBookSnapshot) float64 {
 }
 
 // Normalize 标准化symbol,确保是USDT交易对
+// 已带有可识别计价资产后缀（见KnownQuotes）的symbol原样返回，如 BTCUSDC
 func Normalize(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-	if strings.HasSuffix(symbol, "USDT") {
-		return symbol
+	normalized, err := NormalizeQuote(symbol, DefaultQuote)
+	if err != nil {
+		return strings.ToUpper(strings.TrimSpace(symbol))
 	}
-	return symbol + "USDT"
+	return normalized
 }
 
 // parseFloat 解析float值
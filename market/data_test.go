@@ -0,0 +1,680 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetMergesConcurrentCallsForSameSymbol(t *testing.T) {
+	const callers = 50
+
+	// 单次Get对上游的请求数作为基准
+	solo := &fakeBinance{}
+	soloSrv := newTestServer(t, solo)
+	if _, err := newRESTClient(soloSrv, WithoutClientCache()).Get(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("solo Get: %v", err)
+	}
+
+	// 第一个请求挂起，直到所有调用方都已进入Get
+	fake := &fakeBinance{}
+	arrived, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			close(arrived)
+			<-release
+		})
+		fake.ServeHTTP(w, r)
+	}))
+	c := newRESTClient(srv, WithoutClientCache())
+
+	results := make([]*Data, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = c.Get(context.Background(), "BTCUSDT")
+		}()
+	}
+	<-arrived
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("caller %d: %v", i, err)
+		}
+		if results[i] != results[0] {
+			t.Fatalf("caller %d got a separate result, want the shared one", i)
+		}
+	}
+	if got, want := len(fake.Requests()), len(solo.Requests()); got != want {
+		t.Errorf("%d concurrent Gets sent %d upstream requests, want %d (same as one Get)", callers, got, want)
+	}
+}
+
+var update = flag.Bool("update", false, "重新生成testdata下的golden文件")
+
+// goldenData 覆盖常用分区的固定Data，不依赖时间与数据源
+func goldenData() *Data {
+	return &Data{
+		Symbol:        "BTCUSDT",
+		CurrentPrice:  65432.1,
+		PriceChange1h: 0.42,
+		PriceChange4h: -1.25,
+		CurrentEMA20:  65400.55,
+		CurrentMACD:   12.345,
+		CurrentRSI7:   61.2,
+		Ticker24h: &Ticker24h{
+			Symbol: "BTCUSDT", High: 66000, Low: 64000, Volume: 120000, QuoteVolume: 7.8e9,
+			PriceChangePercent: 1.5, WeightedAvgPrice: 65000, TradeCount: 2500000,
+			OpenTimeMs: 1700000000000, CloseTimeMs: 1700086399999,
+		},
+		OpenInterest: &OIData{
+			Latest: 80000, Average: 78000, Delta1h: 500, TimestampMs: 1700086000000,
+			LatestUSD: 5.2345e9, AverageUSD: 5.1e9, Delta1hPct: 0.63, Delta1hUSD: 3.27e7, PriceDelta1hPct: 0.42,
+		},
+		Funding: &FundingData{
+			Rate: 0.0001, Slope: 1.5e-6, SlopeR2: 0.8, NextTimeMs: 1700092800000, APR: 0.1095,
+			Percentile30d: 55, History30d: 90, Streak: 4, PredictedRate: 0.0001,
+		},
+		Microstructure: &MicrostructureData{
+			CVD1m: 12.5, CVD3m: -4.25, CVD15m: 30, OFI1m: 0.1, OFI3m: -0.05, OFI15m: 0.2,
+			OBI10: 0.15, MicroPrice: 65432.25,
+		},
+		Timeframes: map[string]*TimeframeMetrics{
+			"3m": {Interval: "3m", Close: 65432.1, RSI7: 61.2, RSI14: 55.5, MACD: 12.345, EMA20: 65400.55, EMA60: 65300},
+		},
+		IntradaySeries: &IntradayData{
+			MidPrices:   []float64{65400, 65420.5, 65432.1},
+			EMA20Values: []float64{65390.1, 65395.2, 65400.55},
+			RSI7Values:  []float64{55, 58.5, 61.2},
+		},
+	}
+}
+
+// checkGolden 对比got与testdata/name，-update时改为写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v（用 go test -run %s -update 生成）", err, t.Name())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestDataJSONGolden(t *testing.T) {
+	got, err := json.MarshalIndent(goldenData(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "data.golden.json", append(got, '\n'))
+}
+
+func TestFormatGolden(t *testing.T) {
+	checkGolden(t, "format.golden.txt", []byte(Format(goldenData())))
+}
+
+func TestGetKlinesPaginatesBeyondMaxPerRequest(t *testing.T) {
+	const limit = 2*maxKlinesPerRequest + 200
+	fake := &fakeBinance{}
+	c := newRESTClient(newTestServer(t, fake))
+
+	klines, err := c.getKlines(context.Background(), "BTCUSDT", "1h", limit)
+	if err != nil {
+		t.Fatalf("getKlines: %v", err)
+	}
+	if len(klines) != limit {
+		t.Fatalf("len = %d, want %d", len(klines), limit)
+	}
+	if got := fake.Count("/klines"); got != 3 {
+		t.Errorf("requests = %d, want 3 pages", got)
+	}
+	// 分页边界处既不重复也不缺失：开盘时间严格按周期递增
+	step := intervalDuration("1h").Milliseconds()
+	for i := 1; i < len(klines); i++ {
+		if d := klines[i].OpenTime - klines[i-1].OpenTime; d != step {
+			t.Fatalf("klines[%d].OpenTime - klines[%d].OpenTime = %d, want %d", i, i-1, d, step)
+		}
+	}
+	if last, now := klines[len(klines)-1].OpenTime, time.Now().UnixMilli(); now-last >= step {
+		t.Errorf("last kline opened %dms ago, want the current bar", now-last)
+	}
+}
+
+func TestFormatPrecisionForLowPricedSymbols(t *testing.T) {
+	tests := []struct {
+		name string
+		data *Data
+		want []string
+	}{
+		{
+			// 1000PEPEUSDT：tick size 0.0000001，价格保留7位、指标9位
+			name: "tick size",
+			data: &Data{
+				Symbol: "1000PEPEUSDT", Alias: "PEPEUSDT", Multiplier: 1000,
+				SymbolInfo:   &SymbolInfo{Symbol: "1000PEPEUSDT", TickSize: 0.0000001},
+				CurrentPrice: 0.0123456, CurrentEMA20: 0.012301234, CurrentMACD: 0.000012345, CurrentRSI7: 55,
+				IntradaySeries: &IntradayData{MidPrices: []float64{0.0123401, 0.0123456}},
+			},
+			want: []string{
+				"current_price = 0.0123456, current_ema20 = 0.012301234, current_macd = 0.000012345",
+				"Mid prices: [0.0123401, 0.0123456]",
+				"PEPEUSDT (1000PEPEUSDT) open interest",
+			},
+		},
+		{
+			// 没有SymbolInfo时按5位有效数字
+			name: "significant digits",
+			data: &Data{
+				Symbol: "PEPEUSDT", CurrentPrice: 0.0000123456, CurrentEMA20: 0.0000122222,
+				Microstructure: &MicrostructureData{MicroPrice: 0.0000123461},
+			},
+			want: []string{
+				"current_price = 0.000012346, current_ema20 = 0.00001222220",
+				"MicroPrice: 0.0000123461",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := Format(tt.data)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("Format output missing %q\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "current_price = 0.00,") {
+				t.Errorf("price formatted as zero:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestGetResolvesMultiplierAlias(t *testing.T) {
+	fake := &fakeBinance{}
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/exchangeInfo") {
+			w.Write([]byte(`{"symbols":[{"symbol":"1000PEPEUSDT","pair":"1000PEPEUSDT","contractType":"PERPETUAL","status":"TRADING",` +
+				`"baseAsset":"1000PEPE","quoteAsset":"USDT","pricePrecision":7,"quantityPrecision":0,` +
+				`"filters":[{"filterType":"PRICE_FILTER","tickSize":"0.0000001"}]}]}`))
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	c := NewClient(WithBaseURL(srv.URL), WithRetry(RetryPolicy{}))
+
+	data, err := c.Get(context.Background(), "PEPE")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data.Symbol != "1000PEPEUSDT" || data.Alias != "PEPEUSDT" || data.Multiplier != 1000 {
+		t.Errorf("Symbol/Alias/Multiplier = %s/%s/%v, want 1000PEPEUSDT/PEPEUSDT/1000", data.Symbol, data.Alias, data.Multiplier)
+	}
+	for _, req := range fake.Requests() {
+		if strings.Contains(req, "symbol=PEPEUSDT") {
+			t.Errorf("request %s used the alias instead of 1000PEPEUSDT", req)
+		}
+	}
+	if prec := precisionFor(data); prec.price != 7 {
+		t.Errorf("price decimals = %d, want 7 from the tick size", prec.price)
+	}
+}
+
+func TestGetInsufficientKlines(t *testing.T) {
+	tests := []struct {
+		name    string
+		bars    int
+		opts    []GetOption
+		wantErr bool
+	}{
+		{"empty", 0, nil, true},
+		{"one bar", 1, nil, false},
+		{"one bar with WithMinKlines(2)", 1, []GetOption{WithMinKlines(2)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newFixtureSource("BTCUSDT", time.Now())
+			src.SetKlines("BTCUSDT", "3m", fixtureKlines("3m", tt.bars, time.Now().UnixMilli()/180000*180000))
+			c := NewClient(WithSource(src))
+
+			data, err := c.Get(context.Background(), "BTCUSDT", tt.opts...)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				if tf := data.Timeframes["3m"]; tf == nil || tf.Ready("ema_20") {
+					t.Errorf("Timeframes[3m] = %+v, want ema_20 still warming up", tf)
+				}
+				return
+			}
+			var insufficient *InsufficientDataError
+			if !errors.Is(err, ErrInsufficientData) || !errors.As(err, &insufficient) {
+				t.Fatalf("err = %v, want *InsufficientDataError", err)
+			}
+			if insufficient.Interval != "3m" || insufficient.Got != tt.bars {
+				t.Errorf("Interval/Got = %s/%d, want 3m/%d", insufficient.Interval, insufficient.Got, tt.bars)
+			}
+		})
+	}
+}
+
+func TestSeriesCalculationsWithFewBars(t *testing.T) {
+	if calculateIntradaySeries(nil, SmoothWilder) != nil || calculateLongerTermData(nil, SmoothWilder) != nil {
+		t.Error("want nil for empty klines")
+	}
+
+	one := fixtureKlines("4h", 1, 0)
+	if got := calculateIntradaySeries(one, SmoothWilder); got == nil {
+		t.Error("calculateIntradaySeries(1 bar) = nil")
+	}
+	got := calculateLongerTermData(one, SmoothWilder)
+	if got == nil {
+		t.Fatal("calculateLongerTermData(1 bar) = nil")
+	}
+	if got.CurrentVolume != one[0].Volume || got.AverageVolume != 0 {
+		t.Errorf("CurrentVolume/AverageVolume = %v/%v, want %v/0 (no completed bars)", got.CurrentVolume, got.AverageVolume, one[0].Volume)
+	}
+	if len(got.WarmingUp) == 0 {
+		t.Error("WarmingUp empty, want every indicator still warming up")
+	}
+
+	// 平均成交量不含最后一根未收盘的K线
+	klines := fixtureKlines("4h", 3, 0)
+	klines[2].Volume = 1e9
+	got = calculateLongerTermData(klines, SmoothWilder)
+	if want := (klines[0].Volume + klines[1].Volume) / 2; got.AverageVolume != want {
+		t.Errorf("AverageVolume = %v, want %v", got.AverageVolume, want)
+	}
+}
+
+// 以下为改写前的逐前缀计算方式：对最后10根K线各自在klines[:i+1]上从头计算，作为对照基线
+
+func naiveEMA(closes []float64, period int) float64 {
+	ema := 0.0
+	for _, c := range closes[:period] {
+		ema += c
+	}
+	ema /= float64(period)
+	alpha := 2.0 / float64(period+1)
+	for _, c := range closes[period:] {
+		ema = (c-ema)*alpha + ema
+	}
+	return ema
+}
+
+func naiveRSI(klines []Kline, period int) float64 {
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := klines[i].Close - klines[i-1].Close
+		gain += math.Max(change, 0)
+		loss += math.Max(-change, 0)
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	for i := period + 1; i < len(klines); i++ {
+		change := klines[i].Close - klines[i-1].Close
+		gain = (gain*float64(period-1) + math.Max(change, 0)) / float64(period)
+		loss = (loss*float64(period-1) + math.Max(-change, 0)) / float64(period)
+	}
+	if loss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+func naiveATR(klines []Kline, period int) float64 {
+	tr := func(i int) float64 {
+		prev := klines[i-1].Close
+		return math.Max(klines[i].High-klines[i].Low, math.Max(math.Abs(klines[i].High-prev), math.Abs(klines[i].Low-prev)))
+	}
+	atr := 0.0
+	for i := 1; i <= period; i++ {
+		atr += tr(i)
+	}
+	atr /= float64(period)
+	for i := period + 1; i < len(klines); i++ {
+		atr = (atr*float64(period-1) + tr(i)) / float64(period)
+	}
+	return atr
+}
+
+// naiveIntradaySeries 逐前缀计算最后seriesPoints根K线的EMA20、MACD、RSI7/14与ATR14
+func naiveIntradaySeries(klines []Kline) (ema20, macd, rsi7, rsi14, atr14 []float64) {
+	for i := len(klines) - seriesPoints; i < len(klines); i++ {
+		prefix := klines[:i+1]
+		closes := Klines(prefix).Closes()
+		ema20 = append(ema20, naiveEMA(closes, 20))
+		macd = append(macd, naiveEMA(closes, macdFastPeriod)-naiveEMA(closes, macdSlowPeriod))
+		rsi7 = append(rsi7, naiveRSI(prefix, 7))
+		rsi14 = append(rsi14, naiveRSI(prefix, 14))
+		atr14 = append(atr14, naiveATR(prefix, 14))
+	}
+	return ema20, macd, rsi7, rsi14, atr14
+}
+
+func TestCalculateIntradaySeriesMatchesPrefixBaseline(t *testing.T) {
+	klines := fixtureKlines("3m", 200, 0)
+	got := calculateIntradaySeries(klines, SmoothWilder)
+	ema20, macd, rsi7, rsi14, atr14 := naiveIntradaySeries(klines)
+
+	for _, tt := range []struct {
+		name      string
+		got, want []float64
+	}{
+		{"EMA20Values", got.EMA20Values, ema20},
+		{"MACDValues", got.MACDValues, macd},
+		{"RSI7Values", got.RSI7Values, rsi7},
+		{"RSI14Values", got.RSI14Values, rsi14},
+		{"ATR14Values", got.ATR14Values, atr14},
+	} {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("len(%s) = %d, want %d", tt.name, len(tt.got), len(tt.want))
+			continue
+		}
+		want := make(map[int]float64, len(tt.want))
+		for i, v := range tt.want {
+			want[i] = v
+		}
+		assertSeries(t, tt.name, tt.got, want)
+	}
+}
+
+func BenchmarkCalculateIntradaySeries(b *testing.B) {
+	klines := fixtureKlines("3m", 200, 0)
+	for b.Loop() {
+		calculateIntradaySeries(klines, SmoothWilder)
+	}
+}
+
+// BenchmarkIntradayIndicators 同样的EMA20、MACD、RSI7/14与ATR14：完整序列计算一次 vs 逐前缀重新计算
+func BenchmarkIntradayIndicators(b *testing.B) {
+	klines := fixtureKlines("3m", 200, 0)
+	b.Run("series", func(b *testing.B) {
+		for b.Loop() {
+			recentValues(EMASeries(klines, 20), seriesPoints)
+			macd, _, _ := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+			recentValues(macd, seriesPoints)
+			recentValues(RSISeries(klines, 7), seriesPoints)
+			recentValues(RSISeries(klines, 14), seriesPoints)
+			recentValues(ATRSeries(klines, 14), seriesPoints)
+		}
+	})
+	b.Run("prefix", func(b *testing.B) {
+		for b.Loop() {
+			naiveIntradaySeries(klines)
+		}
+	})
+}
+
+func TestCalculateStochastic(t *testing.T) {
+	klines := referenceKlines()
+	k, d := calculateStochastic(klines, stochKPeriod, stochDPeriod, stochSmoothing)
+	assertSeries(t, "stochastic", []float64{k, d}, map[int]float64{0: 33.6555142503, 1: 28.0813095308})
+
+	// 同一参考值在TimeframeMetrics与3m日内序列中
+	metrics := calculateTimeframeMetrics("3m", klines, NewClient().newGetOptions(nil))
+	if metrics.StochK != k || metrics.StochD != d {
+		t.Errorf("TimeframeMetrics StochK/StochD = %v/%v, want %v/%v", metrics.StochK, metrics.StochD, k, d)
+	}
+	series := calculateIntradaySeries(klines, SmoothWilder)
+	if n := len(series.StochKValues); n != seriesPoints || series.StochKValues[n-1] != k || series.StochDValues[n-1] != d {
+		t.Errorf("StochKValues/StochDValues = %v/%v, want %d points ending at %v/%v", series.StochKValues, series.StochDValues, seriesPoints, k, d)
+	}
+
+	// 横盘：回看区间内最高等于最低时原始%K取50
+	flat := make([]Kline, 20)
+	for i := range flat {
+		flat[i] = Kline{Open: 10, High: 10, Low: 10, Close: 10}
+	}
+	if k, d := calculateStochastic(flat, stochKPeriod, stochDPeriod, stochSmoothing); k != 50 || d != 50 {
+		t.Errorf("flat market %%K/%%D = %v/%v, want 50/50", k, d)
+	}
+
+	// 预热区：K线不足kPeriod+smoothing+dPeriod−2根时%D为0
+	if _, d := calculateStochastic(klines[:stochKPeriod+stochSmoothing+stochDPeriod-3], stochKPeriod, stochDPeriod, stochSmoothing); d != 0 {
+		t.Errorf("%%D during warm-up = %v, want 0", d)
+	}
+}
+
+// legacyPercentageChange 改用ROC之前计算PriceChange1h/4h的实现，用于兼容性对比
+func legacyPercentageChange(klines []Kline, barsBack int) float64 {
+	if len(klines) == 0 || barsBack <= 0 || len(klines) <= barsBack {
+		return 0
+	}
+	latest := klines[len(klines)-1].Close
+	reference := klines[len(klines)-1-barsBack].Close
+	if reference == 0 {
+		return 0
+	}
+	return ((latest - reference) / reference) * 100
+}
+
+func TestPriceChangeMatchesLegacyCalculation(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	c := NewClient(WithSource(newFixtureSource("BTCUSDT", now)))
+	data, err := c.Get(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	// Get按默认数量（200根）请求K线，对同样的K线用旧实现计算：1h用60根1m，4h用4根1h
+	lastOpen := func(interval string) int64 {
+		step := intervalDuration(interval).Milliseconds()
+		return now.UnixMilli() / step * step
+	}
+	want1h := legacyPercentageChange(fixtureKlines("1m", 200, lastOpen("1m")), 60)
+	want4h := legacyPercentageChange(fixtureKlines("1h", 200, lastOpen("1h")), 4)
+	if want1h == 0 || want4h == 0 {
+		t.Fatalf("fixture gives zero legacy changes (%v, %v), want a non-trivial comparison", want1h, want4h)
+	}
+	if data.PriceChange1h != want1h {
+		t.Errorf("PriceChange1h = %v, want legacy %v", data.PriceChange1h, want1h)
+	}
+	if data.PriceChange4h != want4h {
+		t.Errorf("PriceChange4h = %v, want legacy %v", data.PriceChange4h, want4h)
+	}
+	for _, n := range defaultROCLookbacks {
+		if got, want := data.Timeframes["1h"].ROC[n], legacyPercentageChange(fixtureKlines("1h", 200, lastOpen("1h")), n); got != want {
+			t.Errorf("Timeframes[1h].ROC[%d] = %v, want legacy %v", n, got, want)
+		}
+	}
+
+	// ROC与旧实现的边界行为一致：K线不足、回看数<=0、基准价为0时均为0
+	flat := func(prices ...float64) []Kline {
+		klines := make([]Kline, len(prices))
+		for i, p := range prices {
+			klines[i] = Kline{Close: p}
+		}
+		return klines
+	}
+	for _, tt := range []struct {
+		klines []Kline
+		bars   int
+	}{
+		{nil, 1}, {flat(100), 1}, {flat(100, 110), 0}, {flat(100, 110), -1},
+		{flat(100, 110), 1}, {flat(100, 110), 2}, {flat(0, 110), 1}, {flat(100, 90, 120), 2},
+	} {
+		if got, want := ROC(tt.klines, tt.bars), legacyPercentageChange(tt.klines, tt.bars); got != want {
+			t.Errorf("ROC(%v, %d) = %v, want legacy %v", tt.klines, tt.bars, got, want)
+		}
+	}
+}
+
+func TestCalculateAroonTieBreak(t *testing.T) {
+	bars := func(highs, lows []float64) []Kline {
+		klines := make([]Kline, len(highs))
+		for i := range highs {
+			klines[i] = Kline{High: highs[i], Low: lows[i], Open: lows[i], Close: highs[i]}
+		}
+		return klines
+	}
+	tests := []struct {
+		name             string
+		highs, lows      []float64
+		wantUp, wantDown float64
+	}{
+		// 最高价12出现在第1、3根，取最近的第3根：距当前2根，Up=(5−2)/5×100=60（取最早一根会得到40）
+		// 最低价3出现在第1、4根，取第4根：距当前1根，Down=(5−1)/5×100=80
+		{"most recent tie wins", []float64{10, 12, 9, 12, 11, 10}, []float64{5, 3, 6, 7, 3, 4}, 60, 80},
+		// 当前K线追平窗口内的最高价/最低价，视为新高/新低仍在延续
+		{"tie on current bar", []float64{10, 12, 9, 8, 11, 12}, []float64{3, 5, 6, 7, 5, 3}, 100, 100},
+		// 窗口外（第period+2根之前）的更高价不参与比较
+		{"extreme outside window", []float64{99, 10, 12, 9, 12, 11, 10}, []float64{1, 5, 3, 6, 7, 3, 4}, 60, 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up, down, osc := calculateAroon(bars(tt.highs, tt.lows), 5)
+			if up != tt.wantUp || down != tt.wantDown || osc != tt.wantUp-tt.wantDown {
+				t.Errorf("got (%v, %v, %v), want (%v, %v, %v)", up, down, osc, tt.wantUp, tt.wantDown, tt.wantUp-tt.wantDown)
+			}
+		})
+	}
+
+	if up, down, osc := calculateAroon(bars([]float64{1, 2, 3, 4, 5}, []float64{1, 1, 1, 1, 1}), 5); up != 0 || down != 0 || osc != 0 {
+		t.Errorf("period bars only: got (%v, %v, %v), want zeros", up, down, osc)
+	}
+}
+
+func TestClosedCandlesOnlyDropsInProgressBar(t *testing.T) {
+	step := intervalDuration("3m").Milliseconds()
+	tests := []struct {
+		name       string
+		now        time.Time // 预置K线的最后一根在该时刻开盘
+		closedOnly bool
+		wantLen    int
+	}{
+		{"default keeps in-progress bar", time.Now(), false, 200},
+		{"closed only drops in-progress bar", time.Now(), true, 199},
+		// 最后一根在一小时前就已收盘，没有可去掉的K线
+		{"closed only keeps closed last bar", time.Now().Add(-time.Hour), true, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(WithSource(newFixtureSource("BTCUSDT", tt.now)))
+			opts := []GetOption{WithIntervals("3m"), WithRawKlines()}
+			if tt.closedOnly {
+				opts = append(opts, WithClosedCandlesOnly())
+			}
+			data, err := c.Get(context.Background(), "BTCUSDT", opts...)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+
+			all := fixtureKlines("3m", 200, tt.now.UnixMilli()/step*step)
+			if got := len(data.RawKlines["3m"]); got != tt.wantLen {
+				t.Errorf("len(RawKlines[3m]) = %d, want %d", got, tt.wantLen)
+			}
+			if got, want := data.Timeframes["3m"].Close, all[tt.wantLen-1].Close; got != want {
+				t.Errorf("Timeframes[3m].Close = %v, want %v", got, want)
+			}
+			// CurrentPrice始终为最新一根（可能进行中）K线的收盘价
+			if got, want := data.CurrentPrice, all[len(all)-1].Close; got != want {
+				t.Errorf("CurrentPrice = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPriceAtAlignsWithOITimestamps(t *testing.T) {
+	// 1m K线开盘于0、1、2…分钟，第i根收盘价为100+i
+	klines := make([]Kline, 10)
+	for i := range klines {
+		open := int64(i) * 60000
+		klines[i] = Kline{OpenTime: open, Close: 100 + float64(i), CloseTime: open + 59999}
+	}
+	tests := []struct {
+		name   string
+		ts     int64
+		want   float64
+		wantOK bool
+	}{
+		// 2分30秒：最近一根已收盘的是1分钟开盘的K线
+		{"between opens", 150000, 101, true},
+		// 恰好在开盘时刻：上一根刚好收盘
+		{"at an open", 300000, 104, true},
+		{"before first close", 30000, 0, false},
+		// 最后一根在9:59.999收盘，一根K线以内仍可用，更晚则不可用
+		{"just after last close", 600000 + 30000, 109, true},
+		{"long after last close", 600000 + 120000, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := priceAt(klines, tt.ts)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("priceAt(%d) = %v, %v, want %v, %v", tt.ts, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// OI点落在K线开盘时间之间：价格变化取两个时间点各自之前最近收盘的K线，而不是最后两根K线
+	history := []OIPoint{{Value: 900, Timestamp: 0}, {Value: 1000, Timestamp: 150000}, {Value: 1100, Timestamp: 450000}}
+	step, ok := oiStepDelta(history, klines)
+	if !ok || !step.priced {
+		t.Fatalf("oiStepDelta = %+v, %v, want a priced step", step, ok)
+	}
+	if step.oi != 100 || step.oiPct != 10 || step.price != 5 || !approxEqual(step.pricePct, 5.0/101*100) {
+		t.Errorf("oiStepDelta = %+v, want oi 100 (10%%), price 101→106", step)
+	}
+	// K线未覆盖OI点时只有OI变化
+	if step, ok := oiStepDelta([]OIPoint{{Value: 1000, Timestamp: 150000}, {Value: 1100, Timestamp: 3600000}}, klines); !ok || step.priced || step.price != 0 || step.oi != 100 {
+		t.Errorf("uncovered step = %+v, %v, want unpriced with oi 100", step, ok)
+	}
+	if _, ok := oiStepDelta(history[:1], klines); ok {
+		t.Error("oiStepDelta with one point: ok = true, want false")
+	}
+}
+
+func TestGetAlignsOIDeltaWithPriceWindow(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	minute := time.Minute.Milliseconds()
+	lastOpen := now.UnixMilli() / minute * minute
+	// 5m OI历史最后两个点在整分钟后30秒，落在1m K线开盘时间之间
+	from, to := lastOpen-10*minute+30000, lastOpen-5*minute+30000
+	src := newFixtureSource("BTCUSDT", now).SetOpenInterestHistory("BTCUSDT", "5m", []OIPoint{
+		{Value: 950, Timestamp: from - 5*minute},
+		{Value: 1000, Timestamp: from},
+		{Value: 1030, Timestamp: to},
+	})
+
+	data, err := NewClient(WithSource(src)).Get(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	oi := data.OpenInterest
+	// 两个时间点各自之前最近收盘的1m K线开盘于前一整分钟
+	priceFrom := fixtureKline(lastOpen-11*minute, time.Minute).Close
+	priceTo := fixtureKline(lastOpen-6*minute, time.Minute).Close
+	if oi.Delta5m != 30 || !approxEqual(oi.Delta5mPct, 3) {
+		t.Errorf("Delta5m = %v (%v%%), want 30 (3%%)", oi.Delta5m, oi.Delta5mPct)
+	}
+	if !approxEqual(oi.PriceDelta5m, priceTo-priceFrom) || !approxEqual(oi.PriceDelta5mPct, (priceTo-priceFrom)/priceFrom*100) {
+		t.Errorf("PriceDelta5m = %v (%v%%), want %v over the same window", oi.PriceDelta5m, oi.PriceDelta5mPct, priceTo-priceFrom)
+	}
+	if _, ok := oi.Regimes["5m"]; !ok {
+		t.Errorf("Regimes = %v, want a 5m regime from the aligned price change", oi.Regimes)
+	}
+}
@@ -0,0 +1,220 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDepthWindow 深度平滑的默认时间窗口
+const defaultDepthWindow = 10 * time.Second
+
+// DepthStream 通过WebSocket（<symbol>@depth10@100ms）维护的前10档盘口
+// OBI10与MicroPrice按时间加权的指数移动平均平滑，时间常数为window；
+// 连续接收满一个window后Get优先使用平滑值，否则回退到REST单次快照
+type DepthStream struct {
+	client *Client
+	window time.Duration
+	done   chan struct{}
+
+	mu    sync.RWMutex
+	books map[string]*depthState
+}
+
+// depthState 单个symbol的盘口与平滑状态
+type depthState struct {
+	book       OrderBook
+	obi        float64
+	microPrice float64
+	since      time.Time // 本次连续接收的起点，断线后重置
+	last       time.Time
+}
+
+// StartDepthStream 使用默认客户端开启盘口订阅，见Client.StartDepthStream
+func StartDepthStream(ctx context.Context, symbols []string, window time.Duration) (*DepthStream, error) {
+	return defaultClient.StartDepthStream(ctx, symbols, window)
+}
+
+// StartDepthStream 订阅symbols的前10档盘口（100ms推送），并在后台保持连接直到ctx结束
+// window为平滑的时间窗口，<=0时使用默认10秒
+func (c *Client) StartDepthStream(ctx context.Context, symbols []string, window time.Duration) (*DepthStream, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("至少需要订阅一个symbol")
+	}
+	if window <= 0 {
+		window = defaultDepthWindow
+	}
+
+	s := &DepthStream{
+		client: c,
+		window: window,
+		done:   make(chan struct{}),
+		books:  make(map[string]*depthState),
+	}
+	var streams []string
+	for _, symbol := range symbols {
+		streams = append(streams, strings.ToLower(c.normalizeSymbol(symbol))+"@depth10@100ms")
+	}
+
+	cfg := c.config()
+	conn := &streamConn{
+		url:     cfg.wsBaseURL(),
+		streams: streams,
+		logger:  cfg.logger,
+		dialer:  cfg.wsDialer(),
+		onDisconnect: func(error) {
+			s.reset()
+		},
+		onMessage: s.handle,
+	}
+
+	c.addDepthStream(s)
+	go func() {
+		defer close(s.done)
+		defer c.removeDepthStream(s)
+		conn.run(ctx)
+	}()
+	return s, nil
+}
+
+// Done 订阅结束（ctx结束且连接已关闭）后关闭
+func (s *DepthStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Window 平滑的时间窗口
+func (s *DepthStream) Window() time.Duration {
+	return s.window
+}
+
+// Book 返回symbol最新盘口的副本，尚未收到推送时返回false
+func (s *DepthStream) Book(symbol string) (*OrderBook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.books[s.client.normalizeSymbol(symbol)]
+	if !ok {
+		return nil, false
+	}
+	return &OrderBook{
+		Bids: append([][2]float64(nil), state.book.Bids...),
+		Asks: append([][2]float64(nil), state.book.Asks...),
+	}, true
+}
+
+// Smoothed 返回symbol平滑后的OBI10与MicroPrice，连续接收不足一个window时返回false
+func (s *DepthStream) Smoothed(symbol string) (obi, microPrice float64, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, exists := s.books[s.client.normalizeSymbol(symbol)]
+	if !exists || state.last.Sub(state.since) < s.window {
+		return 0, 0, false
+	}
+	return state.obi, state.microPrice, true
+}
+
+// reset 断线后丢弃全部盘口与平滑状态
+func (s *DepthStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books = make(map[string]*depthState)
+}
+
+// wsDepthEvent 有限档深度推送
+// 现货推送没有symbol字段，档位字段名为bids/asks
+type wsDepthEvent struct {
+	Symbol   string     `json:"s"`
+	Bids     [][]string `json:"b"`
+	Asks     [][]string `json:"a"`
+	SpotBids [][]string `json:"bids"`
+	SpotAsks [][]string `json:"asks"`
+}
+
+// handle 用推送整体替换盘口，并以 alpha = 1 - exp(-dt/window) 更新指数移动平均
+func (s *DepthStream) handle(stream string, data json.RawMessage) {
+	var event wsDepthEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		s.client.config().logger.Debugf("无法解析的深度推送 stream=%s err=%v", stream, err)
+		return
+	}
+	if event.Symbol == "" {
+		symbol, _, _ := strings.Cut(stream, "@")
+		event.Symbol = strings.ToUpper(symbol)
+	}
+	if len(event.Bids) == 0 && len(event.Asks) == 0 {
+		event.Bids, event.Asks = event.SpotBids, event.SpotAsks
+	}
+	book := OrderBook{Bids: parseDepthLevels(event.Bids), Asks: parseDepthLevels(event.Asks)}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return
+	}
+	obi := calculateOrderBookImbalance(&book)
+	microPrice := calculateMicroPrice(&book)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.books[event.Symbol]
+	if !ok {
+		s.books[event.Symbol] = &depthState{book: book, obi: obi, microPrice: microPrice, since: now, last: now}
+		return
+	}
+	alpha := 1 - math.Exp(-now.Sub(state.last).Seconds()/s.window.Seconds())
+	state.book = book
+	state.obi += alpha * (obi - state.obi)
+	state.microPrice += alpha * (microPrice - state.microPrice)
+	state.last = now
+}
+
+// parseDepthLevels 解析 [价格, 数量] 字符串档位
+func parseDepthLevels(levels [][]string) [][2]float64 {
+	result := make([][2]float64, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		price, _ := parseFloat(level[0])
+		qty, _ := parseFloat(level[1])
+		result = append(result, [2]float64{price, qty})
+	}
+	return result
+}
+
+// addDepthStream 登记盘口订阅，供Get查询
+func (c *Client) addDepthStream(s *DepthStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.depthStreams = append(c.depthStreams, s)
+}
+
+// removeDepthStream 注销盘口订阅
+func (c *Client) removeDepthStream(s *DepthStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.depthStreams = removeStream(c.depthStreams, s)
+}
+
+// liveBook 从盘口订阅中取平滑后的OBI10、MicroPrice及平滑窗口
+func (c *Client) liveBook(symbol string) (obi, microPrice float64, window time.Duration, ok bool) {
+	c.streamMu.RLock()
+	defer c.streamMu.RUnlock()
+	for _, s := range c.depthStreams {
+		if obi, microPrice, ok := s.Smoothed(symbol); ok {
+			return obi, microPrice, s.window, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// removeStream 从订阅列表中移除s，返回新的切片（不修改原切片，供并发读取的旧快照安全使用）
+func removeStream[T comparable](streams []T, s T) []T {
+	for i, existing := range streams {
+		if existing == s {
+			return append(streams[:i:i], streams[i+1:]...)
+		}
+	}
+	return streams
+}
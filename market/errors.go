@@ -0,0 +1,182 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited 被币安限频（可用errors.Is判断），具体等待时间见*RateLimitError
+var ErrRateLimited = errors.New("binance请求被限频")
+
+// ErrResponseTooLarge 响应体超过大小上限（见WithMaxResponseBytes），不会重试
+var ErrResponseTooLarge = errors.New("响应体超过大小上限")
+
+// ErrInsufficientData K线数量不足（新上市、交割结算中或接口返回空数组），具体周期与数量见*InsufficientDataError
+var ErrInsufficientData = errors.New("K线数据不足")
+
+// InsufficientDataError 某个周期的K线数量少于要求
+type InsufficientDataError struct {
+	Interval string
+	Got      int // 实际收到的K线数量
+	Want     int // 至少需要的数量
+}
+
+func (e *InsufficientDataError) Error() string {
+	return fmt.Sprintf("%s K线数据不足: 收到%d根，至少需要%d根", e.Interval, e.Got, e.Want)
+}
+
+// Is 使errors.Is(err, ErrInsufficientData)成立
+func (e *InsufficientDataError) Is(target error) bool {
+	return target == ErrInsufficientData
+}
+
+// 币安常见错误码
+const (
+	CodeTooManyRequests = -1003 // 请求权重超限
+	CodeInvalidSymbol   = -1121 // 无效的交易对
+)
+
+// APIError 币安接口返回的错误（{"code":-1121,"msg":"Invalid symbol."}）
+type APIError struct {
+	StatusCode int    // HTTP状态码
+	Code       int    // 币安错误码，无法解析时为0
+	Msg        string // 币安错误信息，无法解析时为响应体摘要
+}
+
+func (e *APIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("binance API错误 (HTTP %d, code %d): %s", e.StatusCode, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("binance API错误 (HTTP %d): %s", e.StatusCode, e.Msg)
+}
+
+// RateLimitError 限频错误（HTTP 429/418），携带服务端建议的等待时间
+type RateLimitError struct {
+	*APIError
+	RetryAfter time.Duration // Retry-After头给出的等待时间，未提供时为0
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s (retry after %s)", e.APIError.Error(), e.RetryAfter)
+	}
+	return e.APIError.Error()
+}
+
+// Unwrap 便于errors.As取出*APIError
+func (e *RateLimitError) Unwrap() error {
+	return e.APIError
+}
+
+// Is 使errors.Is(err, ErrRateLimited)成立
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RequestError 请求或解析失败时附带接口与查询参数，Err为底层错误（*APIError、*RateLimitError、解析错误等）
+type RequestError struct {
+	Endpoint string // 接口路径，如 /fapi/v1/klines
+	Query    string // 查询参数，如 symbol=BTCUSDT&interval=3m&limit=200
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	if e.Query == "" {
+		return fmt.Sprintf("GET %s: %v", e.Endpoint, e.Err)
+	}
+	return fmt.Sprintf("GET %s?%s: %v", e.Endpoint, e.Query, e.Err)
+}
+
+// Unwrap 便于errors.As取出*APIError等底层错误
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newRequestError 以请求地址包装err
+func newRequestError(rawURL string, err error) *RequestError {
+	reqErr := &RequestError{Endpoint: rawURL, Err: err}
+	if u, parseErr := url.Parse(rawURL); parseErr == nil {
+		reqErr.Endpoint = u.Path
+		reqErr.Query = u.RawQuery
+	}
+	return reqErr
+}
+
+// IsInvalidSymbol 判断错误是否为无效交易对（接口返回-1121，或本地校验失败的ErrInvalidSymbol/ErrUnknownSymbol）
+func IsInvalidSymbol(err error) bool {
+	if errors.Is(err, ErrInvalidSymbol) || errors.Is(err, ErrUnknownSymbol) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == CodeInvalidSymbol
+}
+
+// IsRateLimited 判断错误是否为限频（HTTP 429/418 或错误码-1003）
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests ||
+		apiErr.StatusCode == http.StatusTeapot ||
+		apiErr.Code == CodeTooManyRequests
+}
+
+// parseAPIError 检查响应状态码与响应体，非2xx或错误结构的响应体返回*APIError，否则返回nil
+func parseAPIError(statusCode int, body []byte) *APIError {
+	var envelope struct {
+		Code *int    `json:"code"`
+		Msg  *string `json:"msg"`
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	isObject := len(trimmed) > 0 && trimmed[0] == '{'
+	if isObject {
+		if err := json.Unmarshal(trimmed, &envelope); err != nil {
+			envelope.Code, envelope.Msg = nil, nil
+		}
+	}
+
+	if statusCode >= 200 && statusCode < 300 {
+		// 部分接口在200时也会返回错误结构
+		if envelope.Code != nil && envelope.Msg != nil && *envelope.Code < 0 {
+			return &APIError{StatusCode: statusCode, Code: *envelope.Code, Msg: *envelope.Msg}
+		}
+		return nil
+	}
+
+	apiErr := &APIError{StatusCode: statusCode}
+	if envelope.Code != nil && envelope.Msg != nil {
+		apiErr.Code = *envelope.Code
+		apiErr.Msg = *envelope.Msg
+		return apiErr
+	}
+
+	msg := bodySnippet(trimmed)
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+	apiErr.Msg = msg
+	return apiErr
+}
+
+// bodySnippet 响应体摘要，用于错误信息
+func bodySnippet(body []byte) string {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 200 {
+		msg = msg[:200] + "..."
+	}
+	return msg
+}
+
+// isContextError 判断错误是否由ctx取消或超时引起
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
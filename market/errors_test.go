@@ -0,0 +1,73 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetJSONErrorTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(t *testing.T, err error)
+	}{
+		{"400 invalid symbol", http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`, func(t *testing.T, err error) {
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Code != CodeInvalidSymbol {
+				t.Errorf("err = %v, want *APIError 400/-1121", err)
+			}
+			if !IsInvalidSymbol(err) || IsRateLimited(err) {
+				t.Errorf("IsInvalidSymbol/IsRateLimited = %v/%v, want true/false", IsInvalidSymbol(err), IsRateLimited(err))
+			}
+		}},
+		{"429 rate limited", http.StatusTooManyRequests, `{"code":-1003,"msg":"Too many requests."}`, func(t *testing.T, err error) {
+			var rlErr *RateLimitError
+			if !errors.As(err, &rlErr) || rlErr.StatusCode != 429 || rlErr.RetryAfter != 7*time.Second {
+				t.Errorf("err = %v, want *RateLimitError 429 with Retry-After 7s", err)
+			}
+			if !errors.Is(err, ErrRateLimited) || !IsRateLimited(err) {
+				t.Errorf("err = %v, want errors.Is ErrRateLimited", err)
+			}
+		}},
+		{"500 server error", http.StatusInternalServerError, `<html>Internal Server Error</html>`, func(t *testing.T, err error) {
+			var apiErr *APIError
+			var rlErr *RateLimitError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 || errors.As(err, &rlErr) {
+				t.Errorf("err = %v, want plain *APIError 500", err)
+			}
+		}},
+		{"malformed JSON", http.StatusOK, `{"openInterest":`, func(t *testing.T, err error) {
+			var syntaxErr *json.SyntaxError
+			var apiErr *APIError
+			if !errors.As(err, &syntaxErr) || errors.As(err, &apiErr) {
+				t.Errorf("err = %v, want a JSON decode error", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			c := newRESTClient(srv, WithRateLimitRetry(0, 0))
+
+			var v map[string]interface{}
+			err := c.getJSON(context.Background(), srv.URL+"/fapi/v1/openInterest?symbol=BTCUSDT", 1, &v)
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("err = %v (%T), want *RequestError", err, err)
+			}
+			if reqErr.Endpoint != "/fapi/v1/openInterest" || reqErr.Query != "symbol=BTCUSDT" {
+				t.Errorf("Endpoint/Query = %s/%s, want /fapi/v1/openInterest/symbol=BTCUSDT", reqErr.Endpoint, reqErr.Query)
+			}
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrUnknownSymbol 交易对不存在或当前不可交易
+var ErrUnknownSymbol = errors.New("未知的交易对")
+
+// defaultExchangeInfoTTL exchangeInfo响应较大且很少变化，默认缓存1小时
+const defaultExchangeInfoTTL = time.Hour
+
+// 交易对状态
+const symbolStatusTrading = "TRADING"
+
+// SymbolInfo 交易对元数据（来自exchangeInfo）
+type SymbolInfo struct {
+	Symbol            string  `json:"symbol"`
+	Pair              string  `json:"pair,omitempty"`
+	ContractType      string  `json:"contract_type,omitempty"` // 合约类型，如PERPETUAL；现货为空
+	Status            string  `json:"status"`
+	BaseAsset         string  `json:"base_asset"`
+	QuoteAsset        string  `json:"quote_asset"`
+	PricePrecision    int     `json:"price_precision"`
+	QuantityPrecision int     `json:"quantity_precision"`
+	TickSize          float64 `json:"tick_size"`    // 价格最小变动单位（PRICE_FILTER.tickSize）
+	StepSize          float64 `json:"step_size"`    // 数量最小变动单位（LOT_SIZE.stepSize）
+	MinNotional       float64 `json:"min_notional"` // 最小名义价值（MIN_NOTIONAL/NOTIONAL），0表示无限制
+	// Filters 按filterType分组的过滤器参数，如 Filters["PRICE_FILTER"]["tickSize"]
+	Filters map[string]map[string]string `json:"filters"`
+}
+
+// WithExchangeInfoTTL 设置exchangeInfo的缓存时间（默认1小时），不受WithoutClientCache影响
+func WithExchangeInfoTTL(ttl time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.exchangeInfoTTL = ttl
+	}
+}
+
+// WithoutSymbolValidation Get不再用exchangeInfo预先校验symbol
+func WithoutSymbolValidation() ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.skipSymbolValidation = true
+	}
+}
+
+// Symbols 使用默认客户端获取可交易的永续合约列表
+func Symbols(ctx context.Context) ([]SymbolInfo, error) {
+	return defaultClient.Symbols(ctx)
+}
+
+// Symbols 获取状态为TRADING的永续合约（现货市场为全部可交易的交易对），按symbol排序
+func (c *Client) Symbols(ctx context.Context) ([]SymbolInfo, error) {
+	all, err := c.exchangeSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	market := c.config().market
+	symbols := make([]SymbolInfo, 0, len(all))
+	for _, info := range all {
+		if info.Status != symbolStatusTrading {
+			continue
+		}
+		if market != SPOT && info.ContractType != "PERPETUAL" {
+			continue
+		}
+		symbols = append(symbols, info)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Symbol < symbols[j].Symbol
+	})
+	return symbols, nil
+}
+
+// ValidateSymbol 使用默认客户端校验symbol
+func ValidateSymbol(ctx context.Context, symbol string) error {
+	return defaultClient.ValidateSymbol(ctx, symbol)
+}
+
+// ValidateSymbol 标准化symbol后检查其是否存在且可交易，否则返回ErrUnknownSymbol
+// 只有带倍数前缀的合约时（如SHIB只有1000SHIBUSDT）视为存在；获取exchangeInfo失败时返回该错误
+func (c *Client) ValidateSymbol(ctx context.Context, symbol string) error {
+	normalized, err := getOptions{market: c.config().market}.normalize(symbol)
+	if err != nil {
+		return err
+	}
+	all, err := c.exchangeSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = checkSymbol(all, symbol, normalized)
+	return err
+}
+
+// checkSymbol 在exchangeInfo中查找normalized（含倍数前缀别名），返回交易所使用的symbol
+// input为调用方的原始输入，用于错误信息
+func checkSymbol(all map[string]SymbolInfo, input, normalized string) (string, error) {
+	resolved, ok := resolveAlias(all, normalized)
+	if !ok {
+		if input == normalized {
+			return "", fmt.Errorf("%w: %q", ErrUnknownSymbol, input)
+		}
+		return "", fmt.Errorf("%w: %q（标准化为 %s）", ErrUnknownSymbol, input, normalized)
+	}
+	if info := all[resolved]; info.Status != symbolStatusTrading {
+		return "", fmt.Errorf("%w: %s 当前状态为 %s", ErrUnknownSymbol, resolved, info.Status)
+	}
+	return resolved, nil
+}
+
+// resolveForGet Get发起请求前的symbol校验，返回交易所使用的symbol（SHIBUSDT→1000SHIBUSDT）
+// 自定义数据源、关闭校验时原样返回；exchangeInfo本身获取失败时只记录日志，不阻断Get
+func (c *Client) resolveForGet(ctx context.Context, symbol string) (string, error) {
+	cfg := c.config()
+	if cfg.source != nil || cfg.skipSymbolValidation {
+		return symbol, nil
+	}
+	all, err := c.exchangeSymbols(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		cfg.logger.Warnf("exchangeInfo获取失败，跳过symbol校验 symbol=%s err=%v", symbol, err)
+		return symbol, nil
+	}
+	return checkSymbol(all, symbol, symbol)
+}
+
+// exchangeSymbols 带缓存的exchangeInfo，并发调用合并为一次请求
+func (c *Client) exchangeSymbols(ctx context.Context) (map[string]SymbolInfo, error) {
+	cfg := c.config()
+	key := "exchangeInfo|" + string(cfg.market)
+	if v, ok := c.cache.get(key); ok {
+		return v.(map[string]SymbolInfo), nil
+	}
+	v, err, _ := c.flight.Do(key, func() (interface{}, error) {
+		symbols, err := c.getExchangeInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.set(key, symbols, cfg.exchangeInfoTTL)
+		return symbols, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]SymbolInfo), nil
+}
+
+// getExchangeInfo 获取全部交易对元数据
+// 币本位合约的状态字段为contractStatus，现货与U本位为status
+func (c *Client) getExchangeInfo(ctx context.Context) (map[string]SymbolInfo, error) {
+	weight := weightExchangeInfo
+	if c.config().market == SPOT {
+		weight = weightSpotExchangeInfo
+	}
+	var raw struct {
+		Symbols []struct {
+			Symbol            string                   `json:"symbol"`
+			Pair              string                   `json:"pair"`
+			ContractType      string                   `json:"contractType"`
+			Status            string                   `json:"status"`
+			ContractStatus    string                   `json:"contractStatus"`
+			BaseAsset         string                   `json:"baseAsset"`
+			QuoteAsset        string                   `json:"quoteAsset"`
+			PricePrecision    int                      `json:"pricePrecision"`
+			QuantityPrecision int                      `json:"quantityPrecision"`
+			Filters           []map[string]interface{} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := c.getJSON(ctx, c.apiEndpoint("/exchangeInfo"), weight, &raw); err != nil {
+		return nil, err
+	}
+
+	symbols := make(map[string]SymbolInfo, len(raw.Symbols))
+	for _, item := range raw.Symbols {
+		status := item.Status
+		if status == "" {
+			status = item.ContractStatus
+		}
+		filters := make(map[string]map[string]string, len(item.Filters))
+		for _, filter := range item.Filters {
+			filterType, _ := filter["filterType"].(string)
+			if filterType == "" {
+				continue
+			}
+			params := make(map[string]string, len(filter))
+			for k, v := range filter {
+				if k != "filterType" {
+					params[k] = fmt.Sprint(v)
+				}
+			}
+			filters[filterType] = params
+		}
+		info := SymbolInfo{
+			Symbol:            item.Symbol,
+			Pair:              item.Pair,
+			ContractType:      item.ContractType,
+			Status:            status,
+			BaseAsset:         item.BaseAsset,
+			QuoteAsset:        item.QuoteAsset,
+			PricePrecision:    item.PricePrecision,
+			QuantityPrecision: item.QuantityPrecision,
+			Filters:           filters,
+		}
+		info.TickSize, _ = parseFloat(filters["PRICE_FILTER"]["tickSize"])
+		info.StepSize, _ = parseFloat(filters["LOT_SIZE"]["stepSize"])
+		info.MinNotional = minNotional(filters)
+		symbols[item.Symbol] = info
+	}
+	return symbols, nil
+}
+
+// minNotional 最小名义价值：U本位合约为MIN_NOTIONAL.notional，现货为NOTIONAL/MIN_NOTIONAL.minNotional
+func minNotional(filters map[string]map[string]string) float64 {
+	for _, candidate := range []struct{ filter, key string }{
+		{"MIN_NOTIONAL", "notional"},
+		{"MIN_NOTIONAL", "minNotional"},
+		{"NOTIONAL", "minNotional"},
+	} {
+		if v, err := parseFloat(filters[candidate.filter][candidate.key]); err == nil && v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// RoundToTick 将价格四舍五入到最近的tickSize整数倍，TickSize未知时原样返回
+func (s *SymbolInfo) RoundToTick(price float64) float64 {
+	if s == nil || s.TickSize <= 0 {
+		return price
+	}
+	return roundToDecimals(math.Round(price/s.TickSize)*s.TickSize, stepDecimals(s.TickSize))
+}
+
+// RoundToStep 将数量向下取整到stepSize的整数倍（下单数量不能超过可用数量），StepSize未知时原样返回
+func (s *SymbolInfo) RoundToStep(qty float64) float64 {
+	if s == nil || s.StepSize <= 0 {
+		return qty
+	}
+	// 加上极小量，避免 0.3/0.1=2.9999999 这类浮点误差导致多舍掉一档
+	steps := math.Floor(qty/s.StepSize + 1e-9)
+	return roundToDecimals(steps*s.StepSize, stepDecimals(s.StepSize))
+}
+
+// stepDecimals 步长的小数位数，如 0.001 为3，10 为0
+func stepDecimals(step float64) int {
+	decimals := 0
+	for decimals < 16 && math.Abs(step-math.Round(step)) > 1e-12*math.Max(1, step) {
+		step *= 10
+		decimals++
+	}
+	return decimals
+}
+
+// roundToDecimals 消除乘法带来的浮点尾差
+func roundToDecimals(v float64, decimals int) float64 {
+	pow := math.Pow(10, float64(decimals))
+	return math.Round(v*pow) / pow
+}
+
+// symbolInfo Data.SymbolInfo的来源：exchangeInfo缓存中的交易对元数据
+// 自定义数据源或获取失败时返回nil（该字段是可选的）
+func (c *Client) symbolInfo(ctx context.Context, symbol string) *SymbolInfo {
+	cfg := c.config()
+	if cfg.source != nil {
+		return nil
+	}
+	all, err := c.exchangeSymbols(ctx)
+	if err != nil {
+		cfg.logger.Debugf("exchangeInfo获取失败，Data.SymbolInfo为空 symbol=%s err=%v", symbol, err)
+		return nil
+	}
+	info, ok := all[symbol]
+	if !ok {
+		return nil
+	}
+	return &info
+}
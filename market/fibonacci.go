@@ -0,0 +1,160 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoSwing K线中没有幅度达到阈值的波段
+var ErrNoSwing = errors.New("没有足够显著的波段")
+
+// DefaultSwingATRMultiple 显著波段的默认阈值：反转幅度至少为3倍ATR14
+const DefaultSwingATRMultiple = 3.0
+
+// fibRatios 斐波那契回撤比例
+var fibRatios = []float64{0.236, 0.382, 0.5, 0.618, 0.786}
+
+// FibLevel 一个回撤价位
+type FibLevel struct {
+	Ratio float64 `json:"ratio"`
+	Price float64 `json:"price"`
+}
+
+// FibRetracement 最近一个显著波段的斐波那契回撤
+type FibRetracement struct {
+	Interval       string     `json:"interval"`
+	Direction      int        `json:"direction"`        // +1 上涨波段（低点→高点），−1 下跌波段（高点→低点）
+	SwingStart     float64    `json:"swing_start"`      // 波段起点价格（上涨波段为最低价，下跌波段为最高价）
+	SwingEnd       float64    `json:"swing_end"`        // 波段终点价格
+	SwingStartTime int64      `json:"swing_start_time"` // 起点K线的开盘时间
+	SwingEndTime   int64      `json:"swing_end_time"`   // 终点K线的开盘时间
+	Levels         []FibLevel `json:"levels"`           // 0.236~0.786回撤价位
+	Retracement    float64    `json:"retracement"`      // 当前价格已回撤的比例，0为波段终点，1为波段起点
+	Band           string     `json:"band"`             // 当前价格所在的区间，如 "0.382-0.5"，回撤不足0.236时为 "0-0.236"
+}
+
+// CalculateFibRetracement 在klines中寻找最近一个显著波段并计算回撤价位
+// 波段用之字形（zigzag）确认：价格从极值反向运动至少atrMultiple倍ATR14时，该极值成为转折点。
+// 优先使用最近一个已完成的波段（两个转折点之间），当前价格已越过其起点（回撤超过1）时改用进行中的波段；
+// ATR无法计算或没有显著波段时返回ErrNoSwing
+func CalculateFibRetracement(klines []Kline, atrMultiple float64) (*FibRetracement, error) {
+	atr, ok := calculateATR(klines, IndicatorConfig{Period: 14})
+	if !ok || atr <= 0 || atrMultiple <= 0 {
+		return nil, fmt.Errorf("%w: ATR14不可用或阈值无效", ErrNoSwing)
+	}
+	completed, current := findSwings(klines, atrMultiple*atr)
+	if current.direction == 0 {
+		return nil, fmt.Errorf("%w: 没有幅度达到%.1f倍ATR的波动", ErrNoSwing, atrMultiple)
+	}
+	fib := newFibRetracement(klines, current)
+	if completed.direction != 0 {
+		if prior := newFibRetracement(klines, completed); prior.Retracement <= 1 {
+			fib = prior
+		}
+	}
+	return fib, nil
+}
+
+// newFibRetracement 按波段计算回撤价位与当前价格的回撤比例
+func newFibRetracement(klines []Kline, leg swingLeg) *FibRetracement {
+	fib := &FibRetracement{
+		Direction:      leg.direction,
+		SwingStartTime: klines[leg.start].OpenTime,
+		SwingEndTime:   klines[leg.end].OpenTime,
+		Levels:         make([]FibLevel, 0, len(fibRatios)),
+	}
+	if leg.direction > 0 {
+		fib.SwingStart, fib.SwingEnd = klines[leg.start].Low, klines[leg.end].High
+	} else {
+		fib.SwingStart, fib.SwingEnd = klines[leg.start].High, klines[leg.end].Low
+	}
+	span := fib.SwingEnd - fib.SwingStart // 上涨为正、下跌为负，回撤价位统一为 终点 − ratio×span
+	for _, ratio := range fibRatios {
+		fib.Levels = append(fib.Levels, FibLevel{Ratio: ratio, Price: fib.SwingEnd - ratio*span})
+	}
+	fib.Retracement = (fib.SwingEnd - klines[len(klines)-1].Close) / span
+	fib.Band = fibBand(fib.Retracement)
+	return fib
+}
+
+// fibBand 回撤比例所在的区间
+func fibBand(retracement float64) string {
+	lower := "0"
+	for _, ratio := range fibRatios {
+		if retracement < ratio {
+			return lower + "-" + formatFloat(ratio, 3)
+		}
+		lower = formatFloat(ratio, 3)
+	}
+	if retracement <= 1 {
+		return lower + "-1"
+	}
+	return ">1"
+}
+
+// swingLeg 之字形中的一段：start、end为起止K线下标，direction为0表示不存在
+type swingLeg struct {
+	start, end, direction int
+}
+
+// findSwings 之字形扫描，返回最近一个已完成的波段与进行中的波段（终点为目前的极值）
+// 进行中的波段幅度必然不小于阈值；没有达到阈值的波动时两者的direction都为0
+func findSwings(klines []Kline, threshold float64) (completed, current swingLeg) {
+	if len(klines) == 0 {
+		return completed, current
+	}
+	highIdx, lowIdx := 0, 0
+	for i := 1; i < len(klines); i++ {
+		k := klines[i]
+		switch current.direction {
+		case 0:
+			if k.High > klines[highIdx].High {
+				highIdx = i
+			}
+			if k.Low < klines[lowIdx].Low {
+				lowIdx = i
+			}
+			if klines[highIdx].High-klines[lowIdx].Low >= threshold {
+				if highIdx > lowIdx {
+					current = swingLeg{start: lowIdx, end: highIdx, direction: 1}
+				} else {
+					current = swingLeg{start: highIdx, end: lowIdx, direction: -1}
+				}
+			}
+		case 1:
+			if k.High >= klines[current.end].High {
+				current.end = i
+			} else if klines[current.end].High-k.Low >= threshold {
+				completed = current
+				current = swingLeg{start: current.end, end: i, direction: -1}
+			}
+		case -1:
+			if k.Low <= klines[current.end].Low {
+				current.end = i
+			} else if k.High-klines[current.end].Low >= threshold {
+				completed = current
+				current = swingLeg{start: current.end, end: i, direction: 1}
+			}
+		}
+	}
+	return completed, current
+}
+
+// FibRetracement 用本次获取的interval周期K线计算最近显著波段的斐波那契回撤，阈值见WithSwingThreshold
+// 未获取该周期（或Data来自不带RawKlines的快照）时返回错误，没有显著波段时返回ErrNoSwing
+func (d *Data) FibRetracement(interval string) (*FibRetracement, error) {
+	klines, ok := d.klines[interval]
+	if !ok {
+		return nil, fmt.Errorf("%s 没有%s周期的K线", d.Symbol, interval)
+	}
+	multiple := d.swingATRMultiple
+	if multiple <= 0 {
+		multiple = DefaultSwingATRMultiple
+	}
+	fib, err := CalculateFibRetracement(klines, multiple)
+	if err != nil {
+		return nil, err
+	}
+	fib.Interval = interval
+	return fib, nil
+}
@@ -0,0 +1,364 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer 启动httptest服务，测试结束时关闭
+func newTestServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// stallHandler 一直不响应，直到请求被取消或测试结束
+func stallHandler(t *testing.T) http.HandlerFunc {
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}
+}
+
+// newRESTClient 指向srv的客户端：不校验symbol、不重试，避免测试依赖exchangeInfo与退避等待
+func newRESTClient(srv *httptest.Server, opts ...ClientOption) *Client {
+	base := []ClientOption{WithBaseURL(srv.URL), WithoutSymbolValidation(), WithRetry(RetryPolicy{})}
+	return NewClient(append(base, opts...)...)
+}
+
+// fixturePrice 第i根K线（开盘时间÷周期）的收盘价：带缓慢上升趋势的正弦波
+func fixturePrice(i int64) float64 {
+	return 100 + 10*math.Sin(float64(i)/10) + float64(i%1000)*0.01
+}
+
+// fixtureKline 开盘时间为openTime、周期为step的确定性K线
+func fixtureKline(openTime int64, step time.Duration) Kline {
+	i := openTime / step.Milliseconds()
+	open, close := fixturePrice(i-1), fixturePrice(i)
+	return Kline{
+		OpenTime:  openTime,
+		Open:      open,
+		High:      math.Max(open, close) + 0.5,
+		Low:       math.Min(open, close) - 0.5,
+		Close:     close,
+		Volume:    1000 + float64(i%7)*100,
+		CloseTime: openTime + step.Milliseconds() - 1,
+	}
+}
+
+// fixtureKlines 以endOpenTime为最后一根开盘时间的n根连续K线
+func fixtureKlines(interval string, n int, endOpenTime int64) []Kline {
+	step := intervalDuration(interval)
+	klines := make([]Kline, n)
+	for i := range klines {
+		klines[i] = fixtureKline(endOpenTime-int64(n-1-i)*step.Milliseconds(), step)
+	}
+	return klines
+}
+
+// fakeBinance 模拟币安U本位REST接口，K线为按周期对齐的确定性序列，并记录收到的请求
+type fakeBinance struct {
+	mu       sync.Mutex
+	requests []string // 收到的请求（路径与查询）
+}
+
+// Requests 收到的全部请求
+func (f *fakeBinance) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// Count 路径以suffix结尾的请求数
+func (f *fakeBinance) Count(suffix string) int {
+	n := 0
+	for _, req := range f.Requests() {
+		path, _, _ := strings.Cut(req, "?")
+		if strings.HasSuffix(path, suffix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeBinance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mu.Unlock()
+
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	now := time.Now().UnixMilli()
+	path := r.URL.Path
+	var body interface{}
+	switch {
+	case strings.HasSuffix(path, "/klines"):
+		body = fixtureKlineRows(q, now)
+	case strings.HasSuffix(path, "/openInterest"):
+		body = map[string]interface{}{"symbol": symbol, "openInterest": "1000.5", "time": now}
+	case strings.HasSuffix(path, "/openInterestHist"):
+		step := intervalDuration(q.Get("period")).Milliseconds()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var rows []map[string]interface{}
+		for i := limit - 1; i >= 0; i-- {
+			ts := (now/step - int64(i)) * step
+			rows = append(rows, map[string]interface{}{
+				"symbol":               symbol,
+				"sumOpenInterest":      strconv.FormatFloat(1000+float64(i%5), 'f', -1, 64),
+				"sumOpenInterestValue": "100000",
+				"timestamp":            ts,
+			})
+		}
+		body = rows
+	case strings.HasSuffix(path, "/premiumIndex"):
+		body = map[string]interface{}{
+			"symbol": symbol, "markPrice": "100.1", "indexPrice": "100.0",
+			"lastFundingRate": "0.0001", "interestRate": "0.0001",
+			"nextFundingTime": now + time.Hour.Milliseconds(), "time": now,
+		}
+	case strings.HasSuffix(path, "/fundingRate"):
+		var rows []map[string]interface{}
+		for i := 7; i >= 0; i-- {
+			rows = append(rows, map[string]interface{}{
+				"symbol": symbol, "fundingRate": strconv.FormatFloat(0.0001*float64(i%3), 'f', -1, 64),
+				"fundingTime": now - int64(i+1)*8*time.Hour.Milliseconds(),
+			})
+		}
+		body = rows
+	case strings.HasSuffix(path, "/aggTrades"):
+		start, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+		body = []map[string]interface{}{
+			{"p": "100.0", "q": "2", "m": false, "T": start + 1},
+			{"p": "100.1", "q": "1", "m": true, "T": start + 2},
+		}
+	case strings.HasSuffix(path, "/depth"):
+		body = map[string]interface{}{
+			"bids": [][]string{{"99.9", "5"}, {"99.8", "3"}},
+			"asks": [][]string{{"100.1", "4"}, {"100.2", "2"}},
+		}
+	case strings.HasSuffix(path, "/ticker/24hr"):
+		ticker := func(symbol string) map[string]interface{} {
+			return map[string]interface{}{
+				"symbol": symbol, "priceChangePercent": "1.5", "weightedAvgPrice": "100",
+				"highPrice": "110", "lowPrice": "90", "volume": "5000", "quoteVolume": "500000",
+				"openTime": now - 24*time.Hour.Milliseconds(), "closeTime": now, "count": 1200,
+			}
+		}
+		// 不指定symbol时返回全部交易对
+		if symbol == "" {
+			body = []map[string]interface{}{ticker("BTCUSDT"), ticker("ETHUSDT")}
+		} else {
+			body = ticker(symbol)
+		}
+	case strings.HasSuffix(path, "/exchangeInfo"):
+		body = map[string]interface{}{"symbols": []map[string]interface{}{{
+			"symbol": "BTCUSDT", "pair": "BTCUSDT", "contractType": "PERPETUAL", "status": "TRADING",
+			"baseAsset": "BTC", "quoteAsset": "USDT", "pricePrecision": 2, "quantityPrecision": 3,
+			"filters": []map[string]interface{}{{"filterType": "PRICE_FILTER", "tickSize": "0.10"}},
+		}}}
+	default:
+		http.Error(w, `{"code":-1121,"msg":"Invalid path."}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// fixtureKlineRows 按limit/startTime/endTime返回fixtureKline序列，格式与币安/klines相同
+func fixtureKlineRows(q map[string][]string, now int64) [][]interface{} {
+	get := func(key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	step := intervalDuration(get("interval"))
+	limit, _ := strconv.Atoi(get("limit"))
+	if limit <= 0 {
+		limit = 500
+	}
+	startTime, _ := strconv.ParseInt(get("startTime"), 10, 64)
+	endTime, _ := strconv.ParseInt(get("endTime"), 10, 64)
+	stepMs := step.Milliseconds()
+
+	last := now / stepMs * stepMs
+	if endTime > 0 && endTime < last {
+		last = endTime / stepMs * stepMs
+	}
+	first := last - int64(limit-1)*stepMs
+	if startTime > 0 {
+		first = (startTime + stepMs - 1) / stepMs * stepMs
+		if end := first + int64(limit-1)*stepMs; end < last {
+			last = end
+		}
+	}
+	var rows [][]interface{}
+	for open := first; open <= last; open += stepMs {
+		k := fixtureKline(open, step)
+		rows = append(rows, []interface{}{
+			k.OpenTime, fmt.Sprint(k.Open), fmt.Sprint(k.High), fmt.Sprint(k.Low), fmt.Sprint(k.Close),
+			fmt.Sprint(k.Volume), k.CloseTime, "0", 10, "0", "0", "0",
+		})
+	}
+	return rows
+}
+
+// newFixtureSource 预置Get默认拉取的全部数据：各周期500根fixtureKline（最后一根包含now）、持仓量、资金费率、成交与盘口
+func newFixtureSource(symbol string, now time.Time) *FakeSource {
+	src := NewFakeSource()
+	nowMs := now.UnixMilli()
+	for _, interval := range SupportedIntervals {
+		step := intervalDuration(interval).Milliseconds()
+		if step <= 0 {
+			continue
+		}
+		src.SetKlines(symbol, interval, fixtureKlines(interval, 500, nowMs/step*step))
+	}
+
+	src.SetOpenInterest(symbol, OIPoint{Value: 1000, Timestamp: nowMs})
+	for _, period := range []string{"5m", "15m", "1h", "4h"} {
+		step := intervalDuration(period).Milliseconds()
+		points := make([]OIPoint, oiHistory4hPoints)
+		for i := range points {
+			ts := (nowMs/step - int64(len(points)-1-i)) * step
+			points[i] = OIPoint{Value: 900 + float64(i%20)*5, Timestamp: ts}
+		}
+		src.SetOpenInterestHistory(symbol, period, points)
+	}
+
+	src.SetPremiumIndex(symbol, &PremiumIndex{
+		Symbol: symbol, MarkPrice: 100.1, IndexPrice: 100, LastFundingRate: 0.0001,
+		InterestRate: 0.0001, NextFundingTime: nowMs + time.Hour.Milliseconds(), Time: nowMs,
+	})
+	funding := make([]FundingRatePoint, 30)
+	for i := range funding {
+		funding[i] = FundingRatePoint{Rate: 0.0001 * float64(i%3), Timestamp: nowMs - int64(len(funding)-i)*8*time.Hour.Milliseconds()}
+	}
+	src.SetFundingRateHistory(symbol, funding)
+
+	trades := make([]AggTrade, 0, 120)
+	for i := range 120 {
+		trades = append(trades, AggTrade{
+			Price: 100 + float64(i%5)*0.1, Quantity: 1 + float64(i%3),
+			BuyerIsMaker: i%2 == 0, Timestamp: nowMs - int64(120-i)*7*time.Second.Milliseconds(),
+		})
+	}
+	src.SetAggTrades(symbol, trades)
+	src.SetDepth(symbol, &OrderBook{
+		Bids: [][2]float64{{99.9, 5}, {99.8, 3}, {99.7, 2}},
+		Asks: [][2]float64{{100.1, 4}, {100.2, 2}, {100.3, 1}},
+	})
+	return src
+}
+
+// recordingLogger 记录Warnf输出的Logger
+type recordingLogger struct {
+	mu    sync.Mutex
+	warns []string
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {}
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, fmt.Sprintf(format, args...))
+}
+
+// Warns 已记录的警告
+func (l *recordingLogger) Warns() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.warns...)
+}
+
+// recordedHandler 按路径最后一段返回dir下录制的响应（如 /dapi/v1/klines → dir/klines.json），
+// 路径不以prefixes之一开头或没有录制文件时测试失败；每个请求的路径与查询记入requests
+func recordedHandler(t *testing.T, dir string, requests *[]string, prefixes ...string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*requests = append(*requests, r.URL.RequestURI())
+		mu.Unlock()
+		if !slices.ContainsFunc(prefixes, func(p string) bool { return strings.HasPrefix(r.URL.Path, p) }) {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join(dir, path.Base(r.URL.Path)+".json"))
+		if err != nil {
+			t.Errorf("no recorded response for %s: %v", r.URL.Path, err)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// referenceOHLCV 指标参考值测试所用的60根K线（开、高、低、收、量），参考值由按教科书公式独立实现的脚本算出
+var referenceOHLCV = [][5]float64{
+	{100, 100.5, 98.6, 100, 1000}, {100, 104.63, 99.15, 103.38, 1037}, {103.38, 104.87, 102.34, 103.33, 1074}, {103.33, 105.11, 101.94, 104.03, 1111},
+	{104.03, 108.4, 103.32, 107.68, 1148}, {107.68, 110.81, 106.57, 109.43, 1185}, {109.43, 110.87, 106.86, 108.21, 1222}, {108.21, 109.94, 107.66, 109.06, 1259},
+	{109.06, 112.62, 107.85, 111.69, 1296}, {111.69, 113.15, 109.8, 111.09, 1333}, {111.09, 112.44, 108.43, 108.83, 1370}, {108.83, 110.21, 107.54, 109.54, 1407},
+	{109.54, 111.62, 108.33, 110.5, 1444}, {110.5, 112, 107.3, 107.86, 1481}, {107.86, 109.07, 104.07, 105.42, 1018}, {105.42, 106.73, 104.32, 106.17, 1055},
+	{106.17, 107.45, 104.93, 105.65, 1092}, {105.65, 107.14, 100.79, 102.18, 1129}, {102.18, 103.21, 99.79, 100.77, 1166}, {100.77, 102.75, 99.91, 101.97, 1203},
+	{101.97, 103.37, 99.34, 100.74, 1240}, {100.74, 102.16, 97.11, 97.96, 1277}, {97.96, 99.38, 96.96, 98.55, 1314}, {98.55, 101.42, 97.16, 100.43, 1351},
+	{100.43, 101.91, 98.59, 99.29, 1388}, {99.29, 100.6, 97.22, 98.33, 1425}, {98.33, 101.67, 96.98, 101.06, 1462}, {101.06, 104.5, 100.52, 103.33, 1499},
+	{103.33, 104.83, 101.44, 102.66, 1036}, {102.66, 104.91, 101.37, 103.75, 1073}, {103.75, 108.38, 103.34, 107.77, 1110}, {107.77, 110.93, 106.47, 109.61, 1147},
+	{109.61, 111.08, 108.08, 109.28, 1184}, {109.28, 112.72, 108.71, 111.74, 1221}, {111.74, 116.41, 110.38, 115.58, 1258}, {115.58, 117.47, 114.48, 116.04, 1295},
+	{116.04, 117.44, 114.93, 115.65, 1332}, {115.65, 119.16, 114.26, 118.39, 1369}, {118.39, 121.65, 117.42, 120.61, 1406}, {120.61, 122.1, 118.34, 119.21, 1443},
+	{119.21, 120.49, 117.17, 118.57, 1480}, {118.57, 121.2, 117.73, 120.65, 1017}, {120.65, 121.86, 119.57, 120.57, 1054}, {120.57, 122.07, 116.27, 117.65, 1091},
+	{117.65, 118.77, 116.34, 117.03, 1128}, {117.03, 118.8, 115.91, 118.13, 1165}, {118.13, 119.48, 114.74, 116.08, 1202}, {116.08, 117.54, 112.29, 112.83, 1239},
+	{112.83, 113.81, 111.61, 112.88, 1276}, {112.88, 114.16, 111.6, 113.27, 1313}, {113.27, 114.72, 109.96, 110.38, 1350}, {110.38, 111.75, 106.97, 108.27, 1387},
+	{108.27, 110.33, 107.07, 109.61, 1424}, {109.61, 110.86, 109.03, 109.78, 1461}, {109.78, 111.27, 106.03, 107.39, 1498}, {107.39, 108.72, 106.3, 107.48, 1035},
+	{107.48, 110.7, 106.75, 110.19, 1072}, {110.19, 111.7, 108.8, 110.45, 1109}, {110.45, 111.94, 108.44, 109.41, 1146}, {109.41, 112.88, 108.53, 111.81, 1183},
+}
+
+// referenceKlines referenceOHLCV对应的1m K线
+func referenceKlines() []Kline {
+	step := time.Minute.Milliseconds()
+	klines := make([]Kline, len(referenceOHLCV))
+	for i, v := range referenceOHLCV {
+		klines[i] = Kline{
+			OpenTime: int64(i) * step, Open: v[0], High: v[1], Low: v[2], Close: v[3], Volume: v[4],
+			CloseTime: int64(i+1)*step - 1,
+		}
+	}
+	return klines
+}
+
+// assertSeries 检查series在各下标的值与参考值的相对误差不超过1e-9，参考值为NaN时要求series也为NaN
+func assertSeries(t *testing.T, name string, series []float64, want map[int]float64) {
+	t.Helper()
+	for i, w := range want {
+		got := series[i]
+		if math.IsNaN(w) {
+			if !math.IsNaN(got) {
+				t.Errorf("%s[%d] = %v, want NaN", name, i, got)
+			}
+			continue
+		}
+		if math.IsNaN(got) || math.Abs(got-w) > 1e-9*math.Max(1, math.Abs(w)) {
+			t.Errorf("%s[%d] = %.10f, want %.10f", name, i, got, w)
+		}
+	}
+}
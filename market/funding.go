@@ -0,0 +1,135 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 资金费率历史：百分位对比最近30天的结算；/fundingRate单次最多1000条，1小时结算的币种30天为720条，一次请求即可覆盖
+// 统计窗口默认同样为30天（见WithFundingWindow），超出1000次结算的部分不参与统计
+const (
+	fundingHistoryWindow = 30 * PeriodDay
+	fundingHistoryLimit  = 1000
+	fundingSlopePoints   = 8 // Slope只用最近8次结算，与原先的行为一致
+)
+
+// fundingInterval 由最后两次结算的间隔推断结算周期（部分币种为4小时或1小时），不足两个点时按8小时
+func fundingInterval(history []FundingRatePoint) time.Duration {
+	if n := len(history); n >= 2 && history[n-1].Timestamp > history[n-2].Timestamp {
+		return time.Duration(history[n-1].Timestamp-history[n-2].Timestamp) * time.Millisecond
+	}
+	return defaultFundingInterval
+}
+
+// annualizeFunding 按结算周期把单期费率换算为年化：8小时结算即 rate × 3 × 365
+func annualizeFunding(rate float64, interval time.Duration) float64 {
+	if interval <= 0 {
+		interval = defaultFundingInterval
+	}
+	return rate * float64(365*24*time.Hour) / float64(interval)
+}
+
+// fundingSince 按时间升序的历史中结算时间不早于sinceMs的部分
+func fundingSince(history []FundingRatePoint, sinceMs int64) []FundingRatePoint {
+	for i, point := range history {
+		if point.Timestamp >= sinceMs {
+			return history[i:]
+		}
+	}
+	return nil
+}
+
+// TimeToNext 距下次结算的时长，按绝对时间戳计算，与时区无关；没有结算时间或已过结算时间时返回0
+func (f *FundingData) TimeToNext(now time.Time) time.Duration {
+	if f == nil || f.NextTimeMs <= 0 {
+		return 0
+	}
+	return max(time.UnixMilli(f.NextTimeMs).Sub(now), 0)
+}
+
+// formatFunding 如 "0.0125% (13.7% APR, p91 of 30d)"，没有30天历史时省略百分位
+func formatFunding(f *FundingData) string {
+	s := fmt.Sprintf("%.4f%% (%.1f%% APR", f.Rate*100, f.APR*100)
+	if f.History30d > 0 {
+		s += fmt.Sprintf(", p%.0f of 30d", f.Percentile30d)
+	}
+	return s + ")"
+}
+
+// FundingStats 一段窗口内已结算资金费率的统计
+type FundingStats struct {
+	Window string  `json:"window"` // 统计窗口，整天数时为 "30d"，否则为Duration格式（如 "36h0m0s"），见WithFundingWindow
+	Count  int     `json:"count"`  // 窗口内的结算次数
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"` // 总体标准差
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// calculateFundingStats 统计结算费率，没有结算时返回nil
+func calculateFundingStats(window time.Duration, points []FundingRatePoint) *FundingStats {
+	if len(points) == 0 {
+		return nil
+	}
+	stats := &FundingStats{Window: fundingWindowLabel(window), Count: len(points), Min: points[0].Rate, Max: points[0].Rate}
+	for _, point := range points {
+		stats.Mean += point.Rate
+		stats.Min = min(stats.Min, point.Rate)
+		stats.Max = max(stats.Max, point.Rate)
+	}
+	stats.Mean /= float64(len(points))
+	variance := 0.0
+	for _, point := range points {
+		diff := point.Rate - stats.Mean
+		variance += diff * diff
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(points)))
+	return stats
+}
+
+// fundingWindowLabel 统计窗口的名称，整天数时写作 "30d"
+func fundingWindowLabel(window time.Duration) string {
+	if window > 0 && window%PeriodDay == 0 {
+		return fmt.Sprintf("%dd", window/PeriodDay)
+	}
+	return window.String()
+}
+
+// fundingStreak 从最近一次结算往前数连续同号的结算次数，正费率为正、负费率为负；最近一次为0或没有历史时为0
+func fundingStreak(history []FundingRatePoint) int {
+	if len(history) == 0 {
+		return 0
+	}
+	sign := 0
+	switch last := history[len(history)-1].Rate; {
+	case last > 0:
+		sign = 1
+	case last < 0:
+		sign = -1
+	default:
+		return 0
+	}
+	streak := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		if (history[i].Rate > 0 && sign < 0) || (history[i].Rate < 0 && sign > 0) || history[i].Rate == 0 {
+			break
+		}
+		streak++
+	}
+	return streak * sign
+}
+
+// formatFundingStats Format中的一行资金费率历史统计
+func formatFundingStats(f *FundingData) string {
+	s := f.Stats
+	streak := "none"
+	switch {
+	case f.Streak > 0:
+		streak = fmt.Sprintf("%d positive", f.Streak)
+	case f.Streak < 0:
+		streak = fmt.Sprintf("%d negative", -f.Streak)
+	}
+	return fmt.Sprintf("Funding history (%s, %d settlements): mean %.4f%% | stdev %.4f%% | min %.4f%% | max %.4f%% | streak: %s\n\n",
+		s.Window, s.Count, s.Mean*100, s.StdDev*100, s.Min*100, s.Max*100, streak)
+}
@@ -0,0 +1,207 @@
+package market
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fundingHistory 按interval结算的rates（时间升序），最近一次在半个周期之前，避免落在统计窗口的边界上
+func fundingHistory(now time.Time, interval time.Duration, rates ...float64) []FundingRatePoint {
+	points := make([]FundingRatePoint, len(rates))
+	for i, rate := range rates {
+		ts := now.Add(-time.Duration(len(rates)-1-i)*interval - interval/2)
+		points[i] = FundingRatePoint{Rate: rate, Timestamp: ts.UnixMilli()}
+	}
+	return points
+}
+
+// getFunding 用预置的当前费率与结算历史执行Get，返回资金费率分区
+func getFunding(t *testing.T, rate float64, history []FundingRatePoint, opts ...GetOption) *Data {
+	t.Helper()
+	now := time.Now()
+	src := newFixtureSource("BTCUSDT", now).
+		SetPremiumIndex("BTCUSDT", &PremiumIndex{Symbol: "BTCUSDT", MarkPrice: 100, IndexPrice: 100, LastFundingRate: rate, Time: now.UnixMilli()}).
+		SetFundingRateHistory("BTCUSDT", history)
+	data, err := NewClient(WithSource(src)).Get(context.Background(), "BTCUSDT", append([]GetOption{WithIntervals("3m")}, opts...)...)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data.Funding == nil {
+		t.Fatal("Funding = nil")
+	}
+	return data
+}
+
+func TestFundingPercentileAndAPR(t *testing.T) {
+	now := time.Now()
+	// 8小时结算共120次：最早30次在30天窗口之外且费率极高（计入时百分位会明显变化），
+	// 最近90次依次为0, 0.001%, …, 0.089%
+	rates := make([]float64, 120)
+	for i := range rates {
+		rates[i] = 0.01
+		if j := i - 30; j >= 0 {
+			rates[i] = float64(j) * 1e-5
+		}
+	}
+	data := getFunding(t, 4.55e-4, fundingHistory(now, 8*time.Hour, rates...))
+	f := data.Funding
+
+	if f.History30d != 90 {
+		t.Errorf("History30d = %d, want 90", f.History30d)
+	}
+	// 0.0455%不低于其中46次（0~0.045%）：46/90
+	if want := 46.0 / 90 * 100; !approxRate(f.Percentile30d, want) {
+		t.Errorf("Percentile30d = %v, want %v", f.Percentile30d, want)
+	}
+	if want := 4.55e-4 * 3 * 365; !approxRate(f.APR, want) {
+		t.Errorf("APR = %v, want %v (rate × 3 × 365)", f.APR, want)
+	}
+	if out := Format(data); !strings.Contains(out, "Funding: 0.0455% (49.8% APR, p51 of 30d)") {
+		t.Errorf("Format missing the funding line:\n%s", out)
+	}
+}
+
+func TestFundingPercentileEdges(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name           string
+		rate           float64
+		history        []FundingRatePoint
+		wantPercentile float64
+		wantAPR        float64
+		wantFormat     string
+	}{
+		// 高于所有历史为100，低于所有历史为0
+		{"above all", 0.001, fundingHistory(now, 8*time.Hour, 1e-4, 2e-4, 3e-4), 100, 0.001 * 3 * 365, "p100 of 30d"},
+		{"below all", -0.001, fundingHistory(now, 8*time.Hour, 1e-4, 2e-4, 3e-4), 0, -0.001 * 3 * 365, "p0 of 30d"},
+		// 1小时结算的币种按 rate × 24 × 365 年化
+		{"hourly settlement", 1e-4, fundingHistory(now, time.Hour, 1e-4, 1e-4), 100, 1e-4 * 24 * 365, "(87.6% APR, p100 of 30d)"},
+		// 没有历史时省略百分位，按8小时年化
+		{"no history", 2e-4, nil, 0, 2e-4 * 3 * 365, "0.0200% (21.9% APR)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := getFunding(t, tt.rate, tt.history)
+			if !approxRate(data.Funding.Percentile30d, tt.wantPercentile) || !approxRate(data.Funding.APR, tt.wantAPR) {
+				t.Errorf("Percentile30d, APR = %v, %v, want %v, %v", data.Funding.Percentile30d, data.Funding.APR, tt.wantPercentile, tt.wantAPR)
+			}
+			if got := formatFunding(data.Funding); !strings.Contains(got, tt.wantFormat) {
+				t.Errorf("formatFunding = %q, want it to contain %q", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestFundingStatsAndStreak(t *testing.T) {
+	now := time.Now()
+	// 2天窗口内为最近6次结算（-0.01%, 0.02%, 0.03%, 0.01%, 0.04%, 0.02%），更早的4次为0.5%，只影响连续次数以外的统计
+	history := fundingHistory(now, 8*time.Hour, 0.005, 0.005, 0.005, 0.005, -1e-4, 2e-4, 3e-4, 1e-4, 4e-4, 2e-4)
+	data := getFunding(t, 2e-4, history, WithFundingWindow(2*PeriodDay))
+	f := data.Funding
+
+	if f.Stats == nil {
+		t.Fatal("Stats = nil")
+	}
+	s := *f.Stats
+	if s.Window != "2d" || s.Count != 6 {
+		t.Errorf("Window, Count = %q, %d, want 2d, 6", s.Window, s.Count)
+	}
+	// 均值11/6，离差平方和89/6，总体标准差√89/6（单位0.01%）
+	if !approxRate(s.Mean, 11.0/6*1e-4) || !approxRate(s.StdDev, math.Sqrt(89)/6*1e-4) || s.Min != -1e-4 || s.Max != 4e-4 {
+		t.Errorf("Stats = %+v, want mean %v stdev %v min -1e-4 max 4e-4", s, 11.0/6*1e-4, math.Sqrt(89)/6*1e-4)
+	}
+	// 连续次数按全部历史计算：最近5次为正，第6次为负
+	if f.Streak != 5 {
+		t.Errorf("Streak = %d, want 5", f.Streak)
+	}
+	if out := Format(data); !strings.Contains(out, "Funding history (2d, 6 settlements):") || !strings.Contains(out, "streak: 5 positive") {
+		t.Errorf("Format missing the funding history line:\n%s", out)
+	}
+
+	// 默认30天窗口覆盖全部10次
+	if s := getFunding(t, 2e-4, history).Funding.Stats; s == nil || s.Window != "30d" || s.Count != 10 || s.Max != 0.005 {
+		t.Errorf("default window Stats = %+v, want 30d over 10 settlements with max 0.005", s)
+	}
+	// 非整天数的窗口
+	if s := getFunding(t, 2e-4, history, WithFundingWindow(40*time.Hour)).Funding.Stats; s == nil || s.Window != "40h0m0s" || s.Count != 5 {
+		t.Errorf("40h window Stats = %+v, want 40h0m0s over 5 settlements", s)
+	}
+	// 窗口内没有结算时为nil
+	if s := getFunding(t, 2e-4, nil).Funding.Stats; s != nil {
+		t.Errorf("no history Stats = %+v, want nil", s)
+	}
+}
+
+func TestFundingStreak(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		rates []float64
+		want  int
+	}{
+		{"no history", nil, 0},
+		{"all positive", []float64{1e-4, 2e-4, 1e-4, 3e-4}, 4},
+		{"negative run after flip", []float64{1e-4, 2e-4, -1e-4, -2e-4, -5e-5}, -3},
+		{"latest zero", []float64{1e-4, 2e-4, 0}, 0},
+		{"zero breaks run", []float64{-1e-4, 0, 1e-4, 1e-4}, 2},
+		{"single negative", []float64{-1e-4}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fundingStreak(fundingHistory(now, 8*time.Hour, tt.rates...)); got != tt.want {
+				t.Errorf("fundingStreak = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFundingSlopeRegression(t *testing.T) {
+	now := time.Now()
+	// 最近8次结算首尾都是0.0035%，中间从0.001%逐次升到0.006%；更早的两次极端值不在回归范围内
+	rates := []float64{0.01, -0.01, 3.5e-5, 1e-5, 2e-5, 3e-5, 4e-5, 5e-5, 6e-5, 3.5e-5}
+	history := fundingHistory(now, 8*time.Hour, rates...)
+	f := getFunding(t, 3.5e-5, history).Funding
+
+	// 原先的首尾差值法得到0，看不出中间的上升趋势
+	recent := history[len(history)-fundingSlopePoints:]
+	hours := float64(recent[len(recent)-1].Timestamp-recent[0].Timestamp) / float64(time.Hour.Milliseconds())
+	if endpoint := (recent[len(recent)-1].Rate - recent[0].Rate) / hours; endpoint != 0 {
+		t.Fatalf("endpoint slope = %v, want 0 for this fixture", endpoint)
+	}
+	// 对全部8个点回归（x为小时）：斜率 5/96 × 0.001% 每小时，R² = 5/12
+	if want := 5.0 / 96 * 1e-5; !approxRate(f.Slope, want) {
+		t.Errorf("Slope = %v, want %v per hour", f.Slope, want)
+	}
+	if !approxRate(f.SlopeR2, 5.0/12) {
+		t.Errorf("SlopeR2 = %v, want %v", f.SlopeR2, 5.0/12)
+	}
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		rates     []float64
+		wantSlope float64
+		wantR2    float64
+	}{
+		// 4小时结算、每次上升0.001%：斜率按小时计为0.00025%
+		{"perfect line", 4 * time.Hour, []float64{1e-5, 2e-5, 3e-5, 4e-5, 5e-5}, 2.5e-6, 1},
+		{"flat", 8 * time.Hour, []float64{1e-4, 1e-4, 1e-4, 1e-4}, 0, 0},
+		{"single settlement", 8 * time.Hour, []float64{1e-4}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := getFunding(t, 1e-4, fundingHistory(now, tt.interval, tt.rates...)).Funding
+			if !approxRate(f.Slope, tt.wantSlope) || !approxRate(f.SlopeR2, tt.wantR2) {
+				t.Errorf("Slope, SlopeR2 = %v, %v, want %v, %v", f.Slope, f.SlopeR2, tt.wantSlope, tt.wantR2)
+			}
+		})
+	}
+}
+
+// approxRate 按相对误差比较，资金费率及其斜率的量级远小于approxEqual的绝对容差
+func approxRate(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Abs(want)+1e-15
+}
@@ -0,0 +1,307 @@
+package market
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL 币安U本位合约REST API默认地址
+const DefaultBaseURL = "https://fapi.binance.com"
+
+// DefaultMaxResponseBytes 默认的响应体大小上限（解压后），现货exchangeInfo有数MB，留出余量
+const DefaultMaxResponseBytes = 16 << 20
+
+// WithMaxResponseBytes 设置单个响应体（解压后）的大小上限，超过时返回ErrResponseTooLarge；<=0表示不限制
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.maxResponseBytes = n
+	}
+}
+
+// futuresDataPrefix 合约数据统计接口的路径前缀，U本位与币本位相同
+const futuresDataPrefix = "/futures/data"
+
+// apiEndpoint 拼接常规行情接口的完整地址（U本位 /fapi/v1，币本位 /dapi/v1）
+func (c *Client) apiEndpoint(path string) string {
+	cfg := c.config()
+	return cfg.restBaseURL() + cfg.market.spec().apiPrefix + path
+}
+
+// dataEndpoint 拼接合约数据统计接口的完整地址
+func (c *Client) dataEndpoint(path string) string {
+	return c.config().restBaseURL() + futuresDataPrefix + path
+}
+
+// httpGet 发起带ctx的GET请求并读取响应体，weight为该请求的币安权重，发送前先向限流器预占
+// 非2xx或错误结构的响应返回*APIError
+// 遇到HTTP 429时按Retry-After有限次等待重试，仍失败则返回*RateLimitError；
+// 连接错误、超时与5xx按RetryPolicy指数退避重试。所有等待都受ctx约束
+func (c *Client) httpGet(ctx context.Context, url string, weight int) (body []byte, err error) {
+	cfg := c.config()
+
+	if cfg.tracer != nil {
+		endpoint := metricsEndpoint(cfg.restBaseURL(), url)
+		var span Span
+		ctx, span = cfg.startSpan(ctx, endpoint, requestAttributes(endpoint, url)...)
+		defer func() {
+			if err != nil {
+				span.SetError(err)
+			}
+			span.End()
+		}()
+	}
+
+	rateLimitAttempts := 0
+	transientAttempts := 1
+	for {
+		if err := c.limiter.acquire(ctx, weight, cfg.weightLimit, cfg.limitMode); err != nil {
+			return nil, err
+		}
+
+		body, err := c.doGet(ctx, cfg, url)
+		if err == nil {
+			return body, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		var wait time.Duration
+		var rlErr *RateLimitError
+		switch {
+		case errors.As(err, &rlErr):
+			if rlErr.StatusCode == http.StatusTeapot {
+				cfg.logger.Errorf("IP已被封禁 endpoint=%s retry_after=%s err=%v", metricsEndpoint(cfg.restBaseURL(), url), rlErr.RetryAfter, err)
+				return nil, err
+			}
+			if rateLimitAttempts >= cfg.rateLimitRetries {
+				cfg.logger.Warnf("触发限频且重试次数已用尽 endpoint=%s attempts=%d err=%v", metricsEndpoint(cfg.restBaseURL(), url), rateLimitAttempts, err)
+				return nil, err
+			}
+			wait = rlErr.RetryAfter
+			if wait <= 0 {
+				wait = time.Second << uint(rateLimitAttempts)
+			}
+			if wait > cfg.rateLimitMaxWait {
+				cfg.logger.Warnf("触发限频且等待时间超过上限 endpoint=%s retry_after=%s max_wait=%s", metricsEndpoint(cfg.restBaseURL(), url), wait, cfg.rateLimitMaxWait)
+				return nil, err
+			}
+			rateLimitAttempts++
+			cfg.logger.Warnf("触发限频，等待后重试 endpoint=%s wait=%s attempt=%d", metricsEndpoint(cfg.restBaseURL(), url), wait, rateLimitAttempts)
+		case isTransient(err):
+			if transientAttempts >= cfg.retry.MaxAttempts {
+				return nil, err
+			}
+			wait = cfg.retry.backoff(transientAttempts)
+			cfg.logger.Warnf("请求失败，等待后重试 endpoint=%s wait=%s attempt=%d err=%v", metricsEndpoint(cfg.restBaseURL(), url), wait, transientAttempts, err)
+			transientAttempts++
+		default:
+			return nil, err
+		}
+
+		// 剩余时间不足以等待时直接返回本次错误，比返回DeadlineExceeded更有信息量
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// getJSON 发起GET请求并将响应体解析到v，失败时返回带接口与查询参数的*RequestError
+// 调用方ctx取消或超时时原样返回ctx的错误
+func (c *Client) getJSON(ctx context.Context, url string, weight int, v interface{}) error {
+	body, err := c.httpGet(ctx, url, weight)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && isContextError(err) {
+			return err
+		}
+		return newRequestError(url, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return newRequestError(url, fmt.Errorf("解析响应失败: %w（响应: %s）", err, bodySnippet(body)))
+	}
+	return nil
+}
+
+// isTransient 判断错误是否值得重试：网络层错误（连接重置、超时等）与5xx
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var netErr *transportError
+	return errors.As(err, &netErr)
+}
+
+// transportError 网络层错误（请求未得到完整响应）
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// backoff 第attempt次重试前的等待时间（attempt从1开始）
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		delta := (rand.Float64()*2 - 1) * p.Jitter * float64(delay)
+		delay += time.Duration(delta)
+	}
+	return delay
+}
+
+// doGet 发起单次GET请求，配置了Metrics时上报状态码与耗时，耗时超过阈值时记录慢请求日志
+// 单次请求（含读取响应体）受Timeouts.Request约束，超时按网络层错误处理，可被重试
+func (c *Client) doGet(ctx context.Context, cfg clientConfig, url string) ([]byte, error) {
+	reqCtx := ctx
+	if cfg.timeouts.Request > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, cfg.timeouts.Request)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 显式请求gzip并自行解压，使大小上限作用于解压后的数据，且不依赖自定义Transport的行为
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	observe := cfg.metrics != nil || cfg.slowRequest > 0
+	var start time.Time
+	if observe {
+		start = time.Now()
+	}
+	resp, err := c.send(ctx, cfg, req)
+	if observe {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		defer func() {
+			dur := time.Since(start)
+			endpoint := metricsEndpoint(cfg.restBaseURL(), url)
+			if cfg.metrics != nil {
+				cfg.metrics.ObserveRequest(endpoint, status, dur)
+			}
+			if cfg.slowRequest > 0 && dur > cfg.slowRequest {
+				cfg.logger.Warnf("慢请求 endpoint=%s status=%d duration=%s", endpoint, status, dur)
+			}
+		}()
+	}
+	return c.readResponse(ctx, cfg, resp, err)
+}
+
+// send 发送请求，网络层错误（含单次请求超时）包装为*transportError
+// ctx为调用方的ctx，其取消或超时时原样返回ctx的错误
+func (c *Client) send(ctx context.Context, cfg clientConfig, req *http.Request) (*http.Response, error) {
+	resp, err := cfg.effectiveHTTPClient().Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &transportError{err: requestTimeoutError(cfg, req, err)}
+	}
+	return resp, nil
+}
+
+// requestTimeoutError 单次请求超时时在错误中注明超时设置
+// 不保留context.DeadlineExceeded的错误链，避免被误判为调用方ctx超时
+func requestTimeoutError(cfg clientConfig, req *http.Request, err error) error {
+	if req != nil && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("请求超时（%s）: %v", cfg.timeouts.Request, err)
+	}
+	return err
+}
+
+// readResponse 读取响应体并解析错误结构
+func (c *Client) readResponse(ctx context.Context, cfg clientConfig, resp *http.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	c.limiter.observe(resp.Header)
+
+	body, err := readBody(resp, cfg.maxResponseBytes)
+	if err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &transportError{err: requestTimeoutError(cfg, resp.Request, err)}
+	}
+
+	if apiErr := parseAPIError(resp.StatusCode, body); apiErr != nil {
+		if IsRateLimited(apiErr) {
+			c.limiter.saturate(cfg.weightLimit)
+			return nil, &RateLimitError{
+				APIError:   apiErr,
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+		return nil, apiErr
+	}
+	return body, nil
+}
+
+// readBody 读取响应体，gzip压缩的响应边读边解压；解压后超过limit字节时返回ErrResponseTooLarge，limit<=0表示不限制
+func readBody(resp *http.Response, limit int64) ([]byte, error) {
+	var r io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("解压响应失败: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w（上限%d字节）", ErrResponseTooLarge, limit)
+	}
+	return body, nil
+}
+
+// parseRetryAfter 解析Retry-After头（秒数或HTTP日期），无法解析时返回0
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
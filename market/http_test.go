@@ -0,0 +1,210 @@
+package market
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetReturnsWhenContextDeadlineExpires(t *testing.T) {
+	srv := newTestServer(t, stallHandler(t))
+	timeouts := DefaultTimeouts
+	timeouts.Request = 2 * time.Second
+	c := newRESTClient(srv, WithTimeouts(timeouts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Get(ctx, "BTCUSDT")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed >= timeouts.Request {
+		t.Fatalf("Get returned after %s, want well within Timeouts.Request (%s)", elapsed, timeouts.Request)
+	}
+}
+
+// flakyHandler 前failures次返回status，之后返回{}，attempts记录收到的请求数
+func flakyHandler(failures int, status int, attempts *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(attempts.Add(1)) <= failures {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"code":-1000,"msg":"flaky"}`, status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}
+}
+
+func TestHTTPGetRetriesTransientErrors(t *testing.T) {
+	const maxAttempts = 4
+	policy := RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{"succeeds on last attempt", maxAttempts - 1, maxAttempts, false},
+		{"gives up after MaxAttempts", maxAttempts, maxAttempts, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := newTestServer(t, flakyHandler(tt.failures, http.StatusServiceUnavailable, &attempts))
+			c := newRESTClient(srv, WithRetry(policy))
+
+			var v struct{}
+			err := c.getJSON(context.Background(), srv.URL+"/fapi/v1/ping", 1, &v)
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantErr && (!errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable) {
+				t.Errorf("err = %v, want *APIError with HTTP 503", err)
+			}
+		})
+	}
+}
+
+func TestHTTPGetNeverRetriesTeapot(t *testing.T) {
+	var attempts atomic.Int32
+	srv := newTestServer(t, flakyHandler(1, http.StatusTeapot, &attempts))
+	c := newRESTClient(srv,
+		WithRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}),
+		WithRateLimitRetry(5, time.Minute))
+
+	var v struct{}
+	err := c.getJSON(context.Background(), srv.URL+"/fapi/v1/ping", 1, &v)
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.StatusCode != http.StatusTeapot {
+		t.Fatalf("err = %v, want *RateLimitError with HTTP 418", err)
+	}
+}
+
+func TestStalledRequestIsBounded(t *testing.T) {
+	t.Run("Timeouts.Request", func(t *testing.T) {
+		var attempts atomic.Int32
+		stall := stallHandler(t)
+		srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			stall(w, r)
+		}))
+		timeouts := DefaultTimeouts
+		timeouts.Request = 100 * time.Millisecond
+		c := newRESTClient(srv, WithTimeouts(timeouts), WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+
+		start := time.Now()
+		var v struct{}
+		err := c.getJSON(context.Background(), srv.URL+"/fapi/v1/ping", 1, &v)
+		elapsed := time.Since(start)
+
+		// 单次请求超时按网络层错误处理并重试，不会表现为调用方ctx的DeadlineExceeded
+		var netErr *transportError
+		if !errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want a request timeout transport error", err)
+		}
+		if got := attempts.Load(); got != 2 {
+			t.Errorf("attempts = %d, want 2", got)
+		}
+		if elapsed > time.Second {
+			t.Errorf("call took %s, want about 2 × Timeouts.Request", elapsed)
+		}
+	})
+
+	t.Run("context cancel", func(t *testing.T) {
+		srv := newTestServer(t, stallHandler(t))
+		timeouts := DefaultTimeouts
+		timeouts.Request = time.Minute
+		c := newRESTClient(srv, WithTimeouts(timeouts))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := c.Get(ctx, "BTCUSDT")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Get returned %s after cancel, want promptly", elapsed)
+		}
+	})
+}
+
+// gzipHandler 客户端声明接受gzip时压缩body，wire记录实际发送的字节数
+func gzipHandler(body []byte, wire *atomic.Int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			wire.Add(int64(len(body)))
+			w.Write(body)
+			return
+		}
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(body)
+		gz.Close()
+		wire.Add(int64(buf.Len()))
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}
+}
+
+func TestGzipResponsesSaveBytes(t *testing.T) {
+	q := url.Values{"interval": {"1m"}, "limit": {strconv.Itoa(maxKlinesPerRequest)}}
+	raw, err := json.Marshal(fixtureKlineRows(q, time.Now().UnixMilli()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wire atomic.Int64
+	srv := newTestServer(t, gzipHandler(raw, &wire))
+	c := newRESTClient(srv)
+
+	klines, err := c.getKlinesPage(context.Background(), "BTCUSDT", "1m", maxKlinesPerRequest, 0, 0)
+	if err != nil {
+		t.Fatalf("getKlinesPage: %v", err)
+	}
+	if len(klines) != maxKlinesPerRequest {
+		t.Fatalf("len = %d, want %d", len(klines), maxKlinesPerRequest)
+	}
+	saved := 1 - float64(wire.Load())/float64(len(raw))
+	t.Logf("1500×1m klines: %d bytes raw, %d bytes gzip (%.0f%% saved)", len(raw), wire.Load(), saved*100)
+	if saved < 0.5 {
+		t.Errorf("gzip saved %.0f%%, want at least 50%% on a kline payload", saved*100)
+	}
+}
+
+func TestGzipResponseSizeLimitAppliesAfterDecompression(t *testing.T) {
+	// 压缩后很小、解压后超过上限的响应
+	body := []byte(`[` + strings.Repeat(`0,`, 1<<20) + `0]`)
+	var wire atomic.Int64
+	srv := newTestServer(t, gzipHandler(body, &wire))
+	c := newRESTClient(srv, WithMaxResponseBytes(64<<10), WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	var v []int
+	err := c.getJSON(context.Background(), srv.URL+"/fapi/v1/ping", 1, &v)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+	}
+	if wire.Load() >= 64<<10 {
+		t.Fatalf("compressed body is %d bytes, want it under the limit for this test", wire.Load())
+	}
+}
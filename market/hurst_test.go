@@ -0,0 +1,71 @@
+package market
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// hurstSeries 用固定种子生成n根K线的收盘价：phi=0时为对数收益率独立同分布的随机游走，
+// phi>0时收益率为AR(1)过程，涨跌会延续，形成持续的趋势
+func hurstSeries(seed uint64, n int, phi float64) []Kline {
+	rng := rand.New(rand.NewPCG(seed, 0))
+	klines := make([]Kline, n)
+	price, ret := 100.0, 0.0
+	for i := range klines {
+		ret = phi*ret + 0.01*rng.NormFloat64()
+		price *= math.Exp(ret)
+		klines[i] = Kline{Open: price, High: price, Low: price, Close: price}
+	}
+	return klines
+}
+
+func TestHurstExponent(t *testing.T) {
+	const seeds = 20
+
+	// 单条序列的估计方差较大，取多条随机游走的均值；短样本下R/S法偏高（约0.56~0.59）
+	sum := 0.0
+	for seed := range uint64(seeds) {
+		h, err := HurstExponent(hurstSeries(seed, 500, 0))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		sum += h.Exponent
+	}
+	if mean := sum / seeds; mean < 0.5 || mean > 0.62 {
+		t.Errorf("random walk mean Hurst = %.3f, want ≈0.5 (0.5~0.62 allowing the short-sample bias)", mean)
+	}
+
+	for seed := range uint64(seeds) {
+		h, err := HurstExponent(hurstSeries(seed, 500, 0.9))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if h.Exponent <= 0.6 {
+			t.Errorf("trending series seed %d: Hurst = %.3f, want > 0.6", seed, h.Exponent)
+		}
+	}
+}
+
+func TestHurstExponentBarCounts(t *testing.T) {
+	if _, err := HurstExponent(hurstSeries(1, hurstMinBars-1, 0)); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("%d bars: err = %v, want ErrInsufficientData", hurstMinBars-1, err)
+	}
+	// 默认的120根4h K线可以计算，但标记为低置信度
+	h, err := HurstExponent(hurstSeries(1, 120, 0))
+	if err != nil || !h.LowConfidence || h.Bars != 120 {
+		t.Errorf("120 bars: got %+v, %v, want a low-confidence estimate over 120 bars", h, err)
+	}
+	if h, err := HurstExponent(hurstSeries(1, hurstConfidentBars, 0)); err != nil || h.LowConfidence {
+		t.Errorf("%d bars: got %+v, %v, want a confident estimate", hurstConfidentBars, h, err)
+	}
+	// 价格不变时所有分段的标准差为0，没有可用的R/S
+	flat := make([]Kline, hurstMinBars)
+	for i := range flat {
+		flat[i] = Kline{Close: 100}
+	}
+	if _, err := HurstExponent(flat); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("flat series: err = %v, want ErrInsufficientData", err)
+	}
+}
@@ -0,0 +1,172 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultFundingInterval 资金费率默认结算间隔，历史不足两个点时使用
+const defaultFundingInterval = 8 * time.Hour
+
+// WithIncrementalRefresh 开启增量刷新：客户端记住每个symbol/周期已拉取的序列，
+// 最后一根K线收盘之前直接返回已保存的序列（进行中K线的价格与成交量保持上次拉取时的值，
+// CurrentPrice最多滞后一根基准周期K线），收盘后只请求从它开始的新K线，追加到已有序列并裁剪到所需数量；
+// OI历史与资金费率历史在下一个点产生之前同样不再请求；WithoutCache时总是重新请求
+func WithIncrementalRefresh() ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.incremental = true
+	}
+}
+
+// seriesStore 增量刷新保存的序列，切片写入后不再修改，可以安全地返回给调用方
+type seriesStore struct {
+	mu      sync.Mutex
+	klines  map[string][]Kline
+	oi      map[string][]OIPoint
+	funding map[string][]FundingRatePoint
+}
+
+func newSeriesStore() *seriesStore {
+	return &seriesStore{
+		klines:  make(map[string][]Kline),
+		oi:      make(map[string][]OIPoint),
+		funding: make(map[string][]FundingRatePoint),
+	}
+}
+
+// incrementalKlines 增量获取K线：最后一根已保存K线收盘前直接返回已保存的序列，
+// 否则只请求从它开始的部分，缺口超过limit时整体重新拉取
+func (c *Client) incrementalKlines(ctx context.Context, symbol, interval string, limit int, force bool) ([]Kline, error) {
+	key := fmt.Sprintf("%s|%s|%d", symbol, interval, limit)
+	step := intervalDuration(interval).Milliseconds()
+
+	c.series.mu.Lock()
+	stored := c.series.klines[key]
+	c.series.mu.Unlock()
+
+	fetchLimit := limit
+	if len(stored) > 0 && step > 0 {
+		last := stored[len(stored)-1]
+		now := time.Now().UnixMilli()
+		if now <= last.CloseTime && !force {
+			return stored, nil
+		}
+		// 上次保存的最后一根是进行中的K线，从它开始重新拉取以得到收盘后的值
+		elapsed := now - last.OpenTime
+		if missing := int(elapsed/step) + 1; missing < limit {
+			fetchLimit = missing
+		}
+	}
+
+	fresh, err := c.source().Klines(ctx, symbol, interval, fetchLimit)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeSeries(stored, fresh, limit, func(k Kline) int64 { return k.OpenTime })
+
+	c.series.mu.Lock()
+	c.series.klines[key] = merged
+	c.series.mu.Unlock()
+	return merged, nil
+}
+
+// incrementalOpenInterestHistory 增量获取OI历史，下一个period的点产生前直接返回已保存的序列
+func (c *Client) incrementalOpenInterestHistory(ctx context.Context, symbol, period string, limit int, force bool) ([]OIPoint, error) {
+	key := fmt.Sprintf("%s|%s|%d", symbol, period, limit)
+	step := intervalDuration(period).Milliseconds()
+
+	c.series.mu.Lock()
+	stored := c.series.oi[key]
+	c.series.mu.Unlock()
+
+	fetchLimit := limit
+	if len(stored) > 0 && step > 0 {
+		elapsed := time.Now().UnixMilli() - stored[len(stored)-1].Timestamp
+		if elapsed < step && !force {
+			return stored, nil
+		}
+		if missing := int(elapsed/step) + 1; missing < limit {
+			fetchLimit = missing
+		}
+	}
+
+	fresh, err := c.source().OpenInterestHistory(ctx, symbol, period, fetchLimit)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeSeries(stored, fresh, limit, func(p OIPoint) int64 { return p.Timestamp })
+
+	c.series.mu.Lock()
+	c.series.oi[key] = merged
+	c.series.mu.Unlock()
+	return merged, nil
+}
+
+// incrementalFundingRateHistory 增量获取资金费率历史，下一次结算前直接返回已保存的序列
+// 结算间隔取最后两个点的间隔（部分币种为4小时或1小时），不足两个点时按8小时
+func (c *Client) incrementalFundingRateHistory(ctx context.Context, symbol string, limit int, force bool) ([]FundingRatePoint, error) {
+	key := fmt.Sprintf("%s|%d", symbol, limit)
+
+	c.series.mu.Lock()
+	stored := c.series.funding[key]
+	c.series.mu.Unlock()
+
+	fetchLimit := limit
+	if n := len(stored); n > 0 {
+		step := defaultFundingInterval.Milliseconds()
+		if n >= 2 && stored[n-1].Timestamp > stored[n-2].Timestamp {
+			step = stored[n-1].Timestamp - stored[n-2].Timestamp
+		}
+		elapsed := time.Now().UnixMilli() - stored[n-1].Timestamp
+		if elapsed < step && !force {
+			return stored, nil
+		}
+		if missing := int(elapsed/step) + 1; missing < limit {
+			fetchLimit = missing
+		}
+	}
+
+	fresh, err := c.source().FundingRateHistory(ctx, symbol, fetchLimit)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeSeries(stored, fresh, limit, func(p FundingRatePoint) int64 { return p.Timestamp })
+
+	c.series.mu.Lock()
+	c.series.funding[key] = merged
+	c.series.mu.Unlock()
+	return merged, nil
+}
+
+// mergeSeries 用fresh覆盖stored中时间不早于fresh首个点的部分，并裁剪为最近limit个点
+// fresh与stored之间存在缺口（fresh首个点晚于stored末尾之后的一个点）时，说明漏拉了数据，只保留fresh
+// 返回新切片，不修改stored
+func mergeSeries[T any](stored, fresh []T, limit int, ts func(T) int64) []T {
+	if len(fresh) == 0 {
+		return stored
+	}
+	first := ts(fresh[0])
+	cut := len(stored)
+	for i, item := range stored {
+		if ts(item) >= first {
+			cut = i
+			break
+		}
+	}
+	if cut == len(stored) && cut > 0 && len(stored) >= 2 {
+		last, prev := ts(stored[cut-1]), ts(stored[cut-2])
+		if first-last > last-prev {
+			stored, cut = nil, 0
+		}
+	}
+
+	merged := make([]T, 0, cut+len(fresh))
+	merged = append(merged, stored[:cut]...)
+	merged = append(merged, fresh...)
+	if limit > 0 && len(merged) > limit {
+		merged = merged[len(merged)-limit:]
+	}
+	return merged
+}
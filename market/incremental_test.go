@@ -0,0 +1,147 @@
+package market
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// incrementalSource 最后一根K线与最后一个OI点都落在当前时刻，测试期间不会收盘或产生新点
+func incrementalSource(now time.Time) *FakeSource {
+	src := newFixtureSource("BTCUSDT", now)
+	nowMs := now.UnixMilli()
+	for _, interval := range SupportedIntervals {
+		if intervalDuration(interval) > 0 {
+			src.SetKlines("BTCUSDT", interval, fixtureKlines(interval, 500, nowMs))
+		}
+	}
+	for _, period := range []string{"5m", "15m", "1h", "4h"} {
+		step := intervalDuration(period).Milliseconds()
+		points := make([]OIPoint, oiHistory4hPoints)
+		for i := range points {
+			points[i] = OIPoint{Value: 900 + float64(i%20)*5, Timestamp: nowMs - int64(len(points)-1-i)*step}
+		}
+		src.SetOpenInterestHistory("BTCUSDT", period, points)
+	}
+	rates := make([]float64, 30)
+	for i := range rates {
+		rates[i] = 0.0001 * float64(i%3)
+	}
+	return src.SetFundingRateHistory("BTCUSDT", fundingHistory(now, 8*time.Hour, rates...))
+}
+
+// callCounts 复制FakeSource各方法的调用次数
+func callCounts(src *FakeSource) map[string]int {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	return maps.Clone(src.calls)
+}
+
+func TestIncrementalRefreshRequestCount(t *testing.T) {
+	src := incrementalSource(time.Now())
+	// 关闭TTL缓存，每次Get都经过增量刷新的判断
+	c := NewClient(WithSource(src), WithIncrementalRefresh(), WithoutClientCache())
+	ctx := context.Background()
+	// 成交与盘口每次都是实时请求，不属于增量刷新的范围
+	opts := []GetOption{WithIntervals("3m", "15m", "1h", "4h"), WithoutMicrostructure()}
+
+	first, err := c.Get(ctx, "BTCUSDT", opts...)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	initial := callCounts(src)
+	if initial["Klines"] == 0 || initial["OpenInterestHistory"] == 0 || initial["FundingRateHistory"] == 0 {
+		t.Fatalf("initial calls = %v, want klines, OI history and funding history fetched", initial)
+	}
+
+	for i := range 3 {
+		data, err := c.Get(ctx, "BTCUSDT", opts...)
+		if err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+		if data.CurrentPrice != first.CurrentPrice {
+			t.Errorf("refresh %d: CurrentPrice = %v, want the stored %v", i, data.CurrentPrice, first.CurrentPrice)
+		}
+	}
+	after := callCounts(src)
+	for _, method := range []string{"Klines", "OpenInterestHistory", "FundingRateHistory"} {
+		if after[method] != initial[method] {
+			t.Errorf("%s calls = %d after refreshes, want %d (no candle or point closed)", method, after[method], initial[method])
+		}
+	}
+	// 稳态下每次刷新只剩当前OI与溢价指数
+	perRefresh := 0
+	for method, n := range after {
+		perRefresh += n - initial[method]
+	}
+	if perRefresh != 3*2 {
+		t.Errorf("%d requests over 3 refreshes, want 6 (calls %v → %v)", perRefresh, initial, after)
+	}
+
+	// WithoutCache强制重新拉取进行中的K线
+	if _, err := c.Get(ctx, "BTCUSDT", append(opts, WithoutCache())...); err != nil {
+		t.Fatalf("Get WithoutCache: %v", err)
+	}
+	if got, want := src.Calls("Klines"), after["Klines"]+4; got != want {
+		t.Errorf("Klines calls with WithoutCache = %d, want %d", got, want)
+	}
+}
+
+func TestIncrementalKlinesRefetchesClosedBar(t *testing.T) {
+	step := intervalDuration("3m").Milliseconds()
+	end := time.Now().UnixMilli()/step*step - 5*step
+	full := fixtureKlines("3m", 300, end)
+	src := NewFakeSource().SetKlines("BTCUSDT", "3m", full)
+	c := NewClient(WithSource(src), WithIncrementalRefresh())
+
+	// 已保存的最后一根早已收盘，其后又有5根：请求从它开始的部分并接到已有序列之后
+	stored := slices.Clone(full[:295])
+	stored[294].Close = 1 // 收盘前拉取的旧值
+	c.series.klines["BTCUSDT|3m|200"] = stored
+
+	got, err := c.incrementalKlines(context.Background(), "BTCUSDT", "3m", 200, false)
+	if err != nil {
+		t.Fatalf("incrementalKlines: %v", err)
+	}
+	if src.Calls("Klines") != 1 {
+		t.Errorf("Klines calls = %d, want 1", src.Calls("Klines"))
+	}
+	if !slices.Equal(got, full[100:]) {
+		t.Errorf("merged klines differ from the last 200 source bars (len %d)", len(got))
+	}
+}
+
+func TestMergeSeries(t *testing.T) {
+	ts := func(v int64) int64 { return v }
+	tests := []struct {
+		name          string
+		stored, fresh []int64
+		limit         int
+		want          []int64
+	}{
+		{"empty store", nil, []int64{10, 20}, 5, []int64{10, 20}},
+		{"nothing fetched", []int64{10, 20}, nil, 5, []int64{10, 20}},
+		// fresh从已保存的最后一点开始：覆盖重叠部分
+		{"overlap", []int64{10, 20, 30}, []int64{30, 40}, 10, []int64{10, 20, 30, 40}},
+		{"overlap several", []int64{10, 20, 30, 40}, []int64{20, 30, 40, 50}, 10, []int64{10, 20, 30, 40, 50}},
+		{"adjacent", []int64{10, 20}, []int64{30}, 10, []int64{10, 20, 30}},
+		// 与已保存序列之间缺了不止一个点：整体重置
+		{"gap resets", []int64{10, 20, 30}, []int64{50, 60}, 10, []int64{50, 60}},
+		{"trim to limit", []int64{10, 20, 30, 40}, []int64{40, 50, 60}, 4, []int64{30, 40, 50, 60}},
+		{"no limit", []int64{10, 20}, []int64{20, 30}, 0, []int64{10, 20, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.stored)
+			got := mergeSeries(tt.stored, tt.fresh, tt.limit, ts)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mergeSeries = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.stored, before) {
+				t.Errorf("stored modified to %v", tt.stored)
+			}
+		})
+	}
+}
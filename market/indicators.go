@@ -0,0 +1,798 @@
+package market
+
+import "math"
+
+// 序列类指标：返回与klines逐根对齐的完整序列，result[i]为第i根K线收盘时的指标值
+// 数据不足的预热区为NaN（可用math.IsNaN判断），数据量少于预热长度时整个序列都是NaN
+
+// EMASeries 收盘价的EMA序列，以前period根收盘价的SMA为初值，前period-1个值为NaN
+func EMASeries(klines []Kline, period int) []float64 {
+	return emaSeries(Klines(klines).Closes(), period)
+}
+
+// Smoothing RSI与ATR的平滑方式，不同看盘软件的默认值不同
+type Smoothing int
+
+const (
+	// SmoothWilder Wilder平滑（RMA，α=1/period，以前period个值的SMA为初值），默认方式，与TradingView的ta.rsi/ta.atr相同
+	SmoothWilder Smoothing = iota
+	// SmoothEMA 指数平滑（α=2/(period+1)，以SMA为初值）
+	SmoothEMA
+	// SmoothSMA 最近period个值的简单平均
+	SmoothSMA
+)
+
+// IndicatorConfig RSI与ATR的计算参数
+type IndicatorConfig struct {
+	Period    int
+	Smoothing Smoothing
+}
+
+// smooth 按平滑方式计算values的平滑序列，跳过开头的NaN；结果与values对齐
+func (cfg IndicatorConfig) smooth(values []float64) []float64 {
+	switch cfg.Smoothing {
+	case SmoothEMA:
+		return emaSeries(values, cfg.Period)
+	case SmoothSMA:
+		return smaSeries(values, cfg.Period)
+	default:
+		return rmaSeries(values, cfg.Period)
+	}
+}
+
+// RSISeries Wilder平滑的RSI序列，前period个值为NaN（需要period个涨跌幅）
+// 区间内没有下跌时为100
+func RSISeries(klines []Kline, period int) []float64 {
+	return RSISeriesWith(klines, IndicatorConfig{Period: period})
+}
+
+// RSISeriesWith 按cfg的周期与平滑方式计算RSI序列，预热区与RSISeries相同
+func RSISeriesWith(klines []Kline, cfg IndicatorConfig) []float64 {
+	result := nanSeries(len(klines))
+	if cfg.Period <= 0 || len(klines) <= cfg.Period {
+		return result
+	}
+
+	// 第一根K线没有涨跌幅，保持NaN，平滑时被跳过
+	gains := nanSeries(len(klines))
+	losses := nanSeries(len(klines))
+	for i := 1; i < len(klines); i++ {
+		change := klines[i].Close - klines[i-1].Close
+		gains[i] = math.Max(change, 0)
+		losses[i] = math.Max(-change, 0)
+	}
+	avgGain := cfg.smooth(gains)
+	avgLoss := cfg.smooth(losses)
+	for i := cfg.Period; i < len(klines); i++ {
+		result[i] = rsiFromAverages(avgGain[i], avgLoss[i])
+	}
+	return result
+}
+
+// rsiFromAverages 由平均涨幅与平均跌幅计算RSI
+func rsiFromAverages(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		return 100
+	}
+	rs := avgGain / avgLoss
+	return 100 - (100 / (1 + rs))
+}
+
+// ATRSeries Wilder平滑的ATR序列，前period个值为NaN（第一根K线没有前收盘价，不计算真实波幅）
+func ATRSeries(klines []Kline, period int) []float64 {
+	return ATRSeriesWith(klines, IndicatorConfig{Period: period})
+}
+
+// ATRSeriesWith 按cfg的周期与平滑方式计算ATR序列，预热区与ATRSeries相同
+func ATRSeriesWith(klines []Kline, cfg IndicatorConfig) []float64 {
+	if cfg.Period <= 0 || len(klines) <= cfg.Period {
+		return nanSeries(len(klines))
+	}
+	trs := trueRanges(klines)
+	trs[0] = math.NaN()
+	return cfg.smooth(trs)
+}
+
+// trueRanges 每根K线的真实波幅，第一根没有前收盘价，为0
+func trueRanges(klines []Kline) []float64 {
+	trs := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		low, high := trueBounds(klines, i)
+		trs[i] = high - low
+	}
+	return trs
+}
+
+// trueBounds 第i根K线（i>=1）的真实低点与真实高点：分别把前收盘价计入最低价与最高价
+// 真实波幅 = 真实高点 − 真实低点，等价于 max(高−低, |高−前收|, |低−前收|)
+func trueBounds(klines []Kline, i int) (low, high float64) {
+	prevClose := klines[i-1].Close
+	return math.Min(klines[i].Low, prevClose), math.Max(klines[i].High, prevClose)
+}
+
+// UltimateOscillatorSeries 终极振荡指标：买压BP=收盘−真实低点，A_n=最近n根ΣBP/ΣTR，
+// UO = 100×(4·A_short + 2·A_mid + A_long)/7；前long个值为NaN，窗口内真实波幅之和为0时为NaN
+func UltimateOscillatorSeries(klines []Kline, short, mid, long int) []float64 {
+	result := nanSeries(len(klines))
+	if short <= 0 || mid <= 0 || long <= 0 || len(klines) <= max(short, mid, long) {
+		return result
+	}
+
+	bp := make([]float64, len(klines))
+	tr := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		low, high := trueBounds(klines, i)
+		bp[i] = klines[i].Close - low
+		tr[i] = high - low
+	}
+	average := func(end, n int) (float64, bool) {
+		sumBP, sumTR := 0.0, 0.0
+		for j := end - n + 1; j <= end; j++ {
+			sumBP += bp[j]
+			sumTR += tr[j]
+		}
+		return sumBP / sumTR, sumTR > 0
+	}
+	for i := max(short, mid, long); i < len(klines); i++ {
+		a1, ok1 := average(i, short)
+		a2, ok2 := average(i, mid)
+		a3, ok3 := average(i, long)
+		if ok1 && ok2 && ok3 {
+			result[i] = 100 * (4*a1 + 2*a2 + a3) / 7
+		}
+	}
+	return result
+}
+
+// MACDSeries MACD线（EMA快线−EMA慢线）、信号线（MACD线的EMA）与柱状图（MACD−信号线）序列
+// MACD线前slow-1个值为NaN，信号线与柱状图前slow+signalPeriod-2个值为NaN
+func MACDSeries(klines []Kline, fast, slow, signalPeriod int) (macd, signal, histogram []float64) {
+	closes := Klines(klines).Closes()
+	fastEMA := emaSeries(closes, fast)
+	slowEMA := emaSeries(closes, slow)
+
+	macd = make([]float64, len(klines))
+	for i := range macd {
+		macd[i] = fastEMA[i] - slowEMA[i] // 任一为NaN时结果为NaN
+	}
+	signal = emaSeries(macd, signalPeriod)
+	histogram = make([]float64, len(klines))
+	for i := range histogram {
+		histogram[i] = macd[i] - signal[i]
+	}
+	return macd, signal, histogram
+}
+
+// emaSeries 对values计算EMA，跳过开头的NaN，以其后period个值的SMA为初值；结果与values对齐
+func emaSeries(values []float64, period int) []float64 {
+	return smoothedSeries(values, period, 2.0/float64(period+1))
+}
+
+// rmaSeries Wilder平滑（α=1/period），初值与emaSeries相同
+func rmaSeries(values []float64, period int) []float64 {
+	return smoothedSeries(values, period, 1.0/float64(period))
+}
+
+// smoothedSeries 以alpha为系数的指数平滑，跳过开头的NaN，以其后period个值的SMA为初值
+func smoothedSeries(values []float64, period int, alpha float64) []float64 {
+	result := nanSeries(len(values))
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if period <= 0 || len(values)-start < period {
+		return result
+	}
+
+	sum := 0.0
+	for _, v := range values[start : start+period] {
+		sum += v
+	}
+	ema := sum / float64(period)
+	result[start+period-1] = ema
+
+	for i := start + period; i < len(values); i++ {
+		ema = (values[i]-ema)*alpha + ema
+		result[i] = ema
+	}
+	return result
+}
+
+// nanSeries 长度为n、全部为NaN的序列
+func nanSeries(n int) []float64 {
+	series := make([]float64, n)
+	for i := range series {
+		series[i] = math.NaN()
+	}
+	return series
+}
+
+// lastValue 序列的最后一个值，序列为空或仍在预热区（NaN）时返回0，与标量指标的约定一致
+func lastValue(series []float64) float64 {
+	v, _ := lastValid(series)
+	return v
+}
+
+// lastValid 序列的最后一个值及其是否有效；序列为空或仍在预热区（NaN）时返回(0, false)
+func lastValid(series []float64) (float64, bool) {
+	if len(series) == 0 || math.IsNaN(series[len(series)-1]) {
+		return 0, false
+	}
+	return series[len(series)-1], true
+}
+
+// BollingerBands 布林带（总体标准差）
+type BollingerBands struct {
+	Upper    float64 `json:"upper"`
+	Middle   float64 `json:"middle"` // period期SMA
+	Lower    float64 `json:"lower"`
+	Width    float64 `json:"width"`     // (Upper−Lower)/Middle，Middle为0时为0
+	PercentB float64 `json:"percent_b"` // 收盘价在带内的位置 (Close−Lower)/(Upper−Lower)，0为下轨、1为上轨，带宽为0时为0.5
+}
+
+// BollingerSeries 逐根K线的布林带，前period-1个元素（预热区）的字段均为NaN
+func BollingerSeries(klines []Kline, period int, multiplier float64) []BollingerBands {
+	result := make([]BollingerBands, len(klines))
+	nan := math.NaN()
+	for i := range result {
+		if period <= 0 || i < period-1 {
+			result[i] = BollingerBands{Upper: nan, Middle: nan, Lower: nan, Width: nan, PercentB: nan}
+			continue
+		}
+		result[i] = bollingerAt(klines[i-period+1:i+1], multiplier)
+	}
+	return result
+}
+
+// bollingerAt 以window最后一根K线为当前K线计算布林带
+func bollingerAt(window []Kline, multiplier float64) BollingerBands {
+	mean := 0.0
+	for _, k := range window {
+		mean += k.Close
+	}
+	mean /= float64(len(window))
+
+	variance := 0.0
+	for _, k := range window {
+		diff := k.Close - mean
+		variance += diff * diff
+	}
+	variance /= float64(len(window))
+	stddev := math.Sqrt(variance)
+
+	bands := BollingerBands{
+		Upper:    mean + multiplier*stddev,
+		Middle:   mean,
+		Lower:    mean - multiplier*stddev,
+		PercentB: 0.5,
+	}
+	if mean != 0 {
+		bands.Width = (bands.Upper - bands.Lower) / mean
+	}
+	if spread := bands.Upper - bands.Lower; spread > 0 {
+		bands.PercentB = (window[len(window)-1].Close - bands.Lower) / spread
+	}
+	return bands
+}
+
+// StochasticSeries 随机指标（慢速）：原始%K = (收盘−N期最低)/(N期最高−N期最低)×100，
+// %K为原始%K的smoothing期SMA，%D为%K的dPeriod期SMA；N期内最高等于最低（横盘）时原始%K取50
+func StochasticSeries(klines []Kline, kPeriod, dPeriod, smoothing int) (k, d []float64) {
+	raw := nanSeries(len(klines))
+	if kPeriod > 0 {
+		for i := kPeriod - 1; i < len(klines); i++ {
+			highest, lowest := klines[i].High, klines[i].Low
+			for _, kl := range klines[i-kPeriod+1 : i] {
+				highest = math.Max(highest, kl.High)
+				lowest = math.Min(lowest, kl.Low)
+			}
+			if highest == lowest {
+				raw[i] = 50
+				continue
+			}
+			raw[i] = (klines[i].Close - lowest) / (highest - lowest) * 100
+		}
+	}
+	k = smaSeries(raw, smoothing)
+	d = smaSeries(k, dPeriod)
+	return k, d
+}
+
+// smaSeries 对values计算简单移动平均，跳过开头的NaN；结果与values对齐
+func smaSeries(values []float64, period int) []float64 {
+	result := nanSeries(len(values))
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if period <= 0 || len(values)-start < period {
+		return result
+	}
+
+	sum := 0.0
+	for i := start; i < len(values); i++ {
+		sum += values[i]
+		if i-start >= period {
+			sum -= values[i-period]
+		}
+		if i-start >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+	return result
+}
+
+// ADXSeries Wilder的趋向指标：+DI/−DI（与ATR相同的Wilder平滑）以及DX的Wilder平均ADX
+// +DI/−DI前period个值为NaN，ADX需要2×period根K线，前2×period-1个值为NaN
+func ADXSeries(klines []Kline, period int) (adx, plusDI, minusDI []float64) {
+	n := len(klines)
+	adx, plusDI, minusDI = nanSeries(n), nanSeries(n), nanSeries(n)
+	if period <= 0 || n <= period {
+		return adx, plusDI, minusDI
+	}
+
+	trs := trueRanges(klines)
+	plusDM := make([]float64, n)
+	minusDM := make([]float64, n)
+	for i := 1; i < n; i++ {
+		up := klines[i].High - klines[i-1].High
+		down := klines[i-1].Low - klines[i].Low
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+	}
+
+	var tr, pdm, mdm float64
+	for i := 1; i <= period; i++ {
+		tr += trs[i]
+		pdm += plusDM[i]
+		mdm += minusDM[i]
+	}
+	p := float64(period)
+	tr, pdm, mdm = tr/p, pdm/p, mdm/p
+
+	dx := nanSeries(n)
+	for i := period; i < n; i++ {
+		if i > period {
+			tr = (tr*(p-1) + trs[i]) / p
+			pdm = (pdm*(p-1) + plusDM[i]) / p
+			mdm = (mdm*(p-1) + minusDM[i]) / p
+		}
+		if tr == 0 {
+			plusDI[i], minusDI[i] = 0, 0
+		} else {
+			plusDI[i] = pdm / tr * 100
+			minusDI[i] = mdm / tr * 100
+		}
+		if sum := plusDI[i] + minusDI[i]; sum > 0 {
+			dx[i] = math.Abs(plusDI[i]-minusDI[i]) / sum * 100
+		} else {
+			dx[i] = 0
+		}
+	}
+
+	// ADX：前period个DX的均值为初值，之后Wilder平滑
+	first := 2*period - 1
+	if n <= first {
+		return adx, plusDI, minusDI
+	}
+	sum := 0.0
+	for i := period; i <= first; i++ {
+		sum += dx[i]
+	}
+	value := sum / p
+	adx[first] = value
+	for i := first + 1; i < n; i++ {
+		value = (value*(p-1) + dx[i]) / p
+		adx[i] = value
+	}
+	return adx, plusDI, minusDI
+}
+
+// OBVSeries 能量潮：收盘上涨累加成交量、下跌累减、持平不变，第一根K线为0
+func OBVSeries(klines []Kline) []float64 {
+	obv := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		obv[i] = obv[i-1]
+		switch {
+		case klines[i].Close > klines[i-1].Close:
+			obv[i] += klines[i].Volume
+		case klines[i].Close < klines[i-1].Close:
+			obv[i] -= klines[i].Volume
+		}
+	}
+	return obv
+}
+
+// linearSlope values对下标做最小二乘回归的斜率（每根K线的变化量），少于2个值时为0
+func linearSlope(values []float64) float64 {
+	return fitLine(nil, values).slope
+}
+
+// lineFit 最小二乘直线拟合 y = intercept + slope·x 的结果
+type lineFit struct {
+	slope     float64
+	intercept float64
+	r2        float64 // 决定系数，y全部相同时为0
+	stdErr    float64 // 残差标准误 √(SSE/(n−2))，少于3个点时为0
+}
+
+// fitLine 对(xs[i], ys[i])做最小二乘回归，xs为nil时以下标0,1,2…为x；少于2个点或x全部相同时为零值
+func fitLine(xs, ys []float64) lineFit {
+	n := float64(len(ys))
+	if len(ys) < 2 {
+		return lineFit{}
+	}
+	x := func(i int) float64 {
+		if xs == nil {
+			return float64(i)
+		}
+		return xs[i]
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range ys {
+		sumX += x(i)
+		sumY += y
+		sumXY += x(i) * y
+		sumXX += x(i) * x(i)
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return lineFit{}
+	}
+	fit := lineFit{slope: (n*sumXY - sumX*sumY) / denom}
+	fit.intercept = (sumY - fit.slope*sumX) / n
+
+	meanY := sumY / n
+	var sse, sst float64
+	for i, y := range ys {
+		residual := y - (fit.intercept + fit.slope*x(i))
+		sse += residual * residual
+		sst += (y - meanY) * (y - meanY)
+	}
+	if sst > 0 {
+		fit.r2 = 1 - sse/sst
+	}
+	if len(ys) > 2 {
+		fit.stdErr = math.Sqrt(sse / (n - 2))
+	}
+	return fit
+}
+
+// KeltnerSeries 肯特纳通道：中轨为收盘价的period期EMA，上下轨为中轨 ± multiplier×ATR(period)
+// 前period个值为NaN（ATR需要period个真实波幅）
+func KeltnerSeries(klines []Kline, period int, multiplier float64) (upper, middle, lower []float64) {
+	middle = EMASeries(klines, period)
+	atr := ATRSeries(klines, period)
+	upper = make([]float64, len(klines))
+	lower = make([]float64, len(klines))
+	for i := range klines {
+		upper[i] = middle[i] + multiplier*atr[i] // 任一为NaN时结果为NaN
+		lower[i] = middle[i] - multiplier*atr[i]
+	}
+	return upper, middle, lower
+}
+
+// SqueezeSeries TTM挤压：period期、bbMultiplier倍标准差的布林带完全落在period期、kcMultiplier倍ATR的肯特纳通道内
+// 任一指标仍在预热区时为false
+func SqueezeSeries(klines []Kline, period int, bbMultiplier, kcMultiplier float64) []bool {
+	bands := BollingerSeries(klines, period, bbMultiplier)
+	kcUpper, _, kcLower := KeltnerSeries(klines, period, kcMultiplier)
+	result := make([]bool, len(klines))
+	for i := range klines {
+		// 与NaN比较恒为false，预热区自然不会判定为挤压
+		result[i] = bands[i].Upper < kcUpper[i] && bands[i].Lower > kcLower[i]
+	}
+	return result
+}
+
+// SuperTrendSeries 超级趋势：以(H+L)/2 ± multiplier×ATR(period)为基础轨道，
+// 上轨只在新值更低或前收盘突破上轨时更新，下轨只在新值更高或前收盘跌破下轨时更新；
+// 收盘突破当前有效轨道时方向翻转。direction为+1（多头，level为下轨）或−1（空头，level为上轨）
+// 前period个level为NaN、direction为0；第一个有效值的方向由收盘价相对(H+L)/2决定
+func SuperTrendSeries(klines []Kline, period int, multiplier float64) (level []float64, direction []int) {
+	level = nanSeries(len(klines))
+	direction = make([]int, len(klines))
+	atr := ATRSeries(klines, period)
+
+	var upper, lower float64
+	for i, k := range klines {
+		if math.IsNaN(atr[i]) {
+			continue
+		}
+		hl2 := (k.High + k.Low) / 2
+		basicUpper := hl2 + multiplier*atr[i]
+		basicLower := hl2 - multiplier*atr[i]
+
+		if direction[i-1] == 0 { // 第一个有效值（ATR预热区之后，i ≥ 1）
+			upper, lower = basicUpper, basicLower
+			direction[i] = -1
+			if k.Close >= hl2 {
+				direction[i] = 1
+			}
+		} else {
+			prevClose := klines[i-1].Close
+			if basicUpper < upper || prevClose > upper {
+				upper = basicUpper
+			}
+			if basicLower > lower || prevClose < lower {
+				lower = basicLower
+			}
+			direction[i] = direction[i-1]
+			switch {
+			case direction[i] < 0 && k.Close > upper:
+				direction[i] = 1
+			case direction[i] > 0 && k.Close < lower:
+				direction[i] = -1
+			}
+		}
+
+		if direction[i] > 0 {
+			level[i] = lower
+		} else {
+			level[i] = upper
+		}
+	}
+	return level, direction
+}
+
+// WilliamsRSeries 威廉指标 %R = (N期最高−收盘)/(N期最高−N期最低)×(−100)，取值[−100, 0]
+// 前period-1个值为NaN；N期内最高等于最低时为−50
+func WilliamsRSeries(klines []Kline, period int) []float64 {
+	result := nanSeries(len(klines))
+	if period <= 0 {
+		return result
+	}
+	for i := period - 1; i < len(klines); i++ {
+		highest, lowest := klines[i].High, klines[i].Low
+		for _, k := range klines[i-period+1 : i] {
+			highest = math.Max(highest, k.High)
+			lowest = math.Min(lowest, k.Low)
+		}
+		if highest == lowest {
+			result[i] = -50
+			continue
+		}
+		result[i] = (highest - klines[i].Close) / (highest - lowest) * -100
+	}
+	return result
+}
+
+// cciConstant Lambert的CCI缩放常数，使约70%~80%的CCI值落在±100之间
+const cciConstant = 0.015
+
+// CCISeries 顺势指标 CCI = (典型价−典型价的N期SMA)/(0.015×典型价的N期平均绝对偏差)
+// 前period-1个值为NaN；平均绝对偏差为0（窗口内典型价全部相同）时为0
+func CCISeries(klines []Kline, period int) []float64 {
+	result := nanSeries(len(klines))
+	if period <= 0 {
+		return result
+	}
+	for i := period - 1; i < len(klines); i++ {
+		window := klines[i-period+1 : i+1]
+		mean := 0.0
+		for _, k := range window {
+			mean += k.TypicalPrice()
+		}
+		mean /= float64(period)
+
+		meanDev := 0.0
+		for _, k := range window {
+			meanDev += math.Abs(k.TypicalPrice() - mean)
+		}
+		meanDev /= float64(period)
+
+		if meanDev == 0 {
+			result[i] = 0
+			continue
+		}
+		result[i] = (klines[i].TypicalPrice() - mean) / (cciConstant * meanDev)
+	}
+	return result
+}
+
+// moneyFlowVolume 资金流量：((收盘−最低)−(最高−收盘))/(最高−最低)×成交量，最高等于最低时为0
+func moneyFlowVolume(k Kline) float64 {
+	spread := k.Range()
+	if spread == 0 {
+		return 0
+	}
+	return ((k.Close - k.Low) - (k.High - k.Close)) / spread * k.Volume
+}
+
+// ADSeries 累积/派发线：逐根累加资金流量，从第一根K线开始
+func ADSeries(klines []Kline) []float64 {
+	result := make([]float64, len(klines))
+	sum := 0.0
+	for i, k := range klines {
+		sum += moneyFlowVolume(k)
+		result[i] = sum
+	}
+	return result
+}
+
+// CMFSeries 蔡金资金流：period期资金流量之和 / period期成交量之和，取值[−1, 1]
+// 前period-1个值为NaN；窗口内成交量为0时为0
+func CMFSeries(klines []Kline, period int) []float64 {
+	result := nanSeries(len(klines))
+	if period <= 0 {
+		return result
+	}
+	var flow, vol float64
+	for i, k := range klines {
+		flow += moneyFlowVolume(k)
+		vol += k.Volume
+		if i >= period {
+			old := klines[i-period]
+			flow -= moneyFlowVolume(old)
+			vol -= old.Volume
+		}
+		if i < period-1 {
+			continue
+		}
+		if vol > 0 {
+			result[i] = flow / vol
+		} else {
+			result[i] = 0
+		}
+	}
+	return result
+}
+
+// Cross 两条序列的相对位置与最近一次交叉
+type Cross struct {
+	Side      int `json:"side"`       // +1 第一条在上方，−1 在下方，0 数据不足或相等
+	BarsSince int `json:"bars_since"` // 最近一次交叉距今的K线数（交叉发生在最后一根时为0），窗口内没有交叉时为−1
+}
+
+// CrossState EMA与MACD的交叉状态
+type CrossState struct {
+	EMA20vsEMA60 Cross `json:"ema20_vs_ema60"`
+	MACDvsSignal Cross `json:"macd_vs_signal"`
+	MACDvsZero   Cross `json:"macd_vs_zero"`
+}
+
+// detectCross 比较a与b在最后一根K线的位置，并向前扫描找到最近一次方向改变
+// 两者相等的K线不视为交叉，沿用之前的方向；任一为NaN（预热区）时停止扫描
+func detectCross(a, b []float64) Cross {
+	side := func(i int) int {
+		switch {
+		case math.IsNaN(a[i]) || math.IsNaN(b[i]):
+			return 0
+		case a[i] > b[i]:
+			return 1
+		case a[i] < b[i]:
+			return -1
+		}
+		return 0
+	}
+
+	last := len(a) - 1
+	cross := Cross{BarsSince: -1}
+	if last < 0 {
+		return cross
+	}
+	cross.Side = side(last)
+	if cross.Side == 0 {
+		return cross
+	}
+	for i := last - 1; i >= 0; i-- {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			break
+		}
+		if s := side(i); s != 0 && s != cross.Side {
+			cross.BarsSince = last - i - 1
+			break
+		}
+	}
+	return cross
+}
+
+// calculateCrossState EMA20/EMA60、MACD/信号线、MACD/零轴的交叉状态
+func calculateCrossState(klines []Kline) CrossState {
+	macd, signal, _ := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	return CrossState{
+		EMA20vsEMA60: detectCross(EMASeries(klines, 20), EMASeries(klines, 60)),
+		MACDvsSignal: detectCross(macd, signal),
+		MACDvsZero:   detectCross(macd, make([]float64, len(macd))),
+	}
+}
+
+// HeikinAshi 平均K线：收=(开+高+低+收)/4，开=(前一根HA开+前一根HA收)/2（第一根为(开+收)/2），
+// 高低分别取原K线与HA开收的极值；时间与成交量不变
+func HeikinAshi(klines []Kline) []Kline {
+	ha := make([]Kline, len(klines))
+	for i, k := range klines {
+		ha[i] = k
+		ha[i].Close = (k.Open + k.High + k.Low + k.Close) / 4
+		if i == 0 {
+			ha[i].Open = (k.Open + k.Close) / 2
+		} else {
+			ha[i].Open = (ha[i-1].Open + ha[i-1].Close) / 2
+		}
+		ha[i].High = math.Max(k.High, math.Max(ha[i].Open, ha[i].Close))
+		ha[i].Low = math.Min(k.Low, math.Min(ha[i].Open, ha[i].Close))
+	}
+	return ha
+}
+
+// heikinAshiTrend 最后一根HA K线的连续同色根数（阳线为正、阴线为负，开收相等时为0）
+// 以及强势标记：无下影的阳线为+1，无上影的阴线为−1，否则为0
+func heikinAshiTrend(klines []Kline) (streak, strong int) {
+	ha := HeikinAshi(klines)
+	color := func(k Kline) int {
+		switch {
+		case k.Close > k.Open:
+			return 1
+		case k.Close < k.Open:
+			return -1
+		}
+		return 0
+	}
+	if len(ha) == 0 {
+		return 0, 0
+	}
+	last := ha[len(ha)-1]
+	c := color(last)
+	if c == 0 {
+		return 0, 0
+	}
+	for i := len(ha) - 1; i >= 0 && color(ha[i]) == c; i-- {
+		streak += c
+	}
+	switch {
+	case c > 0 && last.Low == last.Open:
+		strong = 1
+	case c < 0 && last.High == last.Open:
+		strong = -1
+	}
+	return streak, strong
+}
+
+// elderPeriod Elder强力指数与动力系统使用的EMA周期
+const elderPeriod = 13
+
+// ForceIndexSeries Elder强力指数：(收盘−前收盘)×成交量的EMA(period)，前period个值为NaN
+func ForceIndexSeries(klines []Kline, period int) []float64 {
+	raw := nanSeries(len(klines))
+	for i := 1; i < len(klines); i++ {
+		raw[i] = (klines[i].Close - klines[i-1].Close) * klines[i].Volume
+	}
+	return emaSeries(raw, period)
+}
+
+// ImpulseColor Elder动力系统的颜色
+type ImpulseColor string
+
+const (
+	ImpulseGreen ImpulseColor = "green" // EMA13与MACD柱状图同时上升
+	ImpulseRed   ImpulseColor = "red"   // EMA13与MACD柱状图同时下降
+	ImpulseBlue  ImpulseColor = "blue"  // 两者方向不一致或持平
+)
+
+// ElderImpulse 最后一根K线的Elder动力系统颜色，由EMA13与MACD(12,26,9)柱状图相对前一根的变化决定
+// 任一序列在最后两根K线上不可用时返回空字符串
+func ElderImpulse(klines []Kline) ImpulseColor {
+	n := len(klines)
+	if n < 2 {
+		return ""
+	}
+	ema := EMASeries(klines, elderPeriod)
+	_, _, histogram := MACDSeries(klines, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	if math.IsNaN(ema[n-2]) || math.IsNaN(histogram[n-2]) {
+		return ""
+	}
+	emaSlope := ema[n-1] - ema[n-2]
+	histSlope := histogram[n-1] - histogram[n-2]
+	switch {
+	case emaSlope > 0 && histSlope > 0:
+		return ImpulseGreen
+	case emaSlope < 0 && histSlope < 0:
+		return ImpulseRed
+	}
+	return ImpulseBlue
+}
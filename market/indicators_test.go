@@ -0,0 +1,253 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMACDSeriesReference(t *testing.T) {
+	macd, signal, histogram := MACDSeries(referenceKlines(), 12, 26, 9)
+	assertSeries(t, "macd", macd, map[int]float64{24: math.NaN(), 25: -3.4632292449, 40: 3.56965435, 59: -0.5702650775})
+	assertSeries(t, "signal", signal, map[int]float64{32: math.NaN(), 33: -1.8709738963, 45: 3.0572955645, 59: -0.1042323266})
+	assertSeries(t, "histogram", histogram, map[int]float64{33: 2.0539072691, 59: -0.4660327509})
+}
+
+func TestBollingerSeriesReference(t *testing.T) {
+	bands := BollingerSeries(referenceKlines(), 20, 2)
+	if !math.IsNaN(bands[18].Middle) {
+		t.Errorf("bands[18].Middle = %v, want NaN during warm-up", bands[18].Middle)
+	}
+	want := map[int]BollingerBands{
+		19: {Upper: 113.290935751, Middle: 106.3395, Lower: 99.388064249, Width: 0.1307404257, PercentB: 0.1857124084},
+		40: {Upper: 124.0973615261, Middle: 108.3905, Lower: 92.6836384739, Width: 0.2898198924, PercentB: 0.8240462769},
+		59: {Upper: 121.6312950034, Middle: 113.1215, Lower: 104.6117049966, Width: 0.1504540694, PercentB: 0.4229417395},
+	}
+	for i, w := range want {
+		got := bands[i]
+		assertSeries(t, "bollinger", []float64{got.Upper, got.Middle, got.Lower, got.Width, got.PercentB},
+			map[int]float64{0: w.Upper, 1: w.Middle, 2: w.Lower, 3: w.Width, 4: w.PercentB})
+	}
+}
+
+func TestStochasticSeriesReference(t *testing.T) {
+	k, d := StochasticSeries(referenceKlines(), 14, 3, 3)
+	assertSeries(t, "%K", k, map[int]float64{14: math.NaN(), 15: 48.7208306309, 30: 71.2414236003, 59: 33.6555142503})
+	assertSeries(t, "%D", d, map[int]float64{16: math.NaN(), 17: 38.2059326332, 30: 57.7322736022, 59: 28.0813095308})
+}
+
+func TestADXSeriesReference(t *testing.T) {
+	adx, plusDI, minusDI := ADXSeries(referenceKlines(), 14)
+	assertSeries(t, "adx", adx, map[int]float64{26: math.NaN(), 27: 14.5240850645, 40: 38.8692923107, 59: 23.0419420803})
+	assertSeries(t, "+DI", plusDI, map[int]float64{13: math.NaN(), 14: 27.0245511176, 40: 29.1241746041, 59: 15.8090214981})
+	assertSeries(t, "-DI", minusDI, map[int]float64{14: 12.6786368633, 40: 7.6577992275, 59: 17.153137891})
+}
+
+// squeezeKlines 前30根每根上涨2、振幅1（收盘价波动大、ATR小），之后收盘价横盘、振幅10（收盘价不动、ATR大）
+func squeezeKlines() []Kline {
+	klines := make([]Kline, 60)
+	for i := range klines {
+		c, r := 100+2*float64(i), 0.5
+		if i >= 30 {
+			c, r = 158, 5
+		}
+		klines[i] = Kline{OpenTime: int64(i) * 60000, Open: c, High: c + r, Low: c - r, Close: c}
+	}
+	return klines
+}
+
+func TestSqueezeFlipsOnAtTheRightBar(t *testing.T) {
+	klines := squeezeKlines()
+	// 参考实现：第42根布林上轨163.33仍高于肯特纳上轨162.05，第43根162.42低于162.83，首次落入通道
+	squeeze := SqueezeSeries(klines, keltnerPeriod, 2, keltnerMultiplier)
+	for i, on := range squeeze {
+		if want := i >= 43; on != want {
+			t.Errorf("squeeze[%d] = %v, want %v", i, on, want)
+		}
+	}
+
+	if on, bars := calculateSqueeze(klines[:43], keltnerPeriod, 2, keltnerMultiplier); on || bars != 0 {
+		t.Errorf("before flip: on=%v bars=%d, want false 0", on, bars)
+	}
+	if on, bars := calculateSqueeze(klines[:44], keltnerPeriod, 2, keltnerMultiplier); !on || bars != 1 {
+		t.Errorf("flip bar: on=%v bars=%d, want true 1", on, bars)
+	}
+	if on, bars := calculateSqueeze(klines, keltnerPeriod, 2, keltnerMultiplier); !on || bars != 17 {
+		t.Errorf("all bars: on=%v bars=%d, want true 17", on, bars)
+	}
+
+	// 放量突破的一根K线使布林带扩张到通道外，挤压结束、计数清零
+	breakout := append(klines, Kline{OpenTime: 60 * 60000, Open: 158, High: 191, Low: 157, Close: 190})
+	if on, bars := calculateSqueeze(breakout, keltnerPeriod, 2, keltnerMultiplier); on || bars != 0 {
+		t.Errorf("after breakout: on=%v bars=%d, want false 0", on, bars)
+	}
+}
+
+// superTrendKlines 前25根每根上涨1，之后每根下跌3，振幅均为2
+func superTrendKlines() []Kline {
+	klines := make([]Kline, 40)
+	for i := range klines {
+		c := 100 + float64(i)
+		if i >= 25 {
+			c = 124 - 3*float64(i-24)
+		}
+		klines[i] = Kline{OpenTime: int64(i) * 60000, Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+	return klines
+}
+
+func TestSuperTrendFlipsAtTheRightBar(t *testing.T) {
+	klines := superTrendKlines()
+	level, direction := SuperTrendSeries(klines, superTrendPeriod, superTrendMultiplier)
+	// 第26根收盘118恰好等于下轨，不翻转；第27根收盘115跌破下轨，翻转为空头，level改为上轨 115+3×ATR(2.542)
+	for i, d := range direction {
+		want := 0
+		switch {
+		case i >= 27:
+			want = -1
+		case i >= superTrendPeriod:
+			want = 1
+		}
+		if d != want {
+			t.Errorf("direction[%d] = %d, want %d", i, d, want)
+		}
+	}
+	assertSeries(t, "supertrend", level, map[int]float64{9: math.NaN(), 10: 104, 24: 118, 26: 118, 27: 122.626, 39: 89.7646532074321})
+
+	tests := []struct {
+		name          string
+		n             int
+		wantLevel     float64
+		wantDirection int
+		wantBars      int
+	}{
+		{"bar before flip", 27, 118, 1, 16},
+		{"flip bar", 28, 122.626, -1, 0},
+		{"all bars", 40, 89.7646532074321, -1, 12},
+		{"warm-up only", superTrendPeriod, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, direction, bars := calculateSuperTrend(klines[:tt.n], superTrendPeriod, superTrendMultiplier)
+			if math.Abs(level-tt.wantLevel) > 1e-9 || direction != tt.wantDirection || bars != tt.wantBars {
+				t.Errorf("got (%v, %d, %d), want (%v, %d, %d)", level, direction, bars, tt.wantLevel, tt.wantDirection, tt.wantBars)
+			}
+		})
+	}
+}
+
+func TestCCIScalesMeanAbsoluteDeviationBy0015(t *testing.T) {
+	// 典型价1、2、6：均值3，平均绝对偏差(2+1+3)/3=2，CCI = (6−3)/(0.015×2) = 100
+	// 若误用标准差（√(14/3)≈2.16）或省略0.015，结果都不会是100
+	flat := func(p float64) Kline { return Kline{Open: p, High: p, Low: p, Close: p} }
+	klines := []Kline{flat(1), flat(2), flat(6)}
+	assertSeries(t, "cci", CCISeries(klines, 3), map[int]float64{0: math.NaN(), 1: math.NaN(), 2: 100})
+
+	// 窗口内典型价全部相同时平均绝对偏差为0，CCI为0而不是NaN
+	assertSeries(t, "cci flat", CCISeries([]Kline{flat(5), flat(5), flat(5)}, 3), map[int]float64{2: 0})
+
+	assertSeries(t, "cci reference", CCISeries(referenceKlines(), 20),
+		map[int]float64{18: math.NaN(), 19: -106.7238912732, 40: 100.8658317292, 59: -38.9582449191})
+	assertSeries(t, "williams %r reference", WilliamsRSeries(referenceKlines(), 14),
+		map[int]float64{12: math.NaN(), 13: -36.3573883162, 40: -16.3577386469, 59: -57.0260223048})
+}
+
+func TestRSIAndATRSmoothingReference(t *testing.T) {
+	klines := referenceKlines()
+	nan := math.NaN()
+	// 三种平滑的初值都是前14个值的SMA，之后才分开
+	tests := []struct {
+		name      string
+		smoothing Smoothing
+		rsi, atr  map[int]float64
+	}{
+		{
+			"Wilder", SmoothWilder,
+			map[int]float64{13: nan, 14: 61.3674496644, 30: 63.6685194577, 59: 52.0695845932},
+			map[int]float64{13: nan, 14: 3.8985714286, 30: 3.8727286891, 59: 3.678119624},
+		},
+		{
+			"EMA", SmoothEMA,
+			map[int]float64{13: nan, 14: 61.3674496644, 30: 72.0291167158, 59: 54.3766017415},
+			map[int]float64{13: nan, 14: 3.8985714286, 30: 3.922161423, 59: 3.6345446316},
+		},
+		{
+			"SMA", SmoothSMA,
+			map[int]float64{13: nan, 14: 61.3674496644, 30: 54.1666666667, 59: 35.0520340587},
+			map[int]float64{13: nan, 14: 3.8985714286, 30: 3.9792857143, 59: 3.6957142857},
+		},
+	}
+	for _, tt := range tests {
+		cfg := IndicatorConfig{Period: 14, Smoothing: tt.smoothing}
+		assertSeries(t, "RSI14 "+tt.name, RSISeriesWith(klines, cfg), tt.rsi)
+		assertSeries(t, "ATR14 "+tt.name, ATRSeriesWith(klines, cfg), tt.atr)
+	}
+	// 默认平滑方式为Wilder
+	assertSeries(t, "RSISeries", RSISeries(klines, 14), tests[0].rsi)
+	assertSeries(t, "ATRSeries", ATRSeries(klines, 14), tests[0].atr)
+}
+
+func TestUltimateOscillatorReference(t *testing.T) {
+	nan := math.NaN()
+	// 手算：三根K线的BP/TR为2/3、1/2、2/3，A1=2/3，A2=3/5，A3=5/8，UO=100×(8/3+6/5+5/8)/7
+	hand := []Kline{
+		{High: 10, Low: 8, Close: 9},
+		{High: 12, Low: 9, Close: 11},
+		{High: 11, Low: 9, Close: 10}, // 前收11高于最高价，真实高点取前收
+		{High: 13, Low: 10, Close: 12},
+	}
+	tests := []struct {
+		name             string
+		klines           []Kline
+		short, mid, long int
+		want             map[int]float64
+	}{
+		{"hand-worked", hand, 1, 2, 3, map[int]float64{2: nan, 3: 100 * (8.0/3 + 6.0/5 + 5.0/8) / 7}},
+		// 按公开的定义独立计算的UO(7,14,28)
+		{"UO(7,14,28)", referenceKlines(), 7, 14, 28, map[int]float64{27: nan, 28: 53.4759144502, 29: 53.6590852806, 40: 60.8951441021, 59: 48.9023412071}},
+		{"not enough bars", hand, 1, 2, 4, map[int]float64{3: nan}},
+		{"invalid period", hand, 0, 2, 3, map[int]float64{3: nan}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, tt.name, UltimateOscillatorSeries(tt.klines, tt.short, tt.mid, tt.long), tt.want)
+		})
+	}
+
+	// 窗口内没有波动时为NaN
+	flat := make([]Kline, 30)
+	for i := range flat {
+		flat[i] = Kline{High: 100, Low: 100, Close: 100}
+	}
+	assertSeries(t, "flat", UltimateOscillatorSeries(flat, 7, 14, 28), map[int]float64{29: nan})
+}
+
+// legacyTrueRange 重构前calculateATR中的真实波幅：max(高−低, |高−前收|, |低−前收|)
+func legacyTrueRange(k, prev Kline) float64 {
+	return math.Max(k.High-k.Low, math.Max(math.Abs(k.High-prev.Close), math.Abs(k.Low-prev.Close)))
+}
+
+func TestTrueRangeMatchesLegacyCalculation(t *testing.T) {
+	// 参考K线加上跳空高开与跳空低开，覆盖前收盘价落在区间之外的两种情况
+	klines := append(referenceKlines(),
+		Kline{Open: 130, High: 131, Low: 128, Close: 129},
+		Kline{Open: 90, High: 92, Low: 89, Close: 91},
+	)
+	trs := trueRanges(klines)
+	if trs[0] != 0 {
+		t.Errorf("trueRanges[0] = %v, want 0", trs[0])
+	}
+	for i := 1; i < len(klines); i++ {
+		if want := legacyTrueRange(klines[i], klines[i-1]); trs[i] != want {
+			t.Errorf("trueRanges[%d] = %v, want %v", i, trs[i], want)
+		}
+		low, high := trueBounds(klines, i)
+		if high-low != trs[i] || low > klines[i].Low || high < klines[i].High {
+			t.Errorf("trueBounds(%d) = %v, %v, want bounds spanning the bar with width %v", i, low, high, trs[i])
+		}
+	}
+
+	// calculateATR仍为重构前的Wilder ATR14
+	if got, ok := calculateATR(referenceKlines(), IndicatorConfig{Period: 14}); !ok || !approxEqual(got, 3.678119624) {
+		t.Errorf("calculateATR = %v, %v, want 3.678119624", got, ok)
+	}
+}
@@ -0,0 +1,151 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultIntervals Get默认拉取的K线周期
+var defaultIntervals = []string{"1m", "3m", "15m", "1h", "4h"}
+
+// 默认K线数量，4h周期单独使用较小窗口
+const (
+	defaultKlineLimit   = 200
+	defaultKlineLimit4h = 120
+)
+
+// baseInterval 计算CurrentPrice/EMA/MACD/RSI优先使用的周期
+const baseInterval = "3m"
+
+// SupportedIntervals 币安支持的K线周期
+var SupportedIntervals = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "8h", "12h", "1d", "3d", "1w", "1M"}
+
+// ErrUnsupportedInterval 请求了币安不支持的K线周期
+var ErrUnsupportedInterval = errors.New("不支持的K线周期")
+
+// ValidateInterval 校验周期是否为币安支持的值（区分大小写，1M为月线，1m为分钟线）
+func ValidateInterval(interval string) error {
+	for _, supported := range SupportedIntervals {
+		if interval == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedInterval, interval)
+}
+
+// validateIntervals 在发起任何请求之前校验周期列表
+func validateIntervals(intervals []string) error {
+	for _, interval := range intervals {
+		if err := ValidateInterval(interval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// priceChangeSources 计算PriceChange1h/4h的首选周期，与历史行为保持一致
+var priceChangeSources = map[time.Duration]string{
+	time.Hour:     "1m",
+	4 * time.Hour: "1h",
+}
+
+// priceChangeOver 计算horizon内的价格变化百分比
+// 优先使用首选周期（1h用1m，4h用1h）；未请求首选周期或K线不足时，
+// 退回到能整除horizon且K线数量足够的最细周期；没有可用周期时返回0
+func priceChangeOver(klinesByInterval map[string][]Kline, horizon time.Duration) float64 {
+	candidates := make([]string, 0, len(SupportedIntervals)+1)
+	if preferred, ok := priceChangeSources[horizon]; ok {
+		candidates = append(candidates, preferred)
+	}
+	for _, interval := range SupportedIntervals {
+		candidates = append(candidates, interval)
+	}
+
+	for _, interval := range candidates {
+		klines, ok := klinesByInterval[interval]
+		if !ok {
+			continue
+		}
+		step := intervalDuration(interval)
+		if step <= 0 || step > horizon || horizon%step != 0 {
+			continue
+		}
+		bars := int(horizon / step)
+		if len(klines) <= bars {
+			continue
+		}
+		return ROC(klines, bars)
+	}
+	return 0
+}
+
+// volumeOver 汇总horizon内的成交量（基础资产数量），取能整除horizon且K线数量足够的最细周期的最近horizon/周期根K线（含未收盘的最后一根）
+// 没有可用周期时返回false
+func volumeOver(klinesByInterval map[string][]Kline, horizon time.Duration) (float64, bool) {
+	for _, interval := range SupportedIntervals {
+		klines, ok := klinesByInterval[interval]
+		if !ok {
+			continue
+		}
+		step := intervalDuration(interval)
+		if step <= 0 || step > horizon || horizon%step != 0 {
+			continue
+		}
+		bars := int(horizon / step)
+		if len(klines) < bars {
+			continue
+		}
+		sum := 0.0
+		for _, k := range klines[len(klines)-bars:] {
+			sum += k.Volume
+		}
+		return sum, true
+	}
+	return 0, false
+}
+
+// defaultKlineLimitFor 周期的默认K线数量
+func defaultKlineLimitFor(interval string) int {
+	if interval == "4h" {
+		return defaultKlineLimit4h
+	}
+	return defaultKlineLimit
+}
+
+// intervalDuration 解析币安周期字符串（如 1m、4h、1d、1w、1M），无法解析时返回0
+func intervalDuration(interval string) time.Duration {
+	if len(interval) < 2 {
+		return 0
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0
+	}
+	unit := time.Duration(0)
+	switch interval[len(interval)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	case 'M':
+		unit = 30 * 24 * time.Hour
+	}
+	return time.Duration(n) * unit
+}
+
+// finestInterval 返回最短的周期
+func finestInterval(intervals []string) string {
+	finest := ""
+	for _, interval := range intervals {
+		if finest == "" || intervalDuration(interval) < intervalDuration(finest) {
+			finest = interval
+		}
+	}
+	return finest
+}
@@ -0,0 +1,158 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrKlineGap K线序列中有缺失的K线（通常是交易所维护期间），修复情况见警告内容
+var ErrKlineGap = errors.New("K线不连续")
+
+// GapPolicy 发现缺失K线时的处理方式
+type GapPolicy int
+
+const (
+	// GapFillFlat 以前一根K线的收盘价填充平盘K线（开高低收相同、成交量为0），默认方式
+	GapFillFlat GapPolicy = iota
+	// GapRefetch 按缺口的时间范围重新请求，仍缺失的部分以平盘K线填充；数据源需实现KlineRangeSource
+	GapRefetch
+	// GapReportOnly 只校验并报告，不修复
+	GapReportOnly
+)
+
+// KlineGap 一段连续缺失的K线
+type KlineGap struct {
+	From    int64 `json:"from"`    // 第一根缺失K线的开盘时间
+	To      int64 `json:"to"`      // 最后一根缺失K线的开盘时间
+	Missing int   `json:"missing"` // 缺失的K线数量
+}
+
+// ValidateKlines 按周期检查K线开盘时间的间隔，返回所有缺口
+// 开盘时间不递增或未对齐周期时返回错误；月线（1M）长度不固定，不做检查
+func ValidateKlines(klines []Kline, interval string) ([]KlineGap, error) {
+	step := intervalDuration(interval).Milliseconds()
+	if step <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedInterval, interval)
+	}
+	if strings.HasSuffix(interval, "M") {
+		return nil, nil
+	}
+
+	var gaps []KlineGap
+	for i := 1; i < len(klines); i++ {
+		diff := klines[i].OpenTime - klines[i-1].OpenTime
+		switch {
+		case diff <= 0:
+			return gaps, fmt.Errorf("%s K线开盘时间不递增: 第%d根%d, 第%d根%d", interval, i-1, klines[i-1].OpenTime, i, klines[i].OpenTime)
+		case diff%step != 0:
+			return gaps, fmt.Errorf("%s K线开盘时间未对齐周期: 第%d根%d, 第%d根%d", interval, i-1, klines[i-1].OpenTime, i, klines[i].OpenTime)
+		case diff > step:
+			gaps = append(gaps, KlineGap{
+				From:    klines[i-1].OpenTime + step,
+				To:      klines[i].OpenTime - step,
+				Missing: int(diff/step) - 1,
+			})
+		}
+	}
+	return gaps, nil
+}
+
+// FillKlineGaps 在缺口处插入平盘K线（开高低收为前一根的收盘价，成交量为0），返回新的切片，不修改klines
+func FillKlineGaps(klines []Kline, interval string, gaps []KlineGap) []Kline {
+	return mergeGapKlines(klines, interval, gaps, nil)
+}
+
+// mergeGapKlines 按开盘时间把fetched中落在缺口内的K线插入，其余缺失位置以平盘K线填充
+func mergeGapKlines(klines []Kline, interval string, gaps []KlineGap, fetched []Kline) []Kline {
+	if len(gaps) == 0 {
+		return klines
+	}
+	step := intervalDuration(interval).Milliseconds()
+	byTime := make(map[int64]Kline, len(fetched))
+	for _, k := range fetched {
+		byTime[k.OpenTime] = k
+	}
+
+	missing := 0
+	for _, gap := range gaps {
+		missing += gap.Missing
+	}
+	result := make([]Kline, 0, len(klines)+missing)
+	g := 0
+	for _, k := range klines {
+		for g < len(gaps) && gaps[g].To < k.OpenTime {
+			prevClose := result[len(result)-1].Close
+			for t := gaps[g].From; t <= gaps[g].To; t += step {
+				bar, ok := byTime[t]
+				if !ok {
+					bar = Kline{OpenTime: t, Open: prevClose, High: prevClose, Low: prevClose, Close: prevClose, CloseTime: t + step - 1}
+				}
+				result = append(result, bar)
+				prevClose = bar.Close
+			}
+			g++
+		}
+		result = append(result, k)
+	}
+	return result
+}
+
+// repairKlines 按policy校验并修复一个周期的K线，发现缺口或时间错乱时记录警告（不影响分区可用性）
+func (c *Client) repairKlines(ctx context.Context, report *FetchReport, policy GapPolicy, symbol, interval string, klines []Kline) []Kline {
+	gaps, err := ValidateKlines(klines, interval)
+	if err != nil {
+		report.warn(SectionKlines, interval, err)
+		return klines
+	}
+	if len(gaps) == 0 {
+		return klines
+	}
+
+	missing := 0
+	for _, gap := range gaps {
+		missing += gap.Missing
+	}
+	var fetched []Kline
+	action := "未修复"
+	switch policy {
+	case GapFillFlat:
+		action = "以平盘K线填充"
+	case GapRefetch:
+		if src, ok := c.source().(KlineRangeSource); ok {
+			for _, gap := range gaps {
+				bars, err := src.KlinesRange(ctx, symbol, interval, gap.From, gap.To)
+				if err != nil {
+					report.warn(SectionKlines, interval, fmt.Errorf("重新请求缺失K线失败: %w", err))
+					break
+				}
+				fetched = append(fetched, bars...)
+			}
+		}
+		recovered := countRecovered(gaps, fetched)
+		action = fmt.Sprintf("重新请求补回%d根", recovered)
+		if recovered < missing {
+			action += "，其余以平盘K线填充"
+		}
+	}
+	report.warn(SectionKlines, interval, fmt.Errorf("%w: 缺失%d根（%d处），%s", ErrKlineGap, missing, len(gaps), action))
+	if policy == GapReportOnly {
+		return klines
+	}
+	return mergeGapKlines(klines, interval, gaps, fetched)
+}
+
+// countRecovered fetched中落在缺口内的K线数量
+func countRecovered(gaps []KlineGap, fetched []Kline) int {
+	count := 0
+	for _, k := range fetched {
+		for _, gap := range gaps {
+			if k.OpenTime >= gap.From && k.OpenTime <= gap.To {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,175 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gappedKlines 200根3m K线去掉第50~52根与第150根，共缺4根（2处）
+func gappedKlines(end int64) (full, gapped []Kline) {
+	full = fixtureKlines("3m", 200, end)
+	gapped = slices.Concat(full[:50], full[53:150], full[151:])
+	return full, gapped
+}
+
+func TestValidateKlines(t *testing.T) {
+	step := intervalDuration("3m").Milliseconds()
+	full, gapped := gappedKlines(1000 * step)
+
+	if gaps, err := ValidateKlines(full, "3m"); err != nil || len(gaps) != 0 {
+		t.Errorf("contiguous: gaps = %+v, err = %v, want none", gaps, err)
+	}
+	gaps, err := ValidateKlines(gapped, "3m")
+	if err != nil {
+		t.Fatalf("ValidateKlines: %v", err)
+	}
+	want := []KlineGap{
+		{From: full[50].OpenTime, To: full[52].OpenTime, Missing: 3},
+		{From: full[150].OpenTime, To: full[150].OpenTime, Missing: 1},
+	}
+	if !slices.Equal(gaps, want) {
+		t.Errorf("gaps = %+v, want %+v", gaps, want)
+	}
+
+	for name, klines := range map[string][]Kline{
+		"not increasing": {full[1], full[0]},
+		"duplicate":      {full[0], full[0]},
+		"misaligned":     {full[0], {OpenTime: full[1].OpenTime + 1}},
+	} {
+		if _, err := ValidateKlines(klines, "3m"); err == nil {
+			t.Errorf("%s: err = nil, want an error", name)
+		}
+	}
+	if _, err := ValidateKlines(full, "bogus"); !errors.Is(err, ErrUnsupportedInterval) {
+		t.Errorf("unknown interval: err = %v, want ErrUnsupportedInterval", err)
+	}
+}
+
+func TestFillKlineGapsFlat(t *testing.T) {
+	step := intervalDuration("3m").Milliseconds()
+	full, gapped := gappedKlines(1000 * step)
+	gaps, _ := ValidateKlines(gapped, "3m")
+	before := slices.Clone(gapped)
+
+	filled := FillKlineGaps(gapped, "3m", gaps)
+	if !slices.Equal(gapped, before) {
+		t.Error("FillKlineGaps modified its input")
+	}
+	if len(filled) != len(full) {
+		t.Fatalf("len = %d, want %d", len(filled), len(full))
+	}
+	if rest, err := ValidateKlines(filled, "3m"); err != nil || len(rest) != 0 {
+		t.Errorf("filled klines still have gaps %+v (err %v)", rest, err)
+	}
+	for _, i := range []int{50, 51, 52, 150} {
+		prevClose := full[49].Close
+		if i == 150 {
+			prevClose = full[149].Close
+		}
+		want := Kline{OpenTime: full[i].OpenTime, Open: prevClose, High: prevClose, Low: prevClose, Close: prevClose, CloseTime: full[i].CloseTime}
+		if filled[i] != want {
+			t.Errorf("filled[%d] = %+v, want flat %+v", i, filled[i], want)
+		}
+	}
+}
+
+func TestMergeGapKlinesPartialRefetch(t *testing.T) {
+	step := intervalDuration("3m").Milliseconds()
+	full, gapped := gappedKlines(1000 * step)
+	gaps, _ := ValidateKlines(gapped, "3m")
+
+	// 只补回第一处缺口的第2根，另有一根不在缺口内的K线不计入
+	fetched := []Kline{full[51], full[10]}
+	if got := countRecovered(gaps, fetched); got != 1 {
+		t.Errorf("countRecovered = %d, want 1", got)
+	}
+
+	merged := mergeGapKlines(gapped, "3m", gaps, fetched)
+	if len(merged) != len(full) {
+		t.Fatalf("len = %d, want %d", len(merged), len(full))
+	}
+	if merged[51] != full[51] {
+		t.Errorf("merged[51] = %+v, want the refetched bar", merged[51])
+	}
+	// 补回的K线前后仍缺失的位置按各自前一根（含补回的K线）的收盘价填充
+	if merged[50].Close != full[49].Close || merged[50].Volume != 0 {
+		t.Errorf("merged[50] = %+v, want flat at %v", merged[50], full[49].Close)
+	}
+	if merged[52].Close != full[51].Close || merged[52].Volume != 0 {
+		t.Errorf("merged[52] = %+v, want flat at the refetched close %v", merged[52], full[51].Close)
+	}
+}
+
+// refetchSource 预置的K线有缺口，重新请求时从完整的K线中取回；perGap>0时每处缺口最多返回perGap根，模拟交易所只补回部分数据
+type refetchSource struct {
+	*FakeSource
+	full   []Kline
+	perGap int
+}
+
+func (s refetchSource) KlinesRange(ctx context.Context, symbol, interval string, startTime, endTime int64) ([]Kline, error) {
+	var bars []Kline
+	for _, k := range s.full {
+		if k.OpenTime >= startTime && k.OpenTime <= endTime && (s.perGap <= 0 || len(bars) < s.perGap) {
+			bars = append(bars, k)
+		}
+	}
+	return bars, nil
+}
+
+func TestGetRepairsKlineGaps(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	step := intervalDuration("3m").Milliseconds()
+	full, gapped := gappedKlines(now.UnixMilli() / step * step)
+
+	tests := []struct {
+		name       string
+		policy     GapPolicy
+		perGap     int
+		wantAction string
+		wantLen    int
+	}{
+		{"flat", GapFillFlat, 0, "以平盘K线填充", len(full)},
+		{"refetch", GapRefetch, 0, "重新请求补回4根", len(full)},
+		{"partial refetch", GapRefetch, 1, "重新请求补回2根，其余以平盘K线填充", len(full)},
+		{"report only", GapReportOnly, 0, "未修复", len(gapped)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFixtureSource("BTCUSDT", now)
+			fake.SetKlines("BTCUSDT", "3m", gapped)
+			src := refetchSource{FakeSource: fake, full: full, perGap: tt.perGap}
+			c := NewClient(WithSource(src))
+
+			// K线缺口只是警告，WithStrict下仍然成功
+			data, report, err := c.GetPartial(context.Background(), "BTCUSDT",
+				WithIntervals("3m"), WithGapPolicy(tt.policy), WithStrict(), WithRawKlines())
+			if err != nil {
+				t.Fatalf("GetPartial: %v", err)
+			}
+			var gapErr *SectionError
+			found := false
+			for _, w := range report.Warnings {
+				if errors.As(w, &gapErr) && gapErr.Section == SectionKlines && errors.Is(w, ErrKlineGap) {
+					found = true
+					if msg := w.Error(); !strings.Contains(msg, "缺失4根（2处）") || !strings.Contains(msg, tt.wantAction) {
+						t.Errorf("warning = %q, want 缺失4根（2处） and %q", msg, tt.wantAction)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("warnings = %v, want an ErrKlineGap warning", report.Warnings)
+			}
+			if got := len(data.RawKlines["3m"]); got != tt.wantLen {
+				t.Errorf("len(RawKlines[3m]) = %d, want %d", got, tt.wantLen)
+			}
+			if tt.policy == GapRefetch && tt.perGap == 0 && !slices.Equal(data.RawKlines["3m"], full) {
+				t.Error("RawKlines[3m] after a full refetch differ from the original klines")
+			}
+		})
+	}
+}
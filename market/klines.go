@@ -0,0 +1,74 @@
+package market
+
+// TypicalPrice 典型价 (高+低+收)/3
+func (k Kline) TypicalPrice() float64 {
+	return (k.High + k.Low + k.Close) / 3
+}
+
+// Range 振幅：最高−最低
+func (k Kline) Range() float64 {
+	return k.High - k.Low
+}
+
+// Body 实体大小：|收−开|
+func (k Kline) Body() float64 {
+	if k.Close >= k.Open {
+		return k.Close - k.Open
+	}
+	return k.Open - k.Close
+}
+
+// UpperWick 上影线：最高−max(开, 收)
+func (k Kline) UpperWick() float64 {
+	return k.High - max(k.Open, k.Close)
+}
+
+// LowerWick 下影线：min(开, 收)−最低
+func (k Kline) LowerWick() float64 {
+	return min(k.Open, k.Close) - k.Low
+}
+
+// IsBullish 收盘价高于开盘价
+func (k Kline) IsBullish() bool {
+	return k.Close > k.Open
+}
+
+// Klines 按时间升序的K线序列，可直接由[]Kline转换：market.Klines(klines)
+type Klines []Kline
+
+// Closes 收盘价序列，空序列返回长度为0的切片
+func (ks Klines) Closes() []float64 {
+	return ks.field(func(k Kline) float64 { return k.Close })
+}
+
+// Highs 最高价序列
+func (ks Klines) Highs() []float64 {
+	return ks.field(func(k Kline) float64 { return k.High })
+}
+
+// Lows 最低价序列
+func (ks Klines) Lows() []float64 {
+	return ks.field(func(k Kline) float64 { return k.Low })
+}
+
+// Volumes 成交量序列
+func (ks Klines) Volumes() []float64 {
+	return ks.field(func(k Kline) float64 { return k.Volume })
+}
+
+// Last 最后一根K线，序列为空时ok为false
+func (ks Klines) Last() (k Kline, ok bool) {
+	if len(ks) == 0 {
+		return Kline{}, false
+	}
+	return ks[len(ks)-1], true
+}
+
+// field 逐根取出一个字段
+func (ks Klines) field(get func(Kline) float64) []float64 {
+	values := make([]float64, len(ks))
+	for i, k := range ks {
+		values[i] = get(k)
+	}
+	return values
+}
@@ -0,0 +1,89 @@
+package market
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKlinesAccessorsOnEmptySlices(t *testing.T) {
+	for name, ks := range map[string]Klines{"nil": nil, "empty": {}} {
+		t.Run(name, func(t *testing.T) {
+			for field, values := range map[string][]float64{
+				"Closes": ks.Closes(), "Highs": ks.Highs(), "Lows": ks.Lows(), "Volumes": ks.Volumes(),
+			} {
+				// 返回长度为0的非nil切片，可以直接序列化为[]
+				if values == nil || len(values) != 0 {
+					t.Errorf("%s() = %#v, want an empty non-nil slice", field, values)
+				}
+			}
+			if k, ok := ks.Last(); ok || k != (Kline{}) {
+				t.Errorf("Last() = %+v, %v, want zero Kline, false", k, ok)
+			}
+		})
+	}
+
+	var k Kline
+	if k.TypicalPrice() != 0 || k.Range() != 0 || k.Body() != 0 || k.UpperWick() != 0 || k.LowerWick() != 0 || k.IsBullish() {
+		t.Errorf("zero Kline helpers = %v %v %v %v %v %v, want all zero/false",
+			k.TypicalPrice(), k.Range(), k.Body(), k.UpperWick(), k.LowerWick(), k.IsBullish())
+	}
+}
+
+func TestKlinesAccessors(t *testing.T) {
+	ks := Klines{
+		{Open: 10, High: 12, Low: 9, Close: 11, Volume: 100},
+		{Open: 11, High: 11.5, Low: 8, Close: 9, Volume: 200},
+	}
+	if got := ks.Closes(); len(got) != 2 || got[0] != 11 || got[1] != 9 {
+		t.Errorf("Closes() = %v, want [11 9]", got)
+	}
+	if got := ks.Volumes(); len(got) != 2 || got[1] != 200 {
+		t.Errorf("Volumes() = %v, want [100 200]", got)
+	}
+	if last, ok := ks.Last(); !ok || last != ks[1] {
+		t.Errorf("Last() = %+v, %v, want the second kline", last, ok)
+	}
+	bear := ks[1]
+	if bear.Body() != 2 || bear.Range() != 3.5 || bear.UpperWick() != 0.5 || bear.LowerWick() != 1 || bear.IsBullish() {
+		t.Errorf("bearish kline helpers: body %v range %v upper %v lower %v bullish %v",
+			bear.Body(), bear.Range(), bear.UpperWick(), bear.LowerWick(), bear.IsBullish())
+	}
+	if got := bear.TypicalPrice(); got != (11.5+8+9)/3 {
+		t.Errorf("TypicalPrice() = %v, want %v", got, (11.5+8+9)/3)
+	}
+}
+
+func TestAnalysisHelpersOnEmptyKlines(t *testing.T) {
+	for name, klines := range map[string][]Kline{"nil": nil, "empty": {}} {
+		t.Run(name, func(t *testing.T) {
+			byInterval := map[string][]Kline{"1m": klines, "3m": klines, "1h": klines, "4h": klines, "15m": klines}
+
+			if vp, err := BuildVolumeProfile(klines, VolumeAtTypicalPrice); vp != nil || !errors.Is(err, ErrInsufficientData) {
+				t.Errorf("BuildVolumeProfile = %+v, %v, want nil, ErrInsufficientData", vp, err)
+			}
+			if vp := calculateVolumeProfile(byInterval, time.Hour, VolumeAtTypicalPrice); vp != nil {
+				t.Errorf("calculateVolumeProfile = %+v, want nil", vp)
+			}
+			if vp := calculateVolumeProfile(nil, time.Hour, VolumeAtTypicalPrice); vp != nil {
+				t.Errorf("calculateVolumeProfile(nil map) = %+v, want nil", vp)
+			}
+
+			if bars := AggregateKlines(klines, PeriodDay); bars != nil {
+				t.Errorf("AggregateKlines = %+v, want nil", bars)
+			}
+			for _, period := range []time.Duration{PeriodDay, PeriodWeek} {
+				if p := calculatePivots(byInterval, period); p != nil {
+					t.Errorf("calculatePivots(%s) = %+v, want nil", period, p)
+				}
+			}
+
+			if fib, err := CalculateFibRetracement(klines, DefaultSwingATRMultiple); fib != nil || !errors.Is(err, ErrNoSwing) {
+				t.Errorf("CalculateFibRetracement = %+v, %v, want nil, ErrNoSwing", fib, err)
+			}
+			if completed, current := findSwings(klines, 1); completed.direction != 0 || current.direction != 0 {
+				t.Errorf("findSwings = %+v, %+v, want no swings", completed, current)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultStreamIntervals StartKlineStream默认订阅的周期
+var defaultStreamIntervals = []string{"1m", "3m"}
+
+// streamKlineBuffer 每个symbol/周期在内存中保留的K线数量
+const streamKlineBuffer = 500
+
+// KlineStream 通过WebSocket维护的实时K线缓冲
+// 连接正常且补数完成时，Get直接使用缓冲中的K线，不再发起HTTP请求；
+// 断线期间及补数失败的周期自动回退到REST
+type KlineStream struct {
+	client    *Client
+	ctx       context.Context
+	symbols   []string
+	intervals []string
+	done      chan struct{}
+
+	mu      sync.RWMutex
+	buffers map[string][]Kline // key为 symbol|interval
+	ready   map[string]bool
+}
+
+// StartKlineStream 使用默认客户端开启实时K线订阅，见Client.StartKlineStream
+func StartKlineStream(ctx context.Context, symbols []string, intervals ...string) (*KlineStream, error) {
+	return defaultClient.StartKlineStream(ctx, symbols, intervals...)
+}
+
+// StartKlineStream 订阅symbols在各周期（默认1m、3m）的K线推送，并在后台保持连接直到ctx结束
+// 每次连接（含重连）后先通过REST拉取完整K线补齐断线期间的缺口，之后按推送替换已收盘K线、更新进行中的K线
+func (c *Client) StartKlineStream(ctx context.Context, symbols []string, intervals ...string) (*KlineStream, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("至少需要订阅一个symbol")
+	}
+	if len(intervals) == 0 {
+		intervals = defaultStreamIntervals
+	}
+	if err := validateIntervals(intervals); err != nil {
+		return nil, err
+	}
+
+	s := &KlineStream{
+		client:    c,
+		ctx:       ctx,
+		intervals: append([]string(nil), intervals...),
+		done:      make(chan struct{}),
+		buffers:   make(map[string][]Kline),
+		ready:     make(map[string]bool),
+	}
+	var streams []string
+	for _, symbol := range symbols {
+		symbol = c.normalizeSymbol(symbol)
+		s.symbols = append(s.symbols, symbol)
+		for _, interval := range s.intervals {
+			streams = append(streams, fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval))
+		}
+	}
+
+	cfg := c.config()
+	conn := &streamConn{
+		url:     cfg.wsBaseURL(),
+		streams: streams,
+		logger:  cfg.logger,
+		dialer:  cfg.wsDialer(),
+		onConnect: func(ctx context.Context, reconnect bool) {
+			s.repair(ctx)
+		},
+		onDisconnect: func(error) {
+			s.markStale()
+		},
+		onMessage: s.handle,
+	}
+
+	c.addKlineStream(s)
+	go func() {
+		defer close(s.done)
+		defer c.removeKlineStream(s)
+		conn.run(ctx)
+	}()
+	return s, nil
+}
+
+// Done 订阅结束（ctx结束且连接已关闭）后关闭
+func (s *KlineStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Ready 判断symbol/周期的实时缓冲是否可用
+func (s *KlineStream) Ready(symbol, interval string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready[streamKey(s.client.normalizeSymbol(symbol), interval)]
+}
+
+// Klines 返回symbol/周期实时缓冲的副本，缓冲不可用时返回false
+func (s *KlineStream) Klines(symbol, interval string) ([]Kline, bool) {
+	return s.klines(s.client.normalizeSymbol(symbol), interval, 0)
+}
+
+// klines 返回最近limit根K线的副本，limit<=0表示全部；缓冲不可用或数量不足时返回false
+func (s *KlineStream) klines(symbol, interval string, limit int) ([]Kline, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := streamKey(symbol, interval)
+	if !s.ready[key] {
+		return nil, false
+	}
+	buf := s.buffers[key]
+	if limit <= 0 {
+		limit = len(buf)
+	}
+	if len(buf) < limit || limit == 0 {
+		return nil, false
+	}
+	return append([]Kline(nil), buf[len(buf)-limit:]...), true
+}
+
+// repair 通过REST重新拉取全部缓冲，补齐断线期间的缺口
+func (s *KlineStream) repair(ctx context.Context) {
+	for _, symbol := range s.symbols {
+		for _, interval := range s.intervals {
+			s.repairKey(ctx, symbol, interval)
+		}
+	}
+}
+
+// repairKey 通过REST重新拉取单个缓冲，失败时该缓冲保持不可用，由Get回退到REST
+func (s *KlineStream) repairKey(ctx context.Context, symbol, interval string) {
+	klines, err := s.client.source().Klines(ctx, symbol, interval, streamKlineBuffer)
+	key := streamKey(symbol, interval)
+	if err != nil {
+		s.client.config().logger.Warnf("实时K线补数失败 symbol=%s interval=%s err=%v", symbol, interval, err)
+		s.mu.Lock()
+		s.ready[key] = false
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Lock()
+	s.buffers[key] = klines
+	s.ready[key] = len(klines) > 0
+	s.mu.Unlock()
+}
+
+// markStale 连接断开后所有缓冲标记为不可用
+func (s *KlineStream) markStale() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.ready {
+		s.ready[key] = false
+	}
+}
+
+// wsKlineEvent K线推送
+type wsKlineEvent struct {
+	Symbol string `json:"s"`
+	Kline  struct {
+		OpenTime  int64  `json:"t"`
+		CloseTime int64  `json:"T"`
+		Interval  string `json:"i"`
+		Open      string `json:"o"`
+		Close     string `json:"c"`
+		High      string `json:"h"`
+		Low       string `json:"l"`
+		Volume    string `json:"v"`
+		// BaseVolume 币本位推送中的基础资产成交量（U本位推送中该字段为计价资产成交额，不使用）
+		BaseVolume string `json:"q"`
+	} `json:"k"`
+}
+
+// handle 合并一条K线推送：同一开盘时间替换，更新的开盘时间追加，出现缺口时重新补数
+func (s *KlineStream) handle(stream string, data json.RawMessage) {
+	var event wsKlineEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		s.client.config().logger.Debugf("无法解析的K线推送 stream=%s err=%v", stream, err)
+		return
+	}
+	open, _ := parseFloat(event.Kline.Open)
+	high, _ := parseFloat(event.Kline.High)
+	low, _ := parseFloat(event.Kline.Low)
+	close, _ := parseFloat(event.Kline.Close)
+	volume, _ := parseFloat(event.Kline.Volume)
+	if s.client.config().market == COINM {
+		volume, _ = parseFloat(event.Kline.BaseVolume)
+	}
+	k := Kline{
+		OpenTime:  event.Kline.OpenTime,
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     close,
+		Volume:    volume,
+		CloseTime: event.Kline.CloseTime,
+	}
+	symbol, interval := event.Symbol, event.Kline.Interval
+	key := streamKey(symbol, interval)
+
+	s.mu.Lock()
+	if !s.ready[key] {
+		s.mu.Unlock()
+		return
+	}
+	buf := s.buffers[key]
+	last := buf[len(buf)-1]
+	step := intervalDuration(interval).Milliseconds()
+	switch {
+	case k.OpenTime == last.OpenTime:
+		buf[len(buf)-1] = k
+	case k.OpenTime < last.OpenTime:
+		// 迟到的旧K线，忽略
+	case k.OpenTime-last.OpenTime > step:
+		// 漏掉了中间的K线，标记不可用并重新补数
+		s.ready[key] = false
+		s.mu.Unlock()
+		s.client.config().logger.Warnf("实时K线出现缺口，重新补数 symbol=%s interval=%s", symbol, interval)
+		go s.repairKey(s.ctx, symbol, interval)
+		return
+	default:
+		buf = append(buf, k)
+		if len(buf) > streamKlineBuffer {
+			buf = append([]Kline(nil), buf[len(buf)-streamKlineBuffer:]...)
+		}
+		s.buffers[key] = buf
+	}
+	s.mu.Unlock()
+}
+
+func streamKey(symbol, interval string) string {
+	return symbol + "|" + interval
+}
+
+// addKlineStream 登记实时K线订阅，供Get查询
+func (c *Client) addKlineStream(s *KlineStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.klineStreams = append(c.klineStreams, s)
+}
+
+// removeKlineStream 注销实时K线订阅
+func (c *Client) removeKlineStream(s *KlineStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.klineStreams = removeStream(c.klineStreams, s)
+}
+
+// liveKlines 从实时订阅中取最近limit根K线
+func (c *Client) liveKlines(symbol, interval string, limit int) ([]Kline, bool) {
+	c.streamMu.RLock()
+	defer c.streamMu.RUnlock()
+	for _, s := range c.klineStreams {
+		if klines, ok := s.klines(symbol, interval, limit); ok {
+			return klines, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,293 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxLiquidationWindow 强平累加器保留的最长窗口
+const MaxLiquidationWindow = time.Hour
+
+// LiquidationStream 通过WebSocket（<symbol>@forceOrder）记录最近一小时的强平订单
+// 币安已不再公开提供强平历史的REST接口，只能从订阅开始累积；
+// 该推送每个symbol每秒最多一条（同一秒内只推送最后一笔），统计值是下限
+type LiquidationStream struct {
+	client *Client
+	done   chan struct{}
+
+	symbols map[string]bool // 订阅的symbol（标准化后）
+
+	mu     sync.RWMutex
+	since  time.Time // 本次连接的开始时间，断线后重置
+	events map[string][]Liquidation
+}
+
+// Liquidation 一笔强平订单
+type Liquidation struct {
+	Side      string  `json:"side"`     // 被强平的仓位方向："long"（强平卖单）或 "short"（强平买单）
+	Price     float64 `json:"price"`    // 成交均价
+	Quantity  float64 `json:"quantity"` // 基础资产数量
+	Notional  float64 `json:"notional"` // 计价资产金额
+	Timestamp int64   `json:"timestamp_ms"`
+}
+
+// 强平方向
+const (
+	LiquidationLong  = "long"
+	LiquidationShort = "short"
+)
+
+// LiquidationStats 一个窗口内的强平统计
+type LiquidationStats struct {
+	Count         int          `json:"count"`
+	Notional      float64      `json:"notional"`
+	LongCount     int          `json:"long_count"`
+	LongNotional  float64      `json:"long_notional"` // 多头被强平的金额
+	ShortCount    int          `json:"short_count"`
+	ShortNotional float64      `json:"short_notional"`    // 空头被强平的金额
+	Largest       *Liquidation `json:"largest,omitempty"` // 窗口内金额最大的一笔，没有强平时为nil
+}
+
+// LiquidationData 最近5m/15m/1h的强平统计，来自LiquidationStream
+// 连接时长不足某个窗口时该窗口为nil，避免把未覆盖的时间当作没有强平
+type LiquidationData struct {
+	Last5m  *LiquidationStats `json:"last_5m,omitempty"`
+	Last15m *LiquidationStats `json:"last_15m,omitempty"`
+	Last1h  *LiquidationStats `json:"last_1h,omitempty"`
+	SinceMs int64             `json:"since_ms"` // 本次连续接收的开始时间
+}
+
+// StartLiquidationStream 使用默认客户端开启强平订阅，见Client.StartLiquidationStream
+func StartLiquidationStream(ctx context.Context, symbols []string) (*LiquidationStream, error) {
+	return defaultClient.StartLiquidationStream(ctx, symbols)
+}
+
+// StartLiquidationStream 订阅symbols的强平订单，并在后台保持连接直到ctx结束；现货市场没有强平，返回错误
+func (c *Client) StartLiquidationStream(ctx context.Context, symbols []string) (*LiquidationStream, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("至少需要订阅一个symbol")
+	}
+	cfg := c.config()
+	if !cfg.market.hasDerivatives() {
+		return nil, fmt.Errorf("%s市场没有强平订单", cfg.market)
+	}
+
+	s := &LiquidationStream{
+		client:  c,
+		done:    make(chan struct{}),
+		symbols: make(map[string]bool, len(symbols)),
+		events:  make(map[string][]Liquidation),
+	}
+	var streams []string
+	for _, symbol := range symbols {
+		symbol = c.normalizeSymbol(symbol)
+		s.symbols[symbol] = true
+		streams = append(streams, strings.ToLower(symbol)+"@forceOrder")
+	}
+
+	conn := &streamConn{
+		url:     cfg.wsBaseURL(),
+		streams: streams,
+		logger:  cfg.logger,
+		dialer:  cfg.wsDialer(),
+		onConnect: func(context.Context, bool) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.since = time.Now()
+		},
+		onDisconnect: func(error) {
+			s.reset()
+		},
+		onMessage: s.handle,
+	}
+
+	c.addLiquidationStream(s)
+	go func() {
+		defer close(s.done)
+		defer c.removeLiquidationStream(s)
+		conn.run(ctx)
+	}()
+	return s, nil
+}
+
+// Done 订阅结束（ctx结束且连接已关闭）后关闭
+func (s *LiquidationStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Stats 统计symbol最近window内的强平，window超过MaxLiquidationWindow或连接时长不足window时返回false
+func (s *LiquidationStream) Stats(symbol string, window time.Duration) (*LiquidationStats, bool) {
+	return s.statsAt(s.client.normalizeSymbol(symbol), window, time.Now())
+}
+
+// statsAt 以now为窗口终点统计强平
+func (s *LiquidationStream) statsAt(symbol string, window time.Duration, now time.Time) (*LiquidationStats, bool) {
+	if window <= 0 || window > MaxLiquidationWindow {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.since.IsZero() || now.Sub(s.since) < window {
+		return nil, false
+	}
+
+	start := now.Add(-window).UnixMilli()
+	stats := &LiquidationStats{}
+	for i := range s.events[symbol] {
+		event := &s.events[symbol][i]
+		if event.Timestamp <= start {
+			continue
+		}
+		stats.Count++
+		stats.Notional += event.Notional
+		if event.Side == LiquidationLong {
+			stats.LongCount++
+			stats.LongNotional += event.Notional
+		} else {
+			stats.ShortCount++
+			stats.ShortNotional += event.Notional
+		}
+		if stats.Largest == nil || event.Notional > stats.Largest.Notional {
+			largest := *event
+			stats.Largest = &largest
+		}
+	}
+	return stats, true
+}
+
+// Data 汇总symbol最近5m/15m/1h的强平，未订阅该symbol或连接尚未建立时返回nil
+func (s *LiquidationStream) Data(symbol string) *LiquidationData {
+	symbol = s.client.normalizeSymbol(symbol)
+	if !s.symbols[symbol] {
+		return nil
+	}
+	now := time.Now()
+	s.mu.RLock()
+	since := s.since
+	s.mu.RUnlock()
+	if since.IsZero() {
+		return nil
+	}
+	data := &LiquidationData{SinceMs: since.UnixMilli()}
+	data.Last5m, _ = s.statsAt(symbol, 5*time.Minute, now)
+	data.Last15m, _ = s.statsAt(symbol, 15*time.Minute, now)
+	data.Last1h, _ = s.statsAt(symbol, time.Hour, now)
+	return data
+}
+
+// add 记录一笔强平，并丢弃早于MaxLiquidationWindow的记录
+func (s *LiquidationStream) add(symbol string, event Liquidation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events[symbol]
+	cutoff := event.Timestamp - MaxLiquidationWindow.Milliseconds()
+	drop := 0
+	for drop < len(events) && events[drop].Timestamp <= cutoff {
+		drop++
+	}
+	s.events[symbol] = append(events[drop:], event)
+}
+
+// reset 断线后丢弃全部记录，断线期间的强平无法补齐
+func (s *LiquidationStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Time{}
+	s.events = make(map[string][]Liquidation)
+}
+
+// wsForceOrderEvent 强平订单推送
+type wsForceOrderEvent struct {
+	Order struct {
+		Symbol       string `json:"s"`
+		Side         string `json:"S"`
+		Price        string `json:"p"`
+		Quantity     string `json:"q"`
+		AveragePrice string `json:"ap"`
+		FilledQty    string `json:"z"`
+		TradeTime    int64  `json:"T"`
+	} `json:"o"`
+}
+
+// handle 解析强平推送：SELL为多头仓位被强平，BUY为空头；优先使用成交均价与累计成交量
+func (s *LiquidationStream) handle(stream string, data json.RawMessage) {
+	var event wsForceOrderEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		s.client.config().logger.Debugf("无法解析的强平推送 stream=%s err=%v", stream, err)
+		return
+	}
+	order := event.Order
+	price, _ := parseFloat(order.AveragePrice)
+	if price <= 0 {
+		price, _ = parseFloat(order.Price)
+	}
+	qty, _ := parseFloat(order.FilledQty)
+	if qty <= 0 {
+		qty, _ = parseFloat(order.Quantity)
+	}
+	if s.client.config().market == COINM {
+		qty = contractsToBase(order.Symbol, qty, price)
+	}
+	side := LiquidationShort
+	if order.Side == "SELL" {
+		side = LiquidationLong
+	}
+	s.add(order.Symbol, Liquidation{
+		Side:      side,
+		Price:     price,
+		Quantity:  qty,
+		Notional:  price * qty,
+		Timestamp: order.TradeTime,
+	})
+}
+
+// addLiquidationStream 登记强平订阅，供Get查询
+func (c *Client) addLiquidationStream(s *LiquidationStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.liquidationStreams = append(c.liquidationStreams, s)
+}
+
+// removeLiquidationStream 注销强平订阅
+func (c *Client) removeLiquidationStream(s *LiquidationStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.liquidationStreams = removeStream(c.liquidationStreams, s)
+}
+
+// liveLiquidations 从强平订阅中取symbol的统计，没有已连接的订阅时返回nil
+func (c *Client) liveLiquidations(symbol string) *LiquidationData {
+	c.streamMu.RLock()
+	defer c.streamMu.RUnlock()
+	for _, s := range c.liquidationStreams {
+		if data := s.Data(symbol); data != nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// formatLiquidations Format中的一行强平统计，未覆盖的窗口显示n/a
+func formatLiquidations(data *LiquidationData) string {
+	windows := []struct {
+		label string
+		stats *LiquidationStats
+	}{{"5m", data.Last5m}, {"15m", data.Last15m}, {"1h", data.Last1h}}
+	parts := make([]string, 0, len(windows))
+	for _, w := range windows {
+		if w.stats == nil {
+			parts = append(parts, w.label+" n/a")
+			continue
+		}
+		part := fmt.Sprintf("%s %d orders, longs %.0f / shorts %.0f", w.label, w.stats.Count, w.stats.LongNotional, w.stats.ShortNotional)
+		if w.stats.Largest != nil {
+			part += fmt.Sprintf(", largest %.0f (%s)", w.stats.Largest.Notional, w.stats.Largest.Side)
+		}
+		parts = append(parts, part)
+	}
+	return "Liquidations (notional): " + strings.Join(parts, " | ") + "\n\n"
+}
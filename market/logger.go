@@ -0,0 +1,84 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Logger 诊断日志接口，用于记录被容忍的分区失败、重试、限频与慢请求
+// 实现需要并发安全
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// defaultSlowRequestThreshold 单次请求耗时超过该值时记录警告
+const defaultSlowRequestThreshold = 3 * time.Second
+
+// nopLogger 默认的空日志
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// WithLogger 设置诊断日志，nil表示不输出日志
+func WithLogger(l Logger) ClientOption {
+	return func(cfg *clientConfig) {
+		if l == nil {
+			l = nopLogger{}
+		}
+		cfg.logger = l
+	}
+}
+
+// SetLogger 设置默认客户端的诊断日志，传nil关闭
+func SetLogger(l Logger) {
+	defaultClient.apply(WithLogger(l))
+}
+
+// WithSlowRequestThreshold 单次请求耗时超过d时输出警告日志，d<=0表示不检查
+func WithSlowRequestThreshold(d time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		if d < 0 {
+			d = 0
+		}
+		cfg.slowRequest = d
+	}
+}
+
+// slogLogger 将Logger适配到log/slog
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger 基于*slog.Logger创建Logger，nil表示slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) Debugf(format string, args ...interface{}) {
+	s.log(slog.LevelDebug, format, args...)
+}
+
+func (s slogLogger) Warnf(format string, args ...interface{}) {
+	s.log(slog.LevelWarn, format, args...)
+}
+
+func (s slogLogger) Errorf(format string, args ...interface{}) {
+	s.log(slog.LevelError, format, args...)
+}
+
+func (s slogLogger) log(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+	s.l.Log(ctx, level, fmt.Sprintf(format, args...), "component", "market")
+}
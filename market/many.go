@@ -0,0 +1,114 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MultiError GetMany中各symbol的失败原因，key为标准化后的symbol
+type MultiError map[string]error
+
+func (e MultiError) Error() string {
+	symbols := make([]string, 0, len(e))
+	for symbol := range e {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	parts := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		parts = append(parts, fmt.Sprintf("%s: %v", symbol, e[symbol]))
+	}
+	return fmt.Sprintf("%d个币种获取失败: %s", len(e), strings.Join(parts, "; "))
+}
+
+// GetMany 使用默认客户端并发获取多个币种的市场数据
+func GetMany(ctx context.Context, symbols []string, opts ...GetOption) (map[string]*Data, error) {
+	return defaultClient.GetMany(ctx, symbols, opts...)
+}
+
+// GetMany 并发获取多个币种的市场数据（并发数见WithConcurrency）
+// 返回成功币种的数据（key为标准化后的symbol）；有币种失败时同时返回MultiError，成功的部分依然可用
+func (c *Client) GetMany(ctx context.Context, symbols []string, opts ...GetOption) (map[string]*Data, error) {
+	o := c.newGetOptions(opts)
+	if err := validateIntervals(o.intervals); err != nil {
+		return nil, err
+	}
+
+	// 去重，避免同一币种占用多个worker
+	seen := make(map[string]bool, len(symbols))
+	queue := make(chan string, len(symbols))
+	for _, symbol := range symbols {
+		// 无效的symbol原样交给Get，由其返回错误并记录在MultiError中
+		if normalized, err := o.normalize(symbol); err == nil {
+			symbol = normalized
+		}
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		queue <- symbol
+	}
+	close(queue)
+
+	// 先拉取一次基准（BTC、ETH）的K线写入缓存，各worker计算相关性与相对强弱时共用；
+	// 不走缓存（WithoutCache或关闭了1h缓存）时预取没有用处，各worker自行拉取
+	var bases []string
+	prefetch := !o.bypassCache && c.config().cacheTTL(benchmarkInterval) > 0
+	if o.btcCorrelation && prefetch {
+		bases = append(bases, benchmarkBase)
+	}
+	if o.relativeStrength && prefetch {
+		bases = append(bases, relativeStrengthBases...)
+	}
+	prefetched := make(map[string]bool, len(bases))
+	for _, base := range bases {
+		if benchmark, err := o.normalize(base); err == nil && !prefetched[benchmark] {
+			prefetched[benchmark] = true
+			_, _ = c.cachedKlines(ctx, o, benchmark, benchmarkInterval, o.klineLimit(benchmarkInterval))
+		}
+	}
+
+	// WithTicker24h且币种较多时一次请求全部交易对的ticker写入缓存，代替各worker逐个请求
+	if o.ticker && !o.bypassCache && c.config().cacheTTL("1m") > 0 && len(seen) > tickerBatchMinSymbols {
+		c.prefetchTickers(ctx, seen)
+	}
+
+	workers := o.concurrency
+	if workers > len(seen) {
+		workers = len(seen)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]*Data, len(seen))
+		errs    = make(MultiError)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range queue {
+				data, err := c.Get(ctx, symbol, opts...)
+
+				mu.Lock()
+				if err != nil {
+					errs[symbol] = err
+				} else {
+					results[symbol] = data
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
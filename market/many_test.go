@@ -0,0 +1,67 @@
+package market
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// klineCountingSource 按symbol与周期记录Klines的请求次数
+type klineCountingSource struct {
+	*FakeSource
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (s *klineCountingSource) Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	s.mu.Lock()
+	s.calls[fakeKey(symbol, interval)]++
+	s.mu.Unlock()
+	return s.FakeSource.Klines(ctx, symbol, interval, limit)
+}
+
+func TestGetManyBenchmarkPrefetch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	newSource := func() *klineCountingSource {
+		src := newFixtureSource("ETHUSDT", now)
+		for _, symbol := range []string{"SOLUSDT", "BTCUSDT"} {
+			for _, interval := range []string{"3m", "1h"} {
+				step := intervalDuration(interval).Milliseconds()
+				src.SetKlines(symbol, interval, fixtureKlines(interval, 500, now.UnixMilli()/step*step))
+			}
+		}
+		return &klineCountingSource{FakeSource: src, calls: make(map[string]int)}
+	}
+	symbols := []string{"ETHUSDT", "SOLUSDT"}
+	opts := []GetOption{WithIntervals("3m"), WithBTCCorrelation(), WithoutOpenInterest(), WithoutFunding(), WithoutMicrostructure()}
+
+	tests := []struct {
+		name       string
+		clientOpts []ClientOption
+		getOpts    []GetOption
+		want       int // BTCUSDT 1h K线的请求次数
+	}{
+		// 预取写入缓存，两个worker共用
+		{"cached", nil, nil, 1},
+		// 不走缓存时不预取，每个worker各请求一次
+		{"client cache disabled", []ClientOption{WithoutClientCache()}, nil, 2},
+		{"WithoutCache", nil, []GetOption{WithoutCache()}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newSource()
+			c := NewClient(append([]ClientOption{WithSource(src)}, tt.clientOpts...)...)
+			results, err := c.GetMany(context.Background(), symbols, append(opts, tt.getOpts...)...)
+			if err != nil {
+				t.Fatalf("GetMany: %v", err)
+			}
+			if len(results) != 2 || results["ETHUSDT"].BetaBTC == nil {
+				t.Errorf("results = %v, want both symbols with a BTC beta", results)
+			}
+			if got := src.calls[fakeKey("BTCUSDT", "1h")]; got != tt.want {
+				t.Errorf("BTCUSDT 1h requests = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
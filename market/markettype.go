@@ -0,0 +1,158 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarketType 行情所属的市场
+type MarketType string
+
+const (
+	// USDTM U本位合约（fapi.binance.com），默认市场
+	USDTM MarketType = "usdt-m"
+	// COINM 币本位合约（dapi.binance.com），symbol形如 BTCUSD_PERP
+	COINM MarketType = "coin-m"
+	// SPOT 现货（api.binance.com），没有持仓量与资金费率，Get自动跳过这两个分区
+	SPOT MarketType = "spot"
+)
+
+// 币本位合约默认地址
+const (
+	DefaultCoinMBaseURL   = "https://dapi.binance.com"
+	DefaultCoinMStreamURL = "wss://dstream.binance.com"
+)
+
+// 现货默认地址
+const (
+	DefaultSpotBaseURL   = "https://api.binance.com"
+	DefaultSpotStreamURL = "wss://stream.binance.com:9443"
+)
+
+// marketSpec 市场的默认地址与接口路径前缀
+type marketSpec struct {
+	baseURL   string
+	streamURL string
+	apiPrefix string
+}
+
+var marketSpecs = map[MarketType]marketSpec{
+	USDTM: {baseURL: DefaultBaseURL, streamURL: DefaultStreamURL, apiPrefix: "/fapi/v1"},
+	COINM: {baseURL: DefaultCoinMBaseURL, streamURL: DefaultCoinMStreamURL, apiPrefix: "/dapi/v1"},
+	SPOT:  {baseURL: DefaultSpotBaseURL, streamURL: DefaultSpotStreamURL, apiPrefix: "/api/v3"},
+}
+
+// hasDerivatives 市场是否有持仓量与资金费率
+func (m MarketType) hasDerivatives() bool {
+	return m != SPOT
+}
+
+// WithMarketType 选择市场（默认USDTM），未通过WithBaseURL/WithStreamURL指定地址时使用该市场的默认地址
+// 币本位合约的成交量、成交明细、盘口与OI原始单位为合约张数，客户端统一换算为基础资产数量（如BTC），
+// 与U本位合约的口径一致
+func WithMarketType(m MarketType) ClientOption {
+	return func(cfg *clientConfig) {
+		if _, ok := marketSpecs[m]; !ok {
+			m = USDTM
+		}
+		cfg.market = m
+	}
+}
+
+// spec 返回市场配置，未知市场按USDTM处理
+func (m MarketType) spec() marketSpec {
+	if spec, ok := marketSpecs[m]; ok {
+		return spec
+	}
+	return marketSpecs[USDTM]
+}
+
+// restBaseURL REST根地址，未指定时使用市场默认地址
+func (cfg clientConfig) restBaseURL() string {
+	if cfg.baseURL != "" {
+		return cfg.baseURL
+	}
+	return cfg.market.spec().baseURL
+}
+
+// wsBaseURL WebSocket根地址，未指定时使用市场默认地址
+func (cfg clientConfig) wsBaseURL() string {
+	if cfg.streamURL != "" {
+		return cfg.streamURL
+	}
+	return cfg.market.spec().streamURL
+}
+
+// NormalizeCoinM 将symbol标准化为币本位合约symbol
+// BTC、BTCUSD 补全为永续合约 BTCUSD_PERP；已带合约后缀（_PERP 或交割日期）的原样返回
+func NormalizeCoinM(symbol string) (string, error) {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	s = strings.NewReplacer("/", "", "-", "").Replace(s)
+	if s == "" || !isSymbolText(s) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSymbol, symbol)
+	}
+	if strings.Contains(s, "_") {
+		return s, nil
+	}
+	for _, known := range KnownQuotes {
+		if hasQuoteSuffix(s, known) {
+			return "", fmt.Errorf("%w: %q 不是币本位合约", ErrInvalidSymbol, symbol)
+		}
+	}
+	if !hasQuoteSuffix(s, "USD") {
+		s += "USD"
+	}
+	return s + "_PERP", nil
+}
+
+// coinMPair 币本位合约symbol对应的标的交易对与合约类型，用于 /futures/data 接口
+// 永续合约为PERPETUAL，交割合约无法从symbol区分当季/次季，使用ALL
+func coinMPair(symbol string) (pair, contractType string) {
+	pair, suffix, _ := strings.Cut(symbol, "_")
+	if suffix == "PERP" {
+		return pair, "PERPETUAL"
+	}
+	return pair, "ALL"
+}
+
+// coinMContractSize 币本位合约每张合约的面值（美元）：BTC为100，其余为10
+func coinMContractSize(symbol string) float64 {
+	if strings.HasPrefix(symbol, "BTCUSD") {
+		return 100
+	}
+	return 10
+}
+
+// contractsToBase 将合约张数按价格换算为基础资产数量
+func contractsToBase(symbol string, contracts, price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	return contracts * coinMContractSize(symbol) / price
+}
+
+// contractValue 把持仓量按价格换算为美元名义价值
+type contractValue func(quantity, price float64) float64
+
+// baseValue 数量以基础资产计时，名义价值为 数量×价格
+func baseValue(quantity, price float64) float64 {
+	return quantity * price
+}
+
+// coinMContractValue 币本位合约张数的名义价值为 张数×面值，与价格无关
+func coinMContractValue(symbol string) contractValue {
+	size := coinMContractSize(symbol)
+	return func(contracts, _ float64) float64 {
+		return contracts * size
+	}
+}
+
+// normalizeSymbol 按客户端所选市场标准化symbol，无效输入只转为大写（由接口返回错误）
+func (c *Client) normalizeSymbol(symbol string) string {
+	o := getOptions{market: c.config().market}
+	normalized, err := o.normalize(symbol)
+	if err != nil {
+		return strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	return normalized
+}
@@ -0,0 +1,79 @@
+package market
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+// newCoinMClient 指向录制的dapi响应的币本位客户端
+func newCoinMClient(t *testing.T, requests *[]string) *Client {
+	srv := newTestServer(t, recordedHandler(t, "testdata/dapi", requests, "/dapi/v1/", "/futures/data/"))
+	return newRESTClient(srv, WithMarketType(COINM))
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestCoinMRecordedResponses(t *testing.T) {
+	var requests []string
+	c := newCoinMClient(t, &requests)
+	ctx := context.Background()
+	const symbol = "BTCUSD_PERP"
+
+	klines, err := c.getKlines(ctx, symbol, "3m", 3)
+	if err != nil {
+		t.Fatalf("klines: %v", err)
+	}
+	// 币本位第6列为合约张数，成交量取第8列的基础资产数量
+	if len(klines) != 3 || klines[0].Volume != 4.10771553 || klines[2].Close != 37044.8 {
+		t.Errorf("klines = %+v, want 3 bars with base-asset volume", klines)
+	}
+
+	oi, err := c.getLatestOpenInterest(ctx, symbol)
+	if err != nil || oi.Value != 4035678 {
+		t.Errorf("openInterest = %+v, %v, want 4035678 contracts", oi, err)
+	}
+
+	history, err := c.getOpenInterestHistory(ctx, symbol, "5m", 2)
+	if err != nil || len(history) != 2 || history[1].Value != 10894.2711234 {
+		t.Errorf("openInterestHist = %+v, %v, want sumOpenInterestValue in BTC", history, err)
+	}
+
+	premium, err := c.getPremiumIndex(ctx, symbol)
+	if err != nil || premium.MarkPrice != 37046.1 || premium.LastFundingRate != 0.00012345 {
+		t.Errorf("premiumIndex = %+v, %v, want the BTCUSD_PERP entry", premium, err)
+	}
+
+	funding, err := c.getFundingRateHistory(ctx, symbol, 2)
+	if err != nil || len(funding) != 2 || funding[1].Rate != 0.00008213 {
+		t.Errorf("fundingRate = %+v, %v", funding, err)
+	}
+
+	// 100美元面值：10张 × 100 / 40000 = 0.025 BTC
+	trades, err := c.getAggTrades(ctx, symbol, 1700000400000)
+	if err != nil || len(trades) != 2 || !approxEqual(trades[0].Quantity, 0.025) || !approxEqual(trades[1].Quantity, 0.01) {
+		t.Errorf("aggTrades = %+v, %v, want quantities converted to BTC", trades, err)
+	}
+
+	book, err := c.getOrderBook(ctx, symbol, 10)
+	if err != nil || len(book.Bids) != 2 || !approxEqual(book.Bids[0][1], 0.5) || !approxEqual(book.Asks[0][1], 80*100/40000.1) {
+		t.Errorf("depth = %+v, %v, want quantities converted to BTC", book, err)
+	}
+
+	ticker, err := c.Ticker24h(ctx, "btc")
+	if err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if ticker.Symbol != symbol || ticker.Volume != 22067.3 || ticker.QuoteVolume != 812345600 {
+		t.Errorf("ticker = %+v, want base volume and contracts × $100 quote volume", ticker)
+	}
+
+	for _, req := range requests {
+		if strings.HasPrefix(req, "/futures/data/openInterestHist") && !strings.Contains(req, "pair=BTCUSD&contractType=PERPETUAL") {
+			t.Errorf("openInterestHist request %s, want pair and contractType", req)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package market
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Metrics 请求埋点接口，每次HTTP请求（含重试的每一次尝试）完成后调用一次
+// endpoint为接口路径（如 /fapi/v1/klines，不含根地址与查询参数），status为HTTP状态码，网络层错误时为0
+// 实现需要并发安全，且不应阻塞
+type Metrics interface {
+	ObserveRequest(endpoint string, status int, dur time.Duration)
+}
+
+// WithMetrics 设置请求埋点，nil表示不埋点（不产生任何额外开销）
+func WithMetrics(m Metrics) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.metrics = m
+	}
+}
+
+// SetMetrics 设置默认客户端的请求埋点，传nil关闭
+func SetMetrics(m Metrics) {
+	defaultClient.apply(WithMetrics(m))
+}
+
+// StatusClass 将HTTP状态码归类为指标标签：2xx、3xx、4xx、5xx，限频单独归为429与418，网络层错误为error
+func StatusClass(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "429"
+	case status == http.StatusTeapot:
+		return "418"
+	case status >= 200 && status < 300:
+		return "2xx"
+	case status >= 300 && status < 400:
+		return "3xx"
+	case status >= 400 && status < 500:
+		return "4xx"
+	case status >= 500 && status < 600:
+		return "5xx"
+	default:
+		return "error"
+	}
+}
+
+// PrometheusMetrics 面向Prometheus的Metrics适配器，按endpoint与状态类别（StatusClass）打标签
+// 为避免本包依赖prometheus客户端，计数与耗时通过回调写入调用方注册的向量，例如：
+//
+//	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "binance_requests_total"}, []string{"endpoint", "status"})
+//	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "binance_request_seconds"}, []string{"endpoint", "status"})
+//	market.SetMetrics(market.PrometheusMetrics{
+//		Requests: func(endpoint, status string) { requests.WithLabelValues(endpoint, status).Inc() },
+//		Latency:  func(endpoint, status string, seconds float64) { latency.WithLabelValues(endpoint, status).Observe(seconds) },
+//	})
+type PrometheusMetrics struct {
+	Requests func(endpoint, status string)                  // 请求计数，可为nil
+	Latency  func(endpoint, status string, seconds float64) // 请求耗时（秒），可为nil
+}
+
+// ObserveRequest 实现Metrics接口
+func (m PrometheusMetrics) ObserveRequest(endpoint string, status int, dur time.Duration) {
+	class := StatusClass(status)
+	if m.Requests != nil {
+		m.Requests(endpoint, class)
+	}
+	if m.Latency != nil {
+		m.Latency(endpoint, class, dur.Seconds())
+	}
+}
+
+// metricsEndpoint 从完整请求地址中提取接口路径作为指标标签，去掉根地址与查询参数以控制标签基数
+func metricsEndpoint(baseURL, url string) string {
+	endpoint := strings.TrimPrefix(url, baseURL)
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return endpoint
+}
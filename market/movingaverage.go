@@ -0,0 +1,103 @@
+package market
+
+import "math"
+
+// MAKind 移动平均的类型
+type MAKind int
+
+const (
+	MASMA  MAKind = iota // 简单移动平均
+	MAEMA                // 指数移动平均（以SMA为初值）
+	MAWMA                // 线性加权移动平均，最近一根权重为period
+	MAHMA                // 赫尔移动平均：WMA(2×WMA(n/2) − WMA(n), √n)，滞后更小
+	MADEMA               // 双重指数移动平均：2×EMA − EMA(EMA)
+	MATEMA               // 三重指数移动平均：3×EMA − 3×EMA(EMA) + EMA(EMA(EMA))
+)
+
+// MovingAverage 收盘价的移动平均序列，与klines逐根对齐，预热区为NaN；未知的kind返回全NaN序列
+// SMA/EMA/WMA前period-1个值为NaN，HMA前period+⌊√period⌋-2个值为NaN，
+// DEMA前2×(period-1)个值为NaN，TEMA前3×(period-1)个值为NaN
+func MovingAverage(klines []Kline, period int, kind MAKind) []float64 {
+	closes := Klines(klines).Closes()
+	switch kind {
+	case MASMA:
+		return smaSeries(closes, period)
+	case MAEMA:
+		return emaSeries(closes, period)
+	case MAWMA:
+		return wmaSeries(closes, period)
+	case MAHMA:
+		return hmaSeries(closes, period)
+	case MADEMA:
+		return demaSeries(closes, period)
+	case MATEMA:
+		return temaSeries(closes, period)
+	}
+	return nanSeries(len(klines))
+}
+
+// wmaSeries 对values计算线性加权移动平均，跳过开头的NaN；结果与values对齐
+// 用滚动的加权和与简单和递推，每根K线O(1)
+func wmaSeries(values []float64, period int) []float64 {
+	result := nanSeries(len(values))
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if period <= 0 || len(values)-start < period {
+		return result
+	}
+
+	p := float64(period)
+	denom := p * (p + 1) / 2
+	var weighted, sum float64
+	for i := start; i < start+period; i++ {
+		weighted += float64(i-start+1) * values[i]
+		sum += values[i]
+	}
+	result[start+period-1] = weighted / denom
+	for i := start + period; i < len(values); i++ {
+		// 窗口右移一根：已有的值权重各减1（减去sum），新值权重为period
+		weighted += p*values[i] - sum
+		sum += values[i] - values[i-period]
+		result[i] = weighted / denom
+	}
+	return result
+}
+
+// hmaSeries 对values计算赫尔移动平均，period小于2时返回全NaN序列
+func hmaSeries(values []float64, period int) []float64 {
+	if period < 2 {
+		return nanSeries(len(values))
+	}
+	half := wmaSeries(values, period/2)
+	full := wmaSeries(values, period)
+	diff := make([]float64, len(values))
+	for i := range diff {
+		diff[i] = 2*half[i] - full[i] // 任一为NaN时结果为NaN
+	}
+	return wmaSeries(diff, int(math.Sqrt(float64(period))))
+}
+
+// demaSeries 对values计算DEMA，由EMA序列与EMA的EMA序列逐根组合
+func demaSeries(values []float64, period int) []float64 {
+	ema1 := emaSeries(values, period)
+	ema2 := emaSeries(ema1, period)
+	result := make([]float64, len(values))
+	for i := range result {
+		result[i] = 2*ema1[i] - ema2[i] // ema2为NaN（预热区）时结果为NaN
+	}
+	return result
+}
+
+// temaSeries 对values计算TEMA，由一重、二重、三重EMA序列逐根组合
+func temaSeries(values []float64, period int) []float64 {
+	ema1 := emaSeries(values, period)
+	ema2 := emaSeries(ema1, period)
+	ema3 := emaSeries(ema2, period)
+	result := make([]float64, len(values))
+	for i := range result {
+		result[i] = 3*ema1[i] - 3*ema2[i] + ema3[i]
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMovingAverageReference(t *testing.T) {
+	klines := referenceKlines()
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		kind   MAKind
+		period int
+		want   map[int]float64
+	}{
+		{"SMA20", MASMA, 20, map[int]float64{18: nan, 19: 106.3395, 40: 108.3905, 59: 113.1215}},
+		{"EMA20", MAEMA, 20, map[int]float64{18: nan, 19: 106.3395, 40: 111.6595107298, 59: 111.299403312}},
+		{"WMA20", MAWMA, 20, map[int]float64{18: nan, 19: 106.1971428571, 40: 112.6017619048, 59: 111.105}},
+		// 预热区为period+⌊√period⌋−2根：HMA20前22个、HMA9前10个为NaN
+		{"HMA20", MAHMA, 20, map[int]float64{21: nan, 22: 99.5288701299, 40: 121.1962917749, 59: 107.6368008658}},
+		{"HMA9", MAHMA, 9, map[int]float64{9: nan, 10: 111.4882592593, 59: 110.7513333333}},
+		{"DEMA10", MADEMA, 10, map[int]float64{17: nan, 18: 103.236176608, 59: 109.5402189572}},
+		{"TEMA10", MATEMA, 10, map[int]float64{26: nan, 27: 100.970643373, 59: 109.7047326627}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, tt.name, MovingAverage(klines, tt.period, tt.kind), tt.want)
+		})
+	}
+
+	if got := MovingAverage(klines, 20, MAKind(99)); !math.IsNaN(got[59]) {
+		t.Errorf("unknown kind: got %v, want NaN", got[59])
+	}
+	if got := MovingAverage(klines, 1, MAHMA); !math.IsNaN(got[59]) {
+		t.Errorf("HMA period 1: got %v, want NaN", got[59])
+	}
+
+	// 滚动递推在长序列上的累积误差可以忽略
+	closes := Klines(fixtureKlines("1m", 1500, 0)).Closes()
+	want := naiveHMA(closes, 100)
+	got := hmaSeries(closes, 100)
+	for i := range got {
+		if math.IsNaN(got[i]) != math.IsNaN(want[i]) || math.Abs(got[i]-want[i]) > 1e-9*math.Abs(want[i]) {
+			t.Fatalf("hmaSeries[%d] = %v, want naive %v", i, got[i], want[i])
+		}
+	}
+}
+
+// naiveWMA 每根K线重新计算整个窗口的加权和，作为wmaSeries的对照
+func naiveWMA(values []float64, period int) []float64 {
+	result := nanSeries(len(values))
+	denom := float64(period*(period+1)) / 2
+	for i := range values {
+		if i < period-1 {
+			continue
+		}
+		sum := 0.0
+		for j, v := range values[i-period+1 : i+1] {
+			sum += float64(j+1) * v
+		}
+		result[i] = sum / denom // 窗口含NaN时结果为NaN
+	}
+	return result
+}
+
+// naiveHMA 由naiveWMA组合的赫尔移动平均
+func naiveHMA(values []float64, period int) []float64 {
+	half, full := naiveWMA(values, period/2), naiveWMA(values, period)
+	diff := make([]float64, len(values))
+	for i := range diff {
+		diff[i] = 2*half[i] - full[i]
+	}
+	return naiveWMA(diff, int(math.Sqrt(float64(period))))
+}
+
+func BenchmarkHMA(b *testing.B) {
+	closes := Klines(fixtureKlines("1m", 1500, 0)).Closes()
+	b.Run("rolling", func(b *testing.B) {
+		for b.Loop() {
+			hmaSeries(closes, 100)
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for b.Loop() {
+			naiveHMA(closes, 100)
+		}
+	})
+}
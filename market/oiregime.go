@@ -0,0 +1,82 @@
+package market
+
+import (
+	"math"
+	"strings"
+)
+
+// OIRegime 持仓量与价格同向/背离的组合状态
+type OIRegime string
+
+const (
+	OIRegimeNewLongs        OIRegime = "new_longs"        // OI上升、价格上涨：新开多
+	OIRegimeNewShorts       OIRegime = "new_shorts"       // OI上升、价格下跌：新开空
+	OIRegimeLongLiquidation OIRegime = "long_liquidation" // OI下降、价格下跌：多头平仓/被强平
+	OIRegimeShortCovering   OIRegime = "short_covering"   // OI下降、价格上涨：空头回补
+	OIRegimeFlat            OIRegime = "flat"             // OI或价格的变化低于噪声阈值
+)
+
+// oiRegimeWindows OIData.Regimes的窗口，顺序即Format中的顺序
+var oiRegimeWindows = []string{"5m", "15m", "1h", "4h"}
+
+// OIRegimeThresholds OI状态分类的噪声阈值（百分比），见ClassifyOIRegime
+type OIRegimeThresholds struct {
+	OIPct    float64 // OI变化的绝对值低于该百分比视为持平，默认0.2
+	PricePct float64 // 价格变化的绝对值低于该百分比视为持平，默认0.1
+}
+
+// DefaultOIRegimeThresholds 默认的OI状态阈值
+var DefaultOIRegimeThresholds = OIRegimeThresholds{OIPct: 0.2, PricePct: 0.1}
+
+// withDefaults 为0的阈值取默认值
+func (t OIRegimeThresholds) withDefaults() OIRegimeThresholds {
+	if t.OIPct == 0 {
+		t.OIPct = DefaultOIRegimeThresholds.OIPct
+	}
+	if t.PricePct == 0 {
+		t.PricePct = DefaultOIRegimeThresholds.PricePct
+	}
+	return t
+}
+
+// ClassifyOIRegime 按OI与价格变化的百分比给出状态：任一变化的绝对值低于阈值时为持平，
+// 否则OI升/价涨为新开多，OI升/价跌为新开空，OI降/价跌为多头平仓，OI降/价涨为空头回补
+func ClassifyOIRegime(oiPct, pricePct float64, t OIRegimeThresholds) OIRegime {
+	if math.Abs(oiPct) < t.OIPct || math.Abs(pricePct) < t.PricePct {
+		return OIRegimeFlat
+	}
+	switch {
+	case oiPct > 0 && pricePct > 0:
+		return OIRegimeNewLongs
+	case oiPct > 0:
+		return OIRegimeNewShorts
+	case pricePct < 0:
+		return OIRegimeLongLiquidation
+	default:
+		return OIRegimeShortCovering
+	}
+}
+
+// formatOIRegimes 如 "OI regime: new longs on 15m/1h, short covering on 4h"，全部持平时为 "OI regime: flat"
+func formatOIRegimes(regimes map[string]OIRegime) string {
+	var order []OIRegime
+	windows := make(map[OIRegime][]string)
+	for _, window := range oiRegimeWindows {
+		regime, ok := regimes[window]
+		if !ok || regime == OIRegimeFlat {
+			continue
+		}
+		if _, seen := windows[regime]; !seen {
+			order = append(order, regime)
+		}
+		windows[regime] = append(windows[regime], window)
+	}
+	if len(order) == 0 {
+		return "OI regime: flat\n\n"
+	}
+	parts := make([]string, 0, len(order))
+	for _, regime := range order {
+		parts = append(parts, strings.ReplaceAll(string(regime), "_", " ")+" on "+strings.Join(windows[regime], "/"))
+	}
+	return "OI regime: " + strings.Join(parts, ", ") + "\n\n"
+}
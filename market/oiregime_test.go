@@ -0,0 +1,72 @@
+package market
+
+import "testing"
+
+func TestClassifyOIRegime(t *testing.T) {
+	d := DefaultOIRegimeThresholds
+	tests := []struct {
+		name            string
+		oiPct, pricePct float64
+		t               OIRegimeThresholds
+		want            OIRegime
+	}{
+		// 每种符号组合一行
+		{"OI up, price up", 1, 1, d, OIRegimeNewLongs},
+		{"OI up, price down", 1, -1, d, OIRegimeNewShorts},
+		{"OI down, price down", -1, -1, d, OIRegimeLongLiquidation},
+		{"OI down, price up", -1, 1, d, OIRegimeShortCovering},
+		{"OI zero, price up", 0, 1, d, OIRegimeFlat},
+		{"OI zero, price down", 0, -1, d, OIRegimeFlat},
+		{"OI up, price zero", 1, 0, d, OIRegimeFlat},
+		{"OI down, price zero", -1, 0, d, OIRegimeFlat},
+		{"both zero", 0, 0, d, OIRegimeFlat},
+
+		// 低于噪声阈值视为持平，恰好等于阈值时参与分类
+		{"OI below threshold", 0.19, 1, d, OIRegimeFlat},
+		{"OI at threshold", 0.2, 1, d, OIRegimeNewLongs},
+		{"negative OI below threshold", -0.19, -1, d, OIRegimeFlat},
+		{"price below threshold", 1, -0.09, d, OIRegimeFlat},
+		{"price at threshold", -1, -0.1, d, OIRegimeLongLiquidation},
+
+		// 自定义阈值
+		{"custom OI threshold", 1, 1, OIRegimeThresholds{OIPct: 2, PricePct: 0.1}, OIRegimeFlat},
+		{"custom price threshold", -3, 0.5, OIRegimeThresholds{OIPct: 2, PricePct: 0.5}, OIRegimeShortCovering},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyOIRegime(tt.oiPct, tt.pricePct, tt.t); got != tt.want {
+				t.Errorf("ClassifyOIRegime(%v, %v) = %q, want %q", tt.oiPct, tt.pricePct, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIRegimeThresholdsWithDefaults(t *testing.T) {
+	if got := (OIRegimeThresholds{}).withDefaults(); got != DefaultOIRegimeThresholds {
+		t.Errorf("zero thresholds = %+v, want defaults %+v", got, DefaultOIRegimeThresholds)
+	}
+	if got, want := (OIRegimeThresholds{OIPct: 1}).withDefaults(), (OIRegimeThresholds{OIPct: 1, PricePct: 0.1}); got != want {
+		t.Errorf("partial thresholds = %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatOIRegimes(t *testing.T) {
+	tests := []struct {
+		name    string
+		regimes map[string]OIRegime
+		want    string
+	}{
+		{"none", nil, "OI regime: flat\n\n"},
+		{"all flat", map[string]OIRegime{"5m": OIRegimeFlat, "1h": OIRegimeFlat}, "OI regime: flat\n\n"},
+		{"grouped in window order", map[string]OIRegime{
+			"4h": OIRegimeShortCovering, "1h": OIRegimeNewLongs, "15m": OIRegimeNewLongs, "5m": OIRegimeFlat,
+		}, "OI regime: new longs on 15m/1h, short covering on 4h\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatOIRegimes(tt.regimes); got != tt.want {
+				t.Errorf("formatOIRegimes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
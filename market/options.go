@@ -0,0 +1,389 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetOption 单次获取的配置项
+type GetOption func(*getOptions)
+
+// getOptions 单次获取的配置
+type getOptions struct {
+	concurrency int  // GetMany的并发数
+	bypassCache bool // 跳过缓存读取（结果仍会写回缓存）
+	strict      bool // 任一分区失败即返回错误
+	closedOnly  bool // 只用已收盘的K线计算指标
+
+	quote               string             // 计价资产，空表示DefaultQuote
+	market              MarketType         // 客户端所选市场，决定symbol格式
+	intervals           []string           // 拉取的K线周期
+	klineLimits         map[string]int     // 按周期覆盖的K线数量
+	minKlines           int                // 每个周期至少需要的K线数量，不足时返回ErrInsufficientData
+	rocLookbacks        []int              // TimeframeMetrics.ROC的回看K线数
+	swingATRMultiple    float64            // Data.FibRetracement识别显著波段的ATR倍数
+	smoothing           Smoothing          // RSI与ATR字段的平滑方式
+	regime              RegimeThresholds   // 趋势状态分类的阈值
+	gapPolicy           GapPolicy          // 发现缺失K线时的处理方式
+	volumeSpikeZ        float64            // TimeframeMetrics.VolumeSpike的z-score阈值
+	profileLookback     time.Duration      // Data.VolumeProfile的回看时长
+	profileDistribution VolumeDistribution // Data.VolumeProfile中K线成交量的分配方式
+	fundingWindow       time.Duration      // FundingData.Stats的统计窗口
+	oiRegime            OIRegimeThresholds // OIData.Regimes分类的噪声阈值
+	skipOpenInterest    bool
+	skipFunding         bool
+	skipMicrostructure  bool
+	btcCorrelation      bool // 计算与BTC的相关性与beta
+	relativeStrength    bool // 计算相对BTC、ETH的强弱
+	sentiment           bool // 拉取多空比
+	basisHistory        bool // 拉取溢价指数K线
+	ticker              bool // 拉取24小时行情统计
+	rawKlines           bool // 在Data.RawKlines中附带本次获取的K线
+}
+
+// defaultConcurrency GetMany默认并发数
+const defaultConcurrency = 8
+
+// WithConcurrency 设置GetMany同时拉取的symbol数量，<=0时使用默认值
+func WithConcurrency(n int) GetOption {
+	return func(o *getOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithoutCache 本次获取跳过缓存，强制从接口拉取最新数据（拉取结果仍会刷新缓存）
+func WithoutCache() GetOption {
+	return func(o *getOptions) {
+		o.bypassCache = true
+	}
+}
+
+// WithStrict 严格模式：OI、资金费率、微结构任一子请求失败时Get直接返回错误，而不是返回部分数据
+func WithStrict() GetOption {
+	return func(o *getOptions) {
+		o.strict = true
+	}
+}
+
+// WithClosedCandlesOnly 计算指标前去掉尚未收盘（CloseTime晚于当前时间）的最后一根K线，
+// 指标不再随进行中的K线跳动，便于与回测结果对齐；CurrentPrice仍为进行中K线的最新价格
+func WithClosedCandlesOnly() GetOption {
+	return func(o *getOptions) {
+		o.closedOnly = true
+	}
+}
+
+// WithQuote 指定计价资产（如 USDC），不带计价后缀的symbol会补全为该资产的交易对
+func WithQuote(quote string) GetOption {
+	return func(o *getOptions) {
+		o.quote = quote
+	}
+}
+
+// WithIntervals 指定拉取的K线周期（默认为客户端的周期列表，见WithDefaultIntervals），未指定的周期不会请求，Timeframes中也不会出现
+// 周期必须是SupportedIntervals之一，否则Get在发起请求前返回ErrUnsupportedInterval
+func WithIntervals(intervals ...string) GetOption {
+	return func(o *getOptions) {
+		o.intervals = append([]string(nil), intervals...)
+	}
+}
+
+// WithKlineLimit 设置某个周期拉取的K线数量（默认200，4h为120）
+// 币安单次最多返回1500根，超过时自动按时间向前分页拉取（每页单独计算权重）；上市时间较短的币种可能少于limit
+func WithKlineLimit(interval string, limit int) GetOption {
+	return func(o *getOptions) {
+		if o.klineLimits == nil {
+			o.klineLimits = make(map[string]int)
+		}
+		o.klineLimits[interval] = limit
+	}
+}
+
+// WithMinKlines 设置每个周期至少需要的K线数量（默认1），任一周期不足时Get返回*InsufficientDataError
+// 新上市的币种K线较少，调大该值可以避免在数据不足时算出无意义的指标
+func WithMinKlines(n int) GetOption {
+	return func(o *getOptions) {
+		o.minKlines = n
+	}
+}
+
+// defaultROCLookbacks TimeframeMetrics.ROC默认的回看K线数
+var defaultROCLookbacks = []int{5, 10, 20}
+
+// WithROCLookbacks 设置每个周期计算变动率（TimeframeMetrics.ROC）的回看K线数（默认5/10/20），<=0的值被忽略
+func WithROCLookbacks(bars ...int) GetOption {
+	return func(o *getOptions) {
+		o.rocLookbacks = make([]int, 0, len(bars))
+		for _, n := range bars {
+			if n > 0 {
+				o.rocLookbacks = append(o.rocLookbacks, n)
+			}
+		}
+	}
+}
+
+// WithSwingThreshold 设置Data.FibRetracement识别显著波段的反转幅度（ATR14的倍数，默认3）
+func WithSwingThreshold(atrMultiple float64) GetOption {
+	return func(o *getOptions) {
+		o.swingATRMultiple = atrMultiple
+	}
+}
+
+// WithSmoothing 设置RSI与ATR字段（TimeframeMetrics、IntradayData、LongerTermData中的RSI*/ATR*）的平滑方式，
+// 默认SmoothWilder；Keltner、SuperTrend、形态识别等内部使用的ATR始终为Wilder平滑
+func WithSmoothing(smoothing Smoothing) GetOption {
+	return func(o *getOptions) {
+		o.smoothing = smoothing
+	}
+}
+
+// WithRegimeThresholds 设置趋势状态分类（TimeframeMetrics.Regime）的阈值，为0的字段使用DefaultRegimeThresholds中的值
+func WithRegimeThresholds(t RegimeThresholds) GetOption {
+	return func(o *getOptions) {
+		o.regime = t
+	}
+}
+
+// WithOIRegimeThresholds 设置OI状态分类（OIData.Regimes）的噪声阈值，为0的字段使用DefaultOIRegimeThresholds中的值
+func WithOIRegimeThresholds(t OIRegimeThresholds) GetOption {
+	return func(o *getOptions) {
+		o.oiRegime = t
+	}
+}
+
+// WithGapPolicy 设置K线缺口（交易所维护等造成的缺失K线）的处理方式，默认GapFillFlat
+// Get总会校验K线的连续性，缺口与修复情况记录在FetchReport.Warnings中（SectionKlines）
+func WithGapPolicy(policy GapPolicy) GetOption {
+	return func(o *getOptions) {
+		o.gapPolicy = policy
+	}
+}
+
+// DefaultVolumeSpikeZ TimeframeMetrics.VolumeSpike默认的z-score阈值
+const DefaultVolumeSpikeZ = 2.5
+
+// WithVolumeSpikeThreshold 设置判定放量（TimeframeMetrics.VolumeSpike）的成交量z-score阈值（默认2.5），<=0时使用默认值
+func WithVolumeSpikeThreshold(z float64) GetOption {
+	return func(o *getOptions) {
+		o.volumeSpikeZ = z
+	}
+}
+
+// WithVolumeProfile 设置Data.VolumeProfile的回看时长（默认24小时，<=0时使用默认值）与K线成交量的分配方式
+// 剖面只用已拉取的1m/3m K线，默认数量覆盖不到24小时，需要时配合WithKlineLimit增加K线数量
+func WithVolumeProfile(lookback time.Duration, distribution VolumeDistribution) GetOption {
+	return func(o *getOptions) {
+		o.profileLookback = lookback
+		o.profileDistribution = distribution
+	}
+}
+
+// WithBTCCorrelation 额外拉取BTC的1h K线（走缓存，GetMany中各币种共用），计算Data.CorrelationBTC与Data.BetaBTC
+// symbol本身为BTC时不计算
+func WithBTCCorrelation() GetOption {
+	return func(o *getOptions) {
+		o.btcCorrelation = true
+	}
+}
+
+// WithRelativeStrength 额外拉取BTC与ETH的1h K线（走缓存，GetMany中各币种共用），计算Data.RelativeStrength
+func WithRelativeStrength() GetOption {
+	return func(o *getOptions) {
+		o.relativeStrength = true
+	}
+}
+
+// WithFundingWindow 设置FundingData.Stats的统计窗口（默认30天，<=0时使用默认值）
+// 资金费率历史最多拉取1000次结算，窗口超出的部分不参与统计
+func WithFundingWindow(window time.Duration) GetOption {
+	return func(o *getOptions) {
+		o.fundingWindow = window
+	}
+}
+
+// WithSentiment 额外拉取全部账户与大户的多空比（5m与1h周期）及主动买卖量比（5m与15m周期），填充Data.Sentiment；现货市场忽略
+// 单个子请求失败记为SectionSentiment警告，全部失败时该分区不可用
+func WithSentiment() GetOption {
+	return func(o *getOptions) {
+		o.sentiment = true
+	}
+}
+
+// WithBasisHistory 额外拉取最近60根5m溢价指数K线，填充Data.BasisHistory；现货市场或WithoutFunding时忽略
+// 获取失败记为SectionFunding的警告，不影响资金费率本身
+func WithBasisHistory() GetOption {
+	return func(o *getOptions) {
+		o.basisHistory = true
+	}
+}
+
+// WithTicker24h 额外拉取24小时行情统计（与Client.Ticker24h共用缓存），填充Data.Ticker24h并作为OIData.Volume24h的来源；
+// GetMany中币种较多时改为一次请求全部交易对；Score的24小时动量分项与成交额也取自该数据。
+// 未指定时Data.Ticker24h为nil，OIData.Volume24h由K线汇总
+func WithTicker24h() GetOption {
+	return func(o *getOptions) {
+		o.ticker = true
+	}
+}
+
+// WithRawKlines 在Data.RawKlines中附带本次获取（并按配置修复、裁剪）的各周期K线，便于调用方自行计算指标而不必重新拉取
+// 切片与缓存及其它调用方共享，只能读取，不要修改
+func WithRawKlines() GetOption {
+	return func(o *getOptions) {
+		o.rawKlines = true
+	}
+}
+
+// WithoutOpenInterest 不拉取OI数据，Data.OpenInterest为nil
+func WithoutOpenInterest() GetOption {
+	return func(o *getOptions) {
+		o.skipOpenInterest = true
+	}
+}
+
+// WithoutFunding 不拉取资金费率，Data.Funding为nil
+func WithoutFunding() GetOption {
+	return func(o *getOptions) {
+		o.skipFunding = true
+	}
+}
+
+// WithoutMicrostructure 不拉取成交与盘口数据，Data.Microstructure为nil
+func WithoutMicrostructure() GetOption {
+	return func(o *getOptions) {
+		o.skipMicrostructure = true
+	}
+}
+
+// normalize 按市场与本次配置的计价资产标准化symbol
+func (o getOptions) normalize(symbol string) (string, error) {
+	if o.market == COINM {
+		return NormalizeCoinM(symbol)
+	}
+	quote := o.quote
+	if quote == "" {
+		quote = DefaultQuote
+	}
+	return NormalizeQuote(symbol, quote)
+}
+
+// klineLimit 返回周期的K线数量
+func (o getOptions) klineLimit(interval string) int {
+	if limit, ok := o.klineLimits[interval]; ok && limit > 0 {
+		return limit
+	}
+	return defaultKlineLimitFor(interval)
+}
+
+// hasInterval 判断是否请求了该周期
+func (o getOptions) hasInterval(interval string) bool {
+	for _, iv := range o.intervals {
+		if iv == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// flightKey 合并并发请求时区分不同配置的key，只有结果相同的请求才会合并
+func (o getOptions) flightKey(symbol string) string {
+	var sb strings.Builder
+	sb.WriteString(symbol)
+	for _, interval := range o.intervals {
+		sb.WriteString(fmt.Sprintf("|%s:%d", interval, o.klineLimit(interval)))
+	}
+	if o.minKlines > 1 {
+		sb.WriteString(fmt.Sprintf("|min:%d", o.minKlines))
+	}
+	sb.WriteString(fmt.Sprintf("|roc:%v", o.rocLookbacks))
+	if o.swingATRMultiple > 0 {
+		sb.WriteString(fmt.Sprintf("|swing:%g", o.swingATRMultiple))
+	}
+	sb.WriteString(fmt.Sprintf("|smooth:%d|gap:%d|spike:%g|regime:%v", o.smoothing, o.gapPolicy, o.volumeSpikeZ, o.regime))
+	sb.WriteString(fmt.Sprintf("|profile:%s/%d", o.profileLookback, o.profileDistribution))
+	sb.WriteString(fmt.Sprintf("|funding:%s|oiregime:%v", o.fundingWindow, o.oiRegime))
+
+	flags := make([]string, 0, 8)
+	if o.bypassCache {
+		flags = append(flags, "nocache")
+	}
+	if o.closedOnly {
+		flags = append(flags, "closed")
+	}
+	if o.btcCorrelation {
+		flags = append(flags, "btc")
+	}
+	if o.relativeStrength {
+		flags = append(flags, "rs")
+	}
+	if o.rawKlines {
+		flags = append(flags, "raw")
+	}
+	if o.sentiment {
+		flags = append(flags, "sentiment")
+	}
+	if o.basisHistory {
+		flags = append(flags, "basis")
+	}
+	if o.ticker {
+		flags = append(flags, "ticker")
+	}
+	if o.skipOpenInterest {
+		flags = append(flags, "nooi")
+	}
+	if o.skipFunding {
+		flags = append(flags, "nofunding")
+	}
+	if o.skipMicrostructure {
+		flags = append(flags, "nomicro")
+	}
+	sort.Strings(flags)
+	if len(flags) > 0 {
+		sb.WriteString("|" + strings.Join(flags, ","))
+	}
+	return sb.String()
+}
+
+// newGetOptions 合并单次配置，未指定周期时使用客户端的默认周期
+func (c *Client) newGetOptions(opts []GetOption) getOptions {
+	o := getOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = defaultConcurrency
+	}
+	if o.minKlines < 1 {
+		o.minKlines = 1
+	}
+	if o.rocLookbacks == nil {
+		o.rocLookbacks = defaultROCLookbacks
+	}
+	o.regime = o.regime.withDefaults()
+	o.oiRegime = o.oiRegime.withDefaults()
+	if o.volumeSpikeZ <= 0 {
+		o.volumeSpikeZ = DefaultVolumeSpikeZ
+	}
+	if o.profileLookback <= 0 {
+		o.profileLookback = DefaultVolumeProfileLookback
+	}
+	if o.fundingWindow <= 0 {
+		o.fundingWindow = fundingHistoryWindow
+	}
+	cfg := c.config()
+	o.market = cfg.market
+	if !o.market.hasDerivatives() {
+		o.skipOpenInterest = true
+		o.skipFunding = true
+		o.sentiment = false
+	}
+	if o.skipFunding {
+		o.basisHistory = false
+	}
+	if len(o.intervals) == 0 {
+		o.intervals = cfg.intervals
+	}
+	return o
+}
@@ -0,0 +1,219 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CandlePattern K线形态名称
+type CandlePattern string
+
+const (
+	PatternBullishEngulfing   CandlePattern = "bullish_engulfing"
+	PatternBearishEngulfing   CandlePattern = "bearish_engulfing"
+	PatternHammer             CandlePattern = "hammer"
+	PatternShootingStar       CandlePattern = "shooting_star"
+	PatternDoji               CandlePattern = "doji"
+	PatternInsideBar          CandlePattern = "inside_bar"
+	PatternThreeWhiteSoldiers CandlePattern = "three_white_soldiers"
+	PatternThreeBlackCrows    CandlePattern = "three_black_crows"
+)
+
+// 形态的尺寸阈值，均以该K线处的ATR14为单位，使不同价位的币种可以共用
+const (
+	dojiMaxBody          = 0.1  // 十字星实体不超过0.1倍ATR
+	pinMinRange          = 0.5  // 锤子线/流星线的振幅至少0.5倍ATR
+	pinMinShadow         = 0.6  // 锤子线/流星线的长影线至少占振幅的60%
+	pinMaxOppositeShadow = 0.15 // 锤子线/流星线的另一侧影线不超过振幅的15%
+	engulfingMinBody     = 0.3  // 吞没形态中后一根的实体至少0.3倍ATR
+	soldierMinBody       = 0.5  // 三兵/三鸦每根实体至少0.5倍ATR
+	patternATRPeriod     = 14
+	patternLookback      = 3 // 检查最近几根已收盘的K线
+)
+
+// PatternHit 一次形态识别结果
+type PatternHit struct {
+	Pattern  CandlePattern `json:"pattern"`
+	Interval string        `json:"interval,omitempty"`
+	BarsAgo  int           `json:"bars_ago"` // 形态最后一根K线距klines末尾的根数，0为最后一根
+}
+
+// DetectCandlePatterns 在klines的最后lookback根K线上识别常见形态（结果的Interval为空）
+// 只看K线本身的形状，不判断前序趋势；调用方应只传入已收盘的K线。ATR14尚不可用的K线跳过
+func DetectCandlePatterns(klines []Kline, lookback int) []PatternHit {
+	atr := ATRSeries(klines, patternATRPeriod)
+	var hits []PatternHit
+	for i := len(klines) - 1; i >= 0 && i >= len(klines)-lookback; i-- {
+		if math.IsNaN(atr[i]) || atr[i] <= 0 {
+			continue
+		}
+		for _, pattern := range patternsAt(klines, i, atr[i]) {
+			hits = append(hits, PatternHit{Pattern: pattern, BarsAgo: len(klines) - 1 - i})
+		}
+	}
+	return hits
+}
+
+// patternsAt 以第i根K线为形态最后一根识别形态
+func patternsAt(klines []Kline, i int, atr float64) []CandlePattern {
+	var patterns []CandlePattern
+	k := klines[i]
+	body, upper, lower := k.Body(), k.UpperWick(), k.LowerWick()
+
+	// 锤子线：下影至少为实体的2倍且占振幅60%以上，上影不超过振幅的15%；流星线相反
+	// 影线很长的十字星（蜻蜓/墓碑十字）归为锤子线/流星线，不再记为十字星
+	barRange := k.Range()
+	pin := false
+	if barRange >= pinMinRange*atr {
+		if lower >= 2*body && lower >= pinMinShadow*barRange && upper <= pinMaxOppositeShadow*barRange {
+			patterns = append(patterns, PatternHammer)
+			pin = true
+		}
+		if upper >= 2*body && upper >= pinMinShadow*barRange && lower <= pinMaxOppositeShadow*barRange {
+			patterns = append(patterns, PatternShootingStar)
+			pin = true
+		}
+	}
+	if !pin && body <= dojiMaxBody*atr && barRange > 0 {
+		patterns = append(patterns, PatternDoji)
+	}
+
+	if i >= 1 {
+		prev := klines[i-1]
+		prevBody := math.Abs(prev.Close - prev.Open)
+		if body >= engulfingMinBody*atr && body > prevBody {
+			if prev.Close < prev.Open && k.Close > k.Open && k.Open <= prev.Close && k.Close >= prev.Open {
+				patterns = append(patterns, PatternBullishEngulfing)
+			}
+			if prev.Close > prev.Open && k.Close < k.Open && k.Open >= prev.Close && k.Close <= prev.Open {
+				patterns = append(patterns, PatternBearishEngulfing)
+			}
+		}
+		if k.High < prev.High && k.Low > prev.Low {
+			patterns = append(patterns, PatternInsideBar)
+		}
+	}
+
+	if i >= 2 {
+		if threeSoldiers(klines[i-2:i+1], atr, 1) {
+			patterns = append(patterns, PatternThreeWhiteSoldiers)
+		}
+		if threeSoldiers(klines[i-2:i+1], atr, -1) {
+			patterns = append(patterns, PatternThreeBlackCrows)
+		}
+	}
+	return patterns
+}
+
+// threeSoldiers 三根同向的实体K线（direction为+1时阳线、−1时阴线），收盘价逐根推进，
+// 每根开盘价落在前一根实体内，实体至少soldierMinBody倍ATR
+func threeSoldiers(bars []Kline, atr float64, direction float64) bool {
+	for j, k := range bars {
+		if (k.Close-k.Open)*direction < soldierMinBody*atr {
+			return false
+		}
+		if j == 0 {
+			continue
+		}
+		prev := bars[j-1]
+		if (k.Close-prev.Close)*direction <= 0 {
+			return false
+		}
+		if k.Open < math.Min(prev.Open, prev.Close) || k.Open > math.Max(prev.Open, prev.Close) {
+			return false
+		}
+	}
+	return true
+}
+
+// patternIntervals 形态识别的周期
+var patternIntervals = []string{"15m", "1h", "4h"}
+
+// calculateCandlePatterns 在各周期最近的已收盘K线上识别形态，nowMs之后才收盘的K线（进行中）不参与
+func calculateCandlePatterns(klinesByInterval map[string][]Kline, nowMs int64) []PatternHit {
+	var hits []PatternHit
+	for _, interval := range patternIntervals {
+		klines, ok := klinesByInterval[interval]
+		if !ok {
+			continue
+		}
+		for _, hit := range DetectCandlePatterns(closedKlines(klines, nowMs), patternLookback) {
+			hit.Interval = interval
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// closedKlines 去掉最后一根尚未收盘（CloseTime不早于nowMs）的K线
+func closedKlines(klines []Kline, nowMs int64) []Kline {
+	if n := len(klines); n > 0 && klines[n-1].CloseTime >= nowMs {
+		return klines[:n-1]
+	}
+	return klines
+}
+
+// formatPatternHits Format中的一行形态，只列出1h与4h周期
+func formatPatternHits(hits []PatternHit) string {
+	parts := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Interval != "1h" && hit.Interval != "4h" {
+			continue
+		}
+		ago := "last closed bar"
+		switch {
+		case hit.BarsAgo == 1:
+			ago = "1 bar before last closed"
+		case hit.BarsAgo > 1:
+			ago = fmt.Sprintf("%d bars before last closed", hit.BarsAgo)
+		}
+		parts = append(parts, fmt.Sprintf("%s on %s (%s)", hit.Pattern, hit.Interval, ago))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Candle patterns (1h/4h): " + strings.Join(parts, ", ") + "\n\n"
+}
+
+// candleStatsBars K线形态统计使用的K线数量
+const candleStatsBars = 20
+
+// CandleStats 最近若干根K线的形态统计，用于识别衰竭长影线与放量高潮K线
+type CandleStats struct {
+	Bars         int     `json:"bars"`           // 参与统计的K线数量
+	AvgBody      float64 `json:"avg_body"`       // 平均实体 |收−开|
+	AvgUpperWick float64 `json:"avg_upper_wick"` // 平均上影线
+	AvgLowerWick float64 `json:"avg_lower_wick"` // 平均下影线
+	BodyToRange  float64 `json:"body_to_range"`  // Σ实体/Σ振幅，越小影线越长；振幅之和为0时为0
+	TRPercentile float64 `json:"tr_percentile"`  // 最后一根K线的真实波幅在窗口内的百分位（0~100）
+}
+
+// calculateCandleStats 统计最近period根K线的实体与影线；真实波幅需要前收盘价，第一根K线不参与百分位。K线少于2根时为nil
+func calculateCandleStats(klines []Kline, period int) *CandleStats {
+	if period <= 0 || len(klines) < 2 {
+		return nil
+	}
+	start := max(len(klines)-period, 0)
+	window := klines[start:]
+
+	stats := &CandleStats{Bars: len(window)}
+	var sumRange float64
+	for _, k := range window {
+		stats.AvgBody += k.Body()
+		stats.AvgUpperWick += k.UpperWick()
+		stats.AvgLowerWick += k.LowerWick()
+		sumRange += k.Range()
+	}
+	if sumRange > 0 {
+		stats.BodyToRange = stats.AvgBody / sumRange
+	}
+	n := float64(len(window))
+	stats.AvgBody /= n
+	stats.AvgUpperWick /= n
+	stats.AvgLowerWick /= n
+
+	trs := trueRanges(klines)[max(start, 1):]
+	stats.TRPercentile = PercentileRank(trs, trs[len(trs)-1])
+	return stats
+}
@@ -0,0 +1,109 @@
+package market
+
+import (
+	"slices"
+	"testing"
+)
+
+// patternBase 15根形状相同的小阳线（开100、高101、低99、收100.4），真实波幅均为2，使ATR14为2；
+// 本身不构成任何形态
+func patternBase() []Kline {
+	klines := make([]Kline, patternATRPeriod+1)
+	for i := range klines {
+		klines[i] = Kline{Open: 100, High: 101, Low: 99, Close: 100.4}
+	}
+	return klines
+}
+
+func candle(open, high, low, close float64) Kline {
+	return Kline{Open: open, High: high, Low: low, Close: close}
+}
+
+func TestDetectCandlePatterns(t *testing.T) {
+	// 阈值以ATR（约2）为单位：十字星实体≤0.2，吞没实体≥0.6，三兵实体≥1.0；反例只在一个条件上刚好越界
+	tests := []struct {
+		pattern  CandlePattern
+		positive []Kline
+		negative []Kline
+	}{
+		{
+			// 实体0.05；反例实体0.25超过0.1倍ATR
+			PatternDoji,
+			[]Kline{candle(100.4, 101.4, 99.4, 100.45)},
+			[]Kline{candle(100.4, 101.4, 99.4, 100.65)},
+		},
+		{
+			// 下影1.8占振幅2.2的82%，上影0.1；反例上影0.4超过振幅2.5的15%（0.375）
+			PatternHammer,
+			[]Kline{candle(100.4, 100.8, 98.6, 100.7)},
+			[]Kline{candle(100.4, 101.1, 98.6, 100.7)},
+		},
+		{
+			// 上影1.8，下影0.1；反例下影0.4超过振幅2.5的15%
+			PatternShootingStar,
+			[]Kline{candle(100.4, 102.2, 100.0, 100.1)},
+			[]Kline{candle(100.4, 102.2, 99.7, 100.1)},
+		},
+		{
+			// 前一根阴线100.4→99.9，后一根从99.8涨到100.5完全覆盖；反例开盘100.0高于前收盘
+			PatternBullishEngulfing,
+			[]Kline{candle(100.4, 100.6, 99.6, 99.9), candle(99.8, 100.7, 99.7, 100.5)},
+			[]Kline{candle(100.4, 100.6, 99.6, 99.9), candle(100.0, 100.9, 99.9, 100.7)},
+		},
+		{
+			// 前一根阳线100.4→100.9，后一根从101.0跌到100.3；反例开盘100.8低于前收盘
+			PatternBearishEngulfing,
+			[]Kline{candle(100.4, 101.1, 100.2, 100.9), candle(101.0, 101.1, 100.2, 100.3)},
+			[]Kline{candle(100.4, 101.1, 100.2, 100.9), candle(100.8, 101.1, 100.0, 100.1)},
+		},
+		{
+			// 高低点严格落在前一根（101/99）之内；反例最高价等于前高
+			PatternInsideBar,
+			[]Kline{candle(100.4, 100.9, 99.2, 100.0)},
+			[]Kline{candle(100.4, 101.0, 99.2, 100.0)},
+		},
+		{
+			// 三根实体1.2~1.3的阳线，开盘落在前一根实体内；反例第三根在前一根实体上方跳空开盘
+			PatternThreeWhiteSoldiers,
+			[]Kline{candle(100.4, 101.8, 100.3, 101.6), candle(101.0, 102.5, 100.9, 102.3), candle(101.8, 103.2, 101.7, 103.0)},
+			[]Kline{candle(100.4, 101.8, 100.3, 101.6), candle(101.0, 102.5, 100.9, 102.3), candle(102.4, 103.8, 102.3, 103.6)},
+		},
+		{
+			// 三根实体1.2~1.3的阴线；反例第三根在前一根实体下方跳空开盘
+			PatternThreeBlackCrows,
+			[]Kline{candle(100.4, 100.5, 99.0, 99.2), candle(99.8, 99.9, 98.3, 98.5), candle(99.0, 99.1, 97.6, 97.8)},
+			[]Kline{candle(100.4, 100.5, 99.0, 99.2), candle(99.8, 99.9, 98.3, 98.5), candle(98.4, 98.5, 97.0, 97.2)},
+		},
+	}
+	found := func(klines []Kline, pattern CandlePattern) bool {
+		return slices.Contains(DetectCandlePatterns(klines, 1), PatternHit{Pattern: pattern})
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.pattern), func(t *testing.T) {
+			if base := patternBase(); found(base, tt.pattern) {
+				t.Fatalf("base fixture already shows %s", tt.pattern)
+			}
+			if klines := append(patternBase(), tt.positive...); !found(klines, tt.pattern) {
+				t.Errorf("positive fixture: hits = %+v, want %s", DetectCandlePatterns(klines, 1), tt.pattern)
+			}
+			if klines := append(patternBase(), tt.negative...); found(klines, tt.pattern) {
+				t.Errorf("negative fixture: hits = %+v, want no %s", DetectCandlePatterns(klines, 1), tt.pattern)
+			}
+		})
+	}
+}
+
+func TestDetectCandlePatternsBarsAgo(t *testing.T) {
+	// 十字星后再收一根普通K线：lookback=2时报告BarsAgo=1，lookback=1时看不到
+	klines := append(patternBase(), candle(100.4, 101.4, 99.4, 100.45), candle(100.45, 101.45, 99.45, 100.85))
+	if hits := DetectCandlePatterns(klines, 2); !slices.Contains(hits, PatternHit{Pattern: PatternDoji, BarsAgo: 1}) {
+		t.Errorf("lookback 2: hits = %+v, want doji 1 bar ago", hits)
+	}
+	if hits := DetectCandlePatterns(klines, 1); slices.Contains(hits, PatternHit{Pattern: PatternDoji, BarsAgo: 1}) {
+		t.Errorf("lookback 1: hits = %+v, want the doji outside the window", hits)
+	}
+	// ATR14尚不可用时不识别
+	if hits := DetectCandlePatterns([]Kline{candle(100, 101, 99, 100.4), candle(100.4, 101.4, 99.4, 100.45)}, 1); len(hits) != 0 {
+		t.Errorf("without ATR: hits = %+v, want none", hits)
+	}
+}
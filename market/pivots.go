@@ -0,0 +1,178 @@
+package market
+
+import (
+	"fmt"
+	"time"
+)
+
+// 常用的聚合周期
+const (
+	PeriodDay  = 24 * time.Hour
+	PeriodWeek = 7 * PeriodDay
+)
+
+// weekOffset Unix纪元（1970-01-01）是周四，周线按周一0点（UTC）对齐需要偏移4天
+const weekOffset = 4 * PeriodDay
+
+// AggregateKlines 把K线按UTC对齐的period聚合为更长周期的K线（开=首根开盘，高低取极值，收=末根收盘，成交量求和）
+// 日线及更短周期从UTC 0点对齐，7天的整数倍从周一0点对齐（与币安周线一致）；
+// 聚合后的OpenTime/CloseTime为整个周期的起止时间，首尾周期可能只包含部分K线
+func AggregateKlines(klines []Kline, period time.Duration) []Kline {
+	step := period.Milliseconds()
+	if step <= 0 || len(klines) == 0 {
+		return nil
+	}
+	offset := int64(0)
+	if period%PeriodWeek == 0 {
+		offset = weekOffset.Milliseconds()
+	}
+
+	var result []Kline
+	for _, k := range klines {
+		start := floorDiv(k.OpenTime-offset, step)*step + offset
+		if n := len(result); n > 0 && result[n-1].OpenTime == start {
+			bar := &result[n-1]
+			bar.High = max(bar.High, k.High)
+			bar.Low = min(bar.Low, k.Low)
+			bar.Close = k.Close
+			bar.Volume += k.Volume
+			continue
+		}
+		result = append(result, Kline{
+			OpenTime:  start,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			CloseTime: start + step - 1,
+		})
+	}
+	return result
+}
+
+// floorDiv 向下取整的整数除法（负数时间戳也按日历对齐）
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// PivotLevels 一组枢轴点价位
+type PivotLevels struct {
+	P  float64 `json:"p"`
+	R1 float64 `json:"r1"`
+	R2 float64 `json:"r2"`
+	R3 float64 `json:"r3"`
+	S1 float64 `json:"s1"`
+	S2 float64 `json:"s2"`
+	S3 float64 `json:"s3"`
+}
+
+// Pivots 由上一个完整周期（日/周）的高低收计算的枢轴点
+type Pivots struct {
+	Period      string      `json:"period"`          // "1d" 或 "1w"
+	PeriodStart int64       `json:"period_start_ms"` // 计算所用周期的开始时间（UTC）
+	Classic     PivotLevels `json:"classic"`
+	Fibonacci   PivotLevels `json:"fibonacci"`
+}
+
+// classicPivots 经典枢轴点：P=(H+L+C)/3，R1=2P−L，S1=2P−H，R2/S2=P±(H−L)，R3=H+2(P−L)，S3=L−2(H−P)
+func classicPivots(bar Kline) PivotLevels {
+	p := (bar.High + bar.Low + bar.Close) / 3
+	r := bar.High - bar.Low
+	return PivotLevels{
+		P:  p,
+		R1: 2*p - bar.Low,
+		R2: p + r,
+		R3: bar.High + 2*(p-bar.Low),
+		S1: 2*p - bar.High,
+		S2: p - r,
+		S3: bar.Low - 2*(bar.High-p),
+	}
+}
+
+// fibonacciPivots 斐波那契枢轴点：P同经典，R/S = P ± 0.382/0.618/1.0 ×(H−L)
+func fibonacciPivots(bar Kline) PivotLevels {
+	p := (bar.High + bar.Low + bar.Close) / 3
+	r := bar.High - bar.Low
+	return PivotLevels{
+		P:  p,
+		R1: p + 0.382*r,
+		R2: p + 0.618*r,
+		R3: p + r,
+		S1: p - 0.382*r,
+		S2: p - 0.618*r,
+		S3: p - r,
+	}
+}
+
+// pivotSources 计算日/周枢轴点时依次尝试的K线周期
+var pivotSources = map[time.Duration][]string{
+	PeriodDay:  {"1h", "4h", "15m"},
+	PeriodWeek: {"4h", "1h"},
+}
+
+// calculatePivots 用已拉取的K线聚合出上一个完整的日/周K线并计算枢轴点
+// 上一个周期必须被K线完整覆盖（第一根K线不晚于周期开始），否则尝试下一个周期的K线，都不满足时返回nil
+func calculatePivots(klinesByInterval map[string][]Kline, period time.Duration) *Pivots {
+	for _, interval := range pivotSources[period] {
+		klines := klinesByInterval[interval]
+		bars := AggregateKlines(klines, period)
+		if len(bars) < 2 {
+			continue
+		}
+		prev := bars[len(bars)-2]
+		if klines[0].OpenTime > prev.OpenTime {
+			continue
+		}
+		name := "1d"
+		if period == PeriodWeek {
+			name = "1w"
+		}
+		return &Pivots{
+			Period:      name,
+			PeriodStart: prev.OpenTime,
+			Classic:     classicPivots(prev),
+			Fibonacci:   fibonacciPivots(prev),
+		}
+	}
+	return nil
+}
+
+// Bracket 当前价格所在的两个相邻价位名称（如 "P" 与 "R1"），低于S3时below为空，高于R3时above为空
+func (l PivotLevels) Bracket(price float64) (below, above string) {
+	levels := []struct {
+		name  string
+		value float64
+	}{
+		{"S3", l.S3}, {"S2", l.S2}, {"S1", l.S1}, {"P", l.P}, {"R1", l.R1}, {"R2", l.R2}, {"R3", l.R3},
+	}
+	for _, level := range levels {
+		if price < level.value {
+			return below, level.name
+		}
+		below = level.name
+	}
+	return below, ""
+}
+
+// formatPivots Format中的一行枢轴点，标出当前价格所在的区间
+func formatPivots(label string, levels PivotLevels, price float64, decimals int) string {
+	below, above := levels.Bracket(price)
+	var position string
+	switch {
+	case below == "":
+		position = "below S3"
+	case above == "":
+		position = "above R3"
+	default:
+		position = fmt.Sprintf("between %s and %s", below, above)
+	}
+	return fmt.Sprintf("%s: S3 %s / S2 %s / S1 %s / P %s / R1 %s / R2 %s / R3 %s | price %s\n\n", label,
+		formatFloat(levels.S3, decimals), formatFloat(levels.S2, decimals), formatFloat(levels.S1, decimals),
+		formatFloat(levels.P, decimals), formatFloat(levels.R1, decimals), formatFloat(levels.R2, decimals),
+		formatFloat(levels.R3, decimals), position)
+}
@@ -0,0 +1,187 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultWeightLimit 币安U本位合约每分钟的IP请求权重上限
+const DefaultWeightLimit = 2400
+
+// ErrBudgetExceeded 非阻塞模式下本分钟剩余权重不足以发送请求
+var ErrBudgetExceeded = errors.New("本分钟请求权重预算已用尽")
+
+// LimitMode 权重预算用尽时的处理方式
+type LimitMode int
+
+const (
+	// LimitBlock 阻塞等待下一个计数窗口（受ctx约束）
+	LimitBlock LimitMode = iota
+	// LimitReject 立即返回ErrBudgetExceeded
+	LimitReject
+)
+
+// usedWeightHeader 币安在响应中返回的本分钟已用权重（按IP统计，包含其他进程的消耗）
+const usedWeightHeader = "X-MBX-USED-WEIGHT-1M"
+
+// WeightStats 请求权重使用情况
+type WeightStats struct {
+	Limit    int       // 每分钟权重上限，0表示未启用限流
+	Used     int       // 当前窗口已用权重（本地预占与服务端回报取较大值）
+	ResetAt  time.Time // 当前窗口结束时间
+	Waits    int64     // 因预算不足而等待的次数
+	Rejected int64     // 因预算不足而被拒绝的次数
+}
+
+// weightLimiter 按币安的分钟级固定窗口统计请求权重
+// 发送前按端点权重预占，收到响应后用X-MBX-USED-WEIGHT-1M校准
+type weightLimiter struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	used        int
+	waits       int64
+	rejected    int64
+	now         func() time.Time
+}
+
+func newWeightLimiter() *weightLimiter {
+	return &weightLimiter{now: time.Now}
+}
+
+// rollLocked 进入新的分钟窗口时清零计数
+func (l *weightLimiter) rollLocked(now time.Time) {
+	start := now.Truncate(time.Minute)
+	if !start.Equal(l.windowStart) {
+		l.windowStart = start
+		l.used = 0
+	}
+}
+
+// acquire 预占weight权重，limit<=0表示不限流
+func (l *weightLimiter) acquire(ctx context.Context, weight, limit int, mode LimitMode) error {
+	if limit <= 0 || weight <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.rollLocked(now)
+		if l.used+weight <= limit || l.used == 0 {
+			l.used += weight
+			l.mu.Unlock()
+			return nil
+		}
+		if mode == LimitReject {
+			l.rejected++
+			l.mu.Unlock()
+			return ErrBudgetExceeded
+		}
+		l.waits++
+		wait := l.windowStart.Add(time.Minute).Sub(now)
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// observe 根据响应头校准已用权重
+func (l *weightLimiter) observe(header http.Header) {
+	v := header.Get(usedWeightHeader)
+	if v == "" {
+		return
+	}
+	used, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollLocked(l.now())
+	if used > l.used {
+		l.used = used
+	}
+}
+
+// saturate 收到限频响应后将本窗口视为已用尽
+func (l *weightLimiter) saturate(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollLocked(l.now())
+	if limit > l.used {
+		l.used = limit
+	}
+}
+
+func (l *weightLimiter) stats(limit int) WeightStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollLocked(l.now())
+	return WeightStats{
+		Limit:    limit,
+		Used:     l.used,
+		ResetAt:  l.windowStart.Add(time.Minute),
+		Waits:    l.waits,
+		Rejected: l.rejected,
+	}
+}
+
+// maxKlinesPerRequest /fapi/v1/klines 单次请求的K线数量上限
+const maxKlinesPerRequest = 1500
+
+// klinesWeight /fapi/v1/klines 的权重随limit变化
+func klinesWeight(limit int) int {
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// depthWeight /fapi/v1/depth 的权重随limit变化
+func depthWeight(limit int) int {
+	switch {
+	case limit <= 50:
+		return 2
+	case limit <= 100:
+		return 5
+	case limit <= 500:
+		return 10
+	default:
+		return 20
+	}
+}
+
+// 其余端点的固定权重
+const (
+	weightOpenInterest = 1
+	weightPremiumIndex = 1
+	weightFundingRate  = 1
+	weightAggTrades    = 20
+	// 单个symbol的ticker/24hr 合约为1，现货为2；不指定symbol时合约为40，现货为80
+	weightTicker24h         = 1
+	weightSpotTicker24h     = 2
+	weightAllTickers24h     = 40
+	weightSpotAllTickers24h = 80
+	// exchangeInfo 合约为1，现货为20
+	weightExchangeInfo     = 1
+	weightSpotExchangeInfo = 20
+	// /futures/data 下的统计接口单独限频（每5分钟1000次），不计入IP权重
+	weightFuturesData = 0
+)
@@ -0,0 +1,254 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// volatileParams 每次运行都会变化的查询参数（如按当前时间计算的startTime），回放时忽略
+var volatileParams = []string{"startTime", "endTime", "timestamp"}
+
+// recordedExchange 录制文件中的一次请求/响应
+type recordedExchange struct {
+	Seq        int64             `json:"seq"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Query      string            `json:"query"`
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body"`
+}
+
+// recordedHeaders 需要录制的响应头
+var recordedHeaders = []string{"Content-Type", "Retry-After", usedWeightHeader}
+
+// exactKey 按端点与完整查询参数匹配
+func exactKey(path string, query url.Values) string {
+	return path + "?" + query.Encode()
+}
+
+// stableKey 忽略易变参数后的匹配key
+func stableKey(path string, query url.Values) string {
+	stable := url.Values{}
+	for k, v := range query {
+		stable[k] = v
+	}
+	for _, k := range volatileParams {
+		stable.Del(k)
+	}
+	return path + "?" + stable.Encode()
+}
+
+// recorder 把经过的请求/响应逐个写入目录（每次交换一个JSON文件）
+type recorder struct {
+	dir string
+	seq int64
+}
+
+// WithRecorder 录制模式：所有请求照常发往网络，同时把请求/响应写入dir，供WithReplay回放
+func WithRecorder(dir string) ClientOption {
+	rec := &recorder{dir: dir}
+	return func(cfg *clientConfig) {
+		cfg.recorder = rec
+		cfg.replayer = nil
+	}
+}
+
+func (r *recorder) wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// 录制解压后的内容，回放时不带Content-Encoding
+		body, err := readBody(resp, 0)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Header.Del("Content-Encoding")
+		resp.ContentLength = int64(len(body))
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		exchange := recordedExchange{
+			Seq:        atomic.AddInt64(&r.seq, 1),
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Query:      req.URL.RawQuery,
+			StatusCode: resp.StatusCode,
+			Header:     make(map[string]string),
+			Body:       string(body),
+		}
+		for _, h := range recordedHeaders {
+			if v := resp.Header.Get(h); v != "" {
+				exchange.Header[h] = v
+			}
+		}
+		if err := r.write(exchange); err != nil {
+			return nil, fmt.Errorf("录制响应失败: %w", err)
+		}
+		return resp, nil
+	})
+}
+
+func (r *recorder) write(exchange recordedExchange) error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%06d_%s.json", exchange.Seq, strings.Trim(strings.ReplaceAll(exchange.Path, "/", "_"), "_"))
+	return ioutil.WriteFile(filepath.Join(r.dir, name), data, 0644)
+}
+
+// replayer 从录制目录读取响应，不访问网络
+// 先按完整查询参数匹配；匹配不到时忽略易变参数，按同一key出现的顺序依次返回
+type replayer struct {
+	dir string
+
+	once    sync.Once
+	loadErr error
+	exact   map[string]recordedExchange
+	stable  map[string][]recordedExchange
+
+	mu     sync.Mutex
+	cursor map[string]int
+}
+
+// WithReplay 回放模式：从dir读取WithRecorder录制的响应，不访问网络，响应中的时间戳原样返回
+func WithReplay(dir string) ClientOption {
+	rep := &replayer{dir: dir}
+	return func(cfg *clientConfig) {
+		cfg.replayer = rep
+		cfg.recorder = nil
+	}
+}
+
+func (r *replayer) load() error {
+	r.once.Do(func() {
+		files, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+		if err != nil {
+			r.loadErr = err
+			return
+		}
+
+		exchanges := make([]recordedExchange, 0, len(files))
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				r.loadErr = err
+				return
+			}
+			var exchange recordedExchange
+			if err := json.Unmarshal(data, &exchange); err != nil {
+				r.loadErr = fmt.Errorf("解析录制文件%s失败: %w", file, err)
+				return
+			}
+			exchanges = append(exchanges, exchange)
+		}
+		sort.Slice(exchanges, func(i, j int) bool {
+			return exchanges[i].Seq < exchanges[j].Seq
+		})
+
+		r.exact = make(map[string]recordedExchange, len(exchanges))
+		r.stable = make(map[string][]recordedExchange)
+		r.cursor = make(map[string]int)
+		for _, exchange := range exchanges {
+			query, _ := url.ParseQuery(exchange.Query)
+			r.exact[exactKey(exchange.Path, query)] = exchange
+			key := stableKey(exchange.Path, query)
+			r.stable[key] = append(r.stable[key], exchange)
+		}
+	})
+	return r.loadErr
+}
+
+func (r *replayer) lookup(req *http.Request) (recordedExchange, bool) {
+	query := req.URL.Query()
+	if exchange, ok := r.exact[exactKey(req.URL.Path, query)]; ok {
+		return exchange, true
+	}
+
+	key := stableKey(req.URL.Path, query)
+	candidates := r.stable[key]
+	if len(candidates) == 0 {
+		return recordedExchange{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	idx := r.cursor[key] % len(candidates)
+	r.cursor[key]++
+	return candidates[idx], true
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	exchange, ok := r.lookup(req)
+	if !ok {
+		// 以404+错误结构返回，避免被当作网络错误反复重试
+		exchange = recordedExchange{
+			StatusCode: http.StatusNotFound,
+			Body:       fmt.Sprintf(`{"code":-1,"msg":"replay: 没有录制 %s?%s 的响应"}`, req.URL.Path, req.URL.RawQuery),
+		}
+	}
+
+	header := make(http.Header)
+	for k, v := range exchange.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(exchange.Body)),
+		ContentLength: int64(len(exchange.Body)),
+		Request:       req,
+	}, nil
+}
+
+// roundTripperFunc 把函数适配为http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// effectiveHTTPClient 返回叠加了录制/回放的HTTP客户端
+func (cfg clientConfig) effectiveHTTPClient() *http.Client {
+	if cfg.recorder == nil && cfg.replayer == nil {
+		return cfg.client
+	}
+
+	wrapped := *cfg.client
+	if cfg.replayer != nil {
+		wrapped.Transport = cfg.replayer
+		return &wrapped
+	}
+
+	next := wrapped.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped.Transport = cfg.recorder.wrap(next)
+	return &wrapped
+}
@@ -0,0 +1,144 @@
+package market
+
+import (
+	"math"
+	"sort"
+)
+
+// Regime 趋势状态
+type Regime string
+
+const (
+	RegimeStrongUp   Regime = "strong_up"
+	RegimeUp         Regime = "up"
+	RegimeRange      Regime = "range"
+	RegimeDown       Regime = "down"
+	RegimeStrongDown Regime = "strong_down"
+)
+
+// regimeScores 各状态的分值，用于多周期加权
+var regimeScores = map[Regime]float64{
+	RegimeStrongUp:   2,
+	RegimeUp:         1,
+	RegimeRange:      0,
+	RegimeDown:       -1,
+	RegimeStrongDown: -2,
+}
+
+// choppinessPeriod 震荡指数的周期
+const choppinessPeriod = 14
+
+// RegimeThresholds 趋势状态分类的阈值，见ClassifyRegime
+type RegimeThresholds struct {
+	ADXTrend  float64 // ADX14低于该值视为震荡，默认20
+	ADXStrong float64 // ADX14不低于该值（且震荡指数低于ChopTrend）视为强趋势，默认30
+	ChopRange float64 // 震荡指数高于该值视为震荡，默认61.8
+	ChopTrend float64 // 强趋势要求震荡指数低于该值，默认38.2
+}
+
+// DefaultRegimeThresholds 默认的趋势状态阈值
+var DefaultRegimeThresholds = RegimeThresholds{ADXTrend: 20, ADXStrong: 30, ChopRange: 61.8, ChopTrend: 38.2}
+
+// withDefaults 为0的阈值取默认值
+func (t RegimeThresholds) withDefaults() RegimeThresholds {
+	fill := func(v *float64, def float64) {
+		if *v == 0 {
+			*v = def
+		}
+	}
+	fill(&t.ADXTrend, DefaultRegimeThresholds.ADXTrend)
+	fill(&t.ADXStrong, DefaultRegimeThresholds.ADXStrong)
+	fill(&t.ChopRange, DefaultRegimeThresholds.ChopRange)
+	fill(&t.ChopTrend, DefaultRegimeThresholds.ChopTrend)
+	return t
+}
+
+// ChoppinessSeries 震荡指数：100×log10(Σ最近period根真实波幅 / (区间最高−区间最低)) / log10(period)，
+// 接近100为横盘震荡、接近0为单边趋势；前period个值为NaN，区间最高等于最低时为NaN
+func ChoppinessSeries(klines []Kline, period int) []float64 {
+	result := nanSeries(len(klines))
+	if period <= 1 || len(klines) <= period {
+		return result
+	}
+	trs := trueRanges(klines)
+	for i := period; i < len(klines); i++ {
+		sumTR := 0.0
+		highest, lowest := klines[i].High, klines[i].Low
+		for j := i - period + 1; j <= i; j++ {
+			sumTR += trs[j]
+			highest = math.Max(highest, klines[j].High)
+			lowest = math.Min(lowest, klines[j].Low)
+		}
+		if highest > lowest {
+			result[i] = 100 * math.Log10(sumTR/(highest-lowest)) / math.Log10(float64(period))
+		}
+	}
+	return result
+}
+
+// ClassifyRegime 按以下规则（依次判断）给出周期的趋势状态，EMA60、ADX14或震荡指数不可用（为0）时返回空字符串：
+//  1. 方向：EMA20在EMA60之上且回归斜率为正为上涨，两者都相反为下跌，不一致时为震荡
+//  2. ADX14低于ADXTrend，或震荡指数高于ChopRange时为震荡
+//  3. ADX14不低于ADXStrong且震荡指数低于ChopTrend时为强上涨/强下跌
+//  4. 其余为上涨/下跌
+func ClassifyRegime(m *TimeframeMetrics, t RegimeThresholds) Regime {
+	if m == nil || m.EMA60 == 0 || m.ADX14 == 0 || m.Choppiness14 == 0 {
+		return ""
+	}
+	direction := 0
+	switch {
+	case m.EMA20 > m.EMA60 && m.TrendSlope > 0:
+		direction = 1
+	case m.EMA20 < m.EMA60 && m.TrendSlope < 0:
+		direction = -1
+	}
+	if direction == 0 || m.ADX14 < t.ADXTrend || m.Choppiness14 > t.ChopRange {
+		return RegimeRange
+	}
+	strong := m.ADX14 >= t.ADXStrong && m.Choppiness14 < t.ChopTrend
+	switch {
+	case direction > 0 && strong:
+		return RegimeStrongUp
+	case direction > 0:
+		return RegimeUp
+	case strong:
+		return RegimeStrongDown
+	}
+	return RegimeDown
+}
+
+// blendRegimes 按√(周期分钟数)加权各周期的状态分值（越长的周期权重越大），返回加权分值（−2~2）与对应的状态
+// 分值≥1.5为强上涨，≥0.5为上涨，>−0.5为震荡，>−1.5为下跌，其余为强下跌；没有可用周期时返回0与空字符串
+func blendRegimes(timeframes map[string]*TimeframeMetrics) (Regime, float64) {
+	intervals := make([]string, 0, len(timeframes))
+	for interval := range timeframes {
+		intervals = append(intervals, interval)
+	}
+	sort.Strings(intervals) // 固定求和顺序，结果可复现
+
+	var sum, weights float64
+	for _, interval := range intervals {
+		tf := timeframes[interval]
+		if tf.Regime == "" {
+			continue
+		}
+		weight := math.Sqrt(intervalDuration(interval).Minutes())
+		sum += weight * regimeScores[tf.Regime]
+		weights += weight
+	}
+	if weights == 0 {
+		return "", 0
+	}
+	score := sum / weights
+	switch {
+	case score >= 1.5:
+		return RegimeStrongUp, score
+	case score >= 0.5:
+		return RegimeUp, score
+	case score > -0.5:
+		return RegimeRange, score
+	case score > -1.5:
+		return RegimeDown, score
+	}
+	return RegimeStrongDown, score
+}
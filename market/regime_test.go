@@ -0,0 +1,93 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifyRegime(t *testing.T) {
+	// 基准为健康的上涨：EMA20在EMA60之上、斜率为正、ADX 25、震荡指数50
+	metrics := func(ema20, slope, adx, chop float64) *TimeframeMetrics {
+		return &TimeframeMetrics{EMA20: ema20, EMA60: 100, TrendSlope: slope, ADX14: adx, Choppiness14: chop}
+	}
+	tests := []struct {
+		name string
+		m    *TimeframeMetrics
+		t    RegimeThresholds
+		want Regime
+	}{
+		{"nil metrics", nil, DefaultRegimeThresholds, ""},
+		{"EMA60 unavailable", &TimeframeMetrics{EMA20: 101, TrendSlope: 1, ADX14: 25, Choppiness14: 50}, DefaultRegimeThresholds, ""},
+		{"ADX unavailable", metrics(101, 1, 0, 50), DefaultRegimeThresholds, ""},
+		{"choppiness unavailable", metrics(101, 1, 25, 0), DefaultRegimeThresholds, ""},
+
+		{"up", metrics(101, 1, 25, 50), DefaultRegimeThresholds, RegimeUp},
+		{"down", metrics(99, -1, 25, 50), DefaultRegimeThresholds, RegimeDown},
+		{"strong up", metrics(101, 1, 35, 30), DefaultRegimeThresholds, RegimeStrongUp},
+		{"strong down", metrics(99, -1, 35, 30), DefaultRegimeThresholds, RegimeStrongDown},
+
+		// 规则1：EMA与斜率方向不一致
+		{"EMA up, slope down", metrics(101, -1, 35, 30), DefaultRegimeThresholds, RegimeRange},
+		{"EMA down, slope up", metrics(99, 1, 35, 30), DefaultRegimeThresholds, RegimeRange},
+		{"flat slope", metrics(101, 0, 35, 30), DefaultRegimeThresholds, RegimeRange},
+		{"EMA20 equals EMA60", metrics(100, 1, 35, 30), DefaultRegimeThresholds, RegimeRange},
+		// 规则2：ADX过低或震荡指数过高
+		{"ADX below trend", metrics(101, 1, 19.9, 30), DefaultRegimeThresholds, RegimeRange},
+		{"ADX at trend", metrics(101, 1, 20, 50), DefaultRegimeThresholds, RegimeUp},
+		{"chop above range", metrics(99, -1, 35, 61.9), DefaultRegimeThresholds, RegimeRange},
+		{"chop at range", metrics(99, -1, 25, 61.8), DefaultRegimeThresholds, RegimeDown},
+		// 规则3：强趋势需要ADX与震荡指数同时满足
+		{"ADX at strong", metrics(101, 1, 30, 30), DefaultRegimeThresholds, RegimeStrongUp},
+		{"strong ADX, chop at trend", metrics(101, 1, 35, 38.2), DefaultRegimeThresholds, RegimeUp},
+		{"low chop, ADX below strong", metrics(99, -1, 29.9, 30), DefaultRegimeThresholds, RegimeDown},
+
+		// 自定义阈值
+		{"custom ADX trend", metrics(101, 1, 25, 50), RegimeThresholds{ADXTrend: 26, ADXStrong: 40, ChopRange: 61.8, ChopTrend: 38.2}, RegimeRange},
+		{"custom strong", metrics(101, 1, 25, 45), RegimeThresholds{ADXTrend: 15, ADXStrong: 24, ChopRange: 70, ChopTrend: 50}, RegimeStrongUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyRegime(tt.m, tt.t); got != tt.want {
+				t.Errorf("ClassifyRegime = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegimeThresholdsWithDefaults(t *testing.T) {
+	if got := (RegimeThresholds{}).withDefaults(); got != DefaultRegimeThresholds {
+		t.Errorf("zero thresholds = %+v, want defaults %+v", got, DefaultRegimeThresholds)
+	}
+	got := RegimeThresholds{ADXStrong: 40}.withDefaults()
+	if want := (RegimeThresholds{ADXTrend: 20, ADXStrong: 40, ChopRange: 61.8, ChopTrend: 38.2}); got != want {
+		t.Errorf("partial thresholds = %+v, want %+v", got, want)
+	}
+}
+
+func TestBlendRegimes(t *testing.T) {
+	tf := func(r Regime) *TimeframeMetrics { return &TimeframeMetrics{Regime: r} }
+	tests := []struct {
+		name       string
+		timeframes map[string]*TimeframeMetrics
+		want       Regime
+		wantScore  float64
+	}{
+		{"none", nil, "", 0},
+		{"no classified timeframe", map[string]*TimeframeMetrics{"1h": tf("")}, "", 0},
+		{"single", map[string]*TimeframeMetrics{"4h": tf(RegimeStrongDown)}, RegimeStrongDown, -2},
+		// 权重√15与√240=4√15：(2 − 4)/5 = −0.4，落在震荡区间
+		{"higher timeframe dominates", map[string]*TimeframeMetrics{"15m": tf(RegimeStrongUp), "4h": tf(RegimeDown)}, RegimeRange, -0.4},
+		// 权重√1与√60：(1×(−2) + 7.75×2)/8.75 ≈ 1.543
+		{"strong up", map[string]*TimeframeMetrics{"1m": tf(RegimeStrongDown), "1h": tf(RegimeStrongUp), "3m": tf("")}, RegimeStrongUp, (2*math.Sqrt(60) - 2) / (math.Sqrt(60) + 1)},
+		// 权重√60与√240=2√60：1/3，不足0.5仍为震荡
+		{"up outweighed by range", map[string]*TimeframeMetrics{"1h": tf(RegimeUp), "4h": tf(RegimeRange)}, RegimeRange, math.Sqrt(60) / (math.Sqrt(60) + math.Sqrt(240))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, score := blendRegimes(tt.timeframes)
+			if got != tt.want || !approxEqual(score, tt.wantScore) {
+				t.Errorf("blendRegimes = %q, %v, want %q, %v", got, score, tt.want, tt.wantScore)
+			}
+		})
+	}
+}
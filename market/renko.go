@@ -0,0 +1,94 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidBrickSize 砖块大小必须为正数
+var ErrInvalidBrickSize = errors.New("砖块大小无效")
+
+// renkoIntervals 计算砖形图摘要的周期，用于过滤1m/3m的噪声
+var renkoIntervals = map[string]bool{"1m": true, "3m": true}
+
+// Brick 一块砖形图砖块
+type Brick struct {
+	OpenTime  int64   `json:"open_time"` // 生成该砖块的K线的开盘时间，一根K线可以生成多块砖
+	Open      float64 `json:"open"`
+	Close     float64 `json:"close"`
+	Direction int     `json:"direction"` // +1 上涨砖，−1 下跌砖
+}
+
+// RenkoBricks 按收盘价构建砖形图：以第一根K线的收盘价为基准，
+// 收盘价顺势每推进一个brickSize生成一块砖，反向需越过上一块砖的开盘价再一个brickSize才生成反向砖（即反转需要两块砖的幅度）。
+// 单根K线跳空多块砖时一次生成多块，OpenTime都为该K线的开盘时间；不足一块砖的波动不生成砖块
+func RenkoBricks(klines []Kline, brickSize float64) ([]Brick, error) {
+	if !(brickSize > 0) {
+		return nil, fmt.Errorf("%w: %g", ErrInvalidBrickSize, brickSize)
+	}
+	if len(klines) == 0 {
+		return nil, nil
+	}
+
+	var bricks []Brick
+	// top/bottom为最后一块砖的上下沿，还没有砖时都为基准价
+	top, bottom := klines[0].Close, klines[0].Close
+	for _, k := range klines[1:] {
+		// 上涨砖从上沿起算；上一块为下跌砖时上沿即其开盘价，因此反转自然需要两块砖的幅度
+		for k.Close >= top+brickSize {
+			bricks = append(bricks, Brick{OpenTime: k.OpenTime, Open: top, Close: top + brickSize, Direction: 1})
+			bottom, top = top, top+brickSize
+		}
+		for k.Close <= bottom-brickSize {
+			bricks = append(bricks, Brick{OpenTime: k.OpenTime, Open: bottom, Close: bottom - brickSize, Direction: -1})
+			top, bottom = bottom, bottom-brickSize
+		}
+	}
+	return bricks, nil
+}
+
+// ATRBrickSize 用最后一根K线的ATR14作为砖块大小，ATR不可用时返回包装了ErrInsufficientData的错误
+func ATRBrickSize(klines []Kline) (float64, error) {
+	atr, ok := calculateATR(klines, IndicatorConfig{Period: 14})
+	if !ok || atr <= 0 {
+		return 0, fmt.Errorf("%w: ATR14至少需要15根K线，实际%d根", ErrInsufficientData, len(klines))
+	}
+	return atr, nil
+}
+
+// RenkoSummary 砖形图摘要
+type RenkoSummary struct {
+	BrickSize        float64 `json:"brick_size"`
+	Bricks           int     `json:"bricks"`            // 整个窗口生成的砖块数
+	Direction        int     `json:"direction"`         // 最后一块砖的方向，没有砖时为0
+	RunLength        int     `json:"run_length"`        // 当前连续同向的砖块数
+	DirectionChanges int     `json:"direction_changes"` // 窗口内砖块方向反转的次数
+}
+
+// SummarizeRenko 统计砖块的当前方向、连续根数与反转次数
+func SummarizeRenko(bricks []Brick, brickSize float64) RenkoSummary {
+	summary := RenkoSummary{BrickSize: brickSize, Bricks: len(bricks)}
+	for i, brick := range bricks {
+		if i > 0 && brick.Direction != bricks[i-1].Direction {
+			summary.DirectionChanges++
+			summary.RunLength = 0
+		}
+		summary.RunLength++
+		summary.Direction = brick.Direction
+	}
+	return summary
+}
+
+// calculateRenko 以ATR14为砖块大小计算砖形图摘要，无法计算时为nil
+func calculateRenko(klines []Kline) *RenkoSummary {
+	size, err := ATRBrickSize(klines)
+	if err != nil {
+		return nil
+	}
+	bricks, err := RenkoBricks(klines, size)
+	if err != nil {
+		return nil
+	}
+	summary := SummarizeRenko(bricks, size)
+	return &summary
+}
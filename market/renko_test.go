@@ -0,0 +1,53 @@
+package market
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRenkoBricksMultiBrickBars(t *testing.T) {
+	closes := []float64{100, 135, 125, 95, 60}
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = Kline{OpenTime: int64(i), Close: c}
+	}
+	bricks, err := RenkoBricks(klines, 10)
+	if err != nil {
+		t.Fatalf("RenkoBricks: %v", err)
+	}
+	want := []Brick{
+		// 100→135：一根K线推进3.5块，生成3块上涨砖，余下的0.5块不生成
+		{OpenTime: 1, Open: 100, Close: 110, Direction: 1},
+		{OpenTime: 1, Open: 110, Close: 120, Direction: 1},
+		{OpenTime: 1, Open: 120, Close: 130, Direction: 1},
+		// 125未跌破120−10，不生成；95从上一块的开盘价120起算反向生成2块
+		{OpenTime: 3, Open: 120, Close: 110, Direction: -1},
+		{OpenTime: 3, Open: 110, Close: 100, Direction: -1},
+		// 60恰好落在第4块的下沿，边界上也生成
+		{OpenTime: 4, Open: 100, Close: 90, Direction: -1},
+		{OpenTime: 4, Open: 90, Close: 80, Direction: -1},
+		{OpenTime: 4, Open: 80, Close: 70, Direction: -1},
+		{OpenTime: 4, Open: 70, Close: 60, Direction: -1},
+	}
+	if !slices.Equal(bricks, want) {
+		t.Fatalf("bricks = %+v\nwant %+v", bricks, want)
+	}
+
+	got := SummarizeRenko(bricks, 10)
+	if want := (RenkoSummary{BrickSize: 10, Bricks: 9, Direction: -1, RunLength: 6, DirectionChanges: 1}); got != want {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+}
+
+func TestRenkoBricksInvalidSize(t *testing.T) {
+	for _, size := range []float64{0, -1, math.NaN()} {
+		if _, err := RenkoBricks([]Kline{{Close: 1}}, size); !errors.Is(err, ErrInvalidBrickSize) {
+			t.Errorf("size %v: err = %v, want ErrInvalidBrickSize", size, err)
+		}
+	}
+	if bricks, err := RenkoBricks(nil, 1); err != nil || bricks != nil {
+		t.Errorf("no klines: got %v, %v, want nil, nil", bricks, err)
+	}
+}
@@ -0,0 +1,151 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Section 市场数据中可以单独失败的分区
+type Section string
+
+const (
+	SectionOpenInterest   Section = "open_interest"
+	SectionFunding        Section = "funding"
+	SectionMicrostructure Section = "microstructure"
+	SectionBenchmark      Section = "benchmark" // 与BTC的相关性与beta，见WithBTCCorrelation
+	SectionSentiment      Section = "sentiment" // 多空比与主动买卖量比，见WithSentiment
+	SectionTicker         Section = "ticker"    // 24小时行情统计，见WithTicker24h
+	// SectionKlines K线校验与缺口修复，只产生警告，K线获取失败时Get直接返回错误
+	SectionKlines Section = "klines"
+)
+
+// SectionError 某个分区（或分区内某个子请求）的失败原因
+type SectionError struct {
+	Section Section
+	Detail  string // 具体失败的子请求，如 "openInterestHist 5m"
+	Err     error
+}
+
+func (e *SectionError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s (%s): %v", e.Section, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Section, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// FetchReport 一次获取中各分区的失败情况
+// Warnings 记录所有被容忍的失败；Unavailable 记录整体不可用的分区（其数据为零值，不可信）
+type FetchReport struct {
+	mu          sync.Mutex
+	Symbol      string
+	Warnings    []error
+	Unavailable []Section
+	logger      Logger
+}
+
+// OK 所有分区均获取成功
+func (r *FetchReport) OK() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.Warnings) == 0
+}
+
+// Failed 判断分区是否整体不可用
+func (r *FetchReport) Failed(section Section) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.Unavailable {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Err 将所有警告合并为一个错误，没有警告时返回nil
+func (r *FetchReport) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.Warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s 部分数据获取失败: %w", r.Symbol, errors.Join(r.Warnings...))
+}
+
+// strictErr WithStrict使用的错误：合并分区失败的警告，K线校验与修复的警告（SectionKlines）不算失败
+func (r *FetchReport) strictErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failures []error
+	for _, err := range r.Warnings {
+		var sectionErr *SectionError
+		if errors.As(err, &sectionErr) && sectionErr.Section == SectionKlines {
+			continue
+		}
+		failures = append(failures, err)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s 部分数据获取失败: %w", r.Symbol, errors.Join(failures...))
+}
+
+// warn 记录分区内某个子请求的失败（分区仍有部分数据可用）
+func (r *FetchReport) warn(section Section, detail string, err error) {
+	if r.logger != nil {
+		r.logger.Warnf("分区获取失败(已容忍) symbol=%s section=%s detail=%q err=%v", r.Symbol, section, detail, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Warnings = append(r.Warnings, &SectionError{Section: section, Detail: detail, Err: err})
+}
+
+// fail 记录分区整体不可用
+func (r *FetchReport) fail(section Section, detail string, err error) {
+	r.warn(section, detail, err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Unavailable = append(r.Unavailable, section)
+}
+
+// unavailableSections 返回不可用分区的副本，供Data使用
+func (r *FetchReport) unavailableSections() []Section {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.Unavailable) == 0 {
+		return nil
+	}
+	return append([]Section(nil), r.Unavailable...)
+}
+
+// GetPartial 使用默认客户端获取市场数据，并返回各分区的失败情况
+func GetPartial(ctx context.Context, symbol string, opts ...GetOption) (*Data, *FetchReport, error) {
+	return defaultClient.GetPartial(ctx, symbol, opts...)
+}
+
+// GetPartial 获取市场数据，尽可能组装出Data，同时返回各分区的失败情况
+// 只有K线获取失败（无法计算任何指标）、限频或ctx结束时才返回error
+func (c *Client) GetPartial(ctx context.Context, symbol string, opts ...GetOption) (*Data, *FetchReport, error) {
+	o := c.newGetOptions(opts)
+	symbol, err := o.normalize(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.getWithReport(ctx, symbol, o)
+}
+
+// Unavailable 判断分区是否因获取失败而不可用（字段为零值）
+func (d *Data) Unavailable(section Section) bool {
+	for _, s := range d.UnavailableSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
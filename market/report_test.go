@@ -0,0 +1,60 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetToleratesOpenInterestFailure(t *testing.T) {
+	fake := &fakeBinance{}
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/openInterest") {
+			http.Error(w, `{"code":-1000,"msg":"internal error"}`, http.StatusInternalServerError)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	logger := &recordingLogger{}
+	c := newRESTClient(srv, WithLogger(logger))
+
+	data, report, err := c.GetPartial(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetPartial: %v", err)
+	}
+	if !data.Unavailable(SectionOpenInterest) || !report.Failed(SectionOpenInterest) {
+		t.Errorf("UnavailableSections = %v, want %s", data.UnavailableSections, SectionOpenInterest)
+	}
+	if data.Timeframes["3m"] == nil || data.Funding == nil {
+		t.Error("other sections missing after an OI failure")
+	}
+
+	var sectionErr *SectionError
+	var apiErr *APIError
+	if len(report.Warnings) != 1 || !errors.As(report.Warnings[0], &sectionErr) || sectionErr.Section != SectionOpenInterest {
+		t.Fatalf("Warnings = %v, want one open_interest SectionError", report.Warnings)
+	}
+	if !errors.As(sectionErr, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("warning = %v, want wrapping HTTP 500 *APIError", sectionErr)
+	}
+
+	warned := false
+	for _, msg := range logger.Warns() {
+		if strings.Contains(msg, "section=open_interest") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("logger warnings = %q, want one for section=open_interest", logger.Warns())
+	}
+
+	// 默认模式下Get同样成功，WithStrict时返回错误
+	if _, err := c.Get(context.Background(), "BTCUSDT", WithoutCache()); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "BTCUSDT", WithoutCache(), WithStrict()); err == nil {
+		t.Error("Get with WithStrict succeeded, want error for the failed OI section")
+	}
+}
@@ -0,0 +1,151 @@
+package market
+
+import "math"
+
+// ScoreWeights 综合评分各分项的权重，只看相对大小；不可用的分项不参与加权
+type ScoreWeights struct {
+	RSI          float64 // 多周期RSI14相对50的位置
+	MACD         float64 // 多周期MACD柱状图的方向
+	EMAAlignment float64 // 多周期收盘价/EMA20/EMA60的排列
+	OpenInterest float64 // 1h持仓量变化与价格变化的配合
+	Funding      float64 // 资金费率的极端程度（反向）
+	CVD          float64 // 3m/15m主动买卖差的方向
+	Momentum24h  float64 // 24小时涨跌幅（Ticker24h，需WithTicker24h）
+}
+
+// DefaultScoreWeights 默认的综合评分权重
+var DefaultScoreWeights = ScoreWeights{RSI: 20, MACD: 20, EMAAlignment: 25, OpenInterest: 15, Funding: 10, CVD: 10, Momentum24h: 10}
+
+// 综合评分的归一化参数
+const (
+	scoreOIScalePct     = 2      // 1h持仓量变化2%时OI分项约为±0.76（tanh(1)）
+	scoreFundingExtreme = 0.0005 // 资金费率0.05%时资金费率分项约为∓0.76
+	scoreMomentumPct    = 5      // 24小时涨跌5%时24小时动量分项约为±0.76
+)
+
+// scoreIntervals 参与多周期分项的周期，按固定顺序遍历保证结果可复现
+var scoreIntervals = []string{"1m", "3m", "15m", "1h", "4h"}
+
+// ScoreComponent 综合评分的一个分项
+type ScoreComponent struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`        // 归一化后的分项值，−1（看空）~ 1（看多）
+	Weight       float64 `json:"weight"`       // 配置的权重
+	Contribution float64 `json:"contribution"` // 对总分的贡献，各分项之和等于Total
+	Available    bool    `json:"available"`    // 数据不可用时为false，不参与加权
+}
+
+// Score 综合动量/质量评分
+type Score struct {
+	Total      float64          `json:"total"` // −100 ~ 100，没有可用分项时为0
+	Components []ScoreComponent `json:"components"`
+	// QuoteVolume24h 24小时成交额（Ticker24h），排序时可用来排除流动性不足的币种；未获取ticker时为0
+	QuoteVolume24h float64 `json:"quote_volume_24h"`
+}
+
+// Score 把多周期RSI、MACD柱状图方向、EMA排列、持仓量变化、资金费率、CVD与24小时涨跌幅归一化到−1~1后按权重加权，
+// 得到−100~100的综合评分，并给出每个分项的贡献。各分项的归一化方式：
+//   - RSI：各周期(RSI14−50)/50的平均
+//   - MACD：各周期柱状图符号（+1/−1/0）的平均
+//   - EMAAlignment：各周期 ½·sign(收盘−EMA20) + ½·sign(EMA20−EMA60) 的平均
+//   - OpenInterest：tanh(1h持仓量变化% / 2) × sign(1h价格变化)，增仓上涨为正、增仓下跌为负
+//   - Funding：−tanh(费率 / 0.05%)，多头拥挤时为负
+//   - CVD：sign(CVD3m)与sign(CVD15m)的平均
+//   - Momentum24h：tanh(24小时涨跌幅% / 5)，只在WithTicker24h时可用
+//
+// 同样的Data总是得到同样的结果，可直接用于多个币种的排序
+func (d *Data) Score(weights ScoreWeights) Score {
+	components := []ScoreComponent{
+		d.timeframeComponent("rsi", weights.RSI, func(tf *TimeframeMetrics) (float64, bool) {
+			if tf.RSI14 == 0 {
+				return 0, false
+			}
+			return (tf.RSI14 - 50) / 50, true
+		}),
+		d.timeframeComponent("macd", weights.MACD, func(tf *TimeframeMetrics) (float64, bool) {
+			return sign(tf.MACDHistogram), tf.MACDSignal != 0
+		}),
+		d.timeframeComponent("ema_alignment", weights.EMAAlignment, func(tf *TimeframeMetrics) (float64, bool) {
+			if tf.EMA60 == 0 {
+				return 0, false
+			}
+			return 0.5*sign(tf.Close-tf.EMA20) + 0.5*sign(tf.EMA20-tf.EMA60), true
+		}),
+		{Name: "open_interest", Weight: weights.OpenInterest},
+		{Name: "funding", Weight: weights.Funding},
+		{Name: "cvd", Weight: weights.CVD},
+		{Name: "momentum_24h", Weight: weights.Momentum24h},
+	}
+
+	if oi := d.OpenInterest; oi != nil && !d.Unavailable(SectionOpenInterest) && oi.Latest > 0 {
+		components[3].Value = math.Tanh(oi.Delta1h/oi.Latest*100/scoreOIScalePct) * sign(oi.PriceDelta1h)
+		components[3].Available = true
+	}
+	if d.Funding != nil && !d.Unavailable(SectionFunding) {
+		components[4].Value = -math.Tanh(d.Funding.Rate / scoreFundingExtreme)
+		components[4].Available = true
+	}
+	if m := d.Microstructure; m != nil && !d.Unavailable(SectionMicrostructure) {
+		components[5].Value = (sign(m.CVD3m) + sign(m.CVD15m)) / 2
+		components[5].Available = true
+	}
+	if t := d.Ticker24h; t != nil && !d.Unavailable(SectionTicker) {
+		components[6].Value = math.Tanh(t.PriceChangePercent / scoreMomentumPct)
+		components[6].Available = true
+	}
+
+	totalWeight := 0.0
+	for _, c := range components {
+		if c.Available && c.Weight > 0 {
+			totalWeight += c.Weight
+		}
+	}
+	score := Score{Components: components}
+	if d.Ticker24h != nil {
+		score.QuoteVolume24h = d.Ticker24h.QuoteVolume
+	}
+	if totalWeight == 0 {
+		return score
+	}
+	for i := range components {
+		c := &components[i]
+		if !c.Available || c.Weight <= 0 {
+			continue
+		}
+		c.Contribution = 100 * c.Weight * c.Value / totalWeight
+		score.Total += c.Contribution
+	}
+	return score
+}
+
+// timeframeComponent 对scoreIntervals中已计算且value可用的周期取平均，得到一个分项
+func (d *Data) timeframeComponent(name string, weight float64, value func(*TimeframeMetrics) (float64, bool)) ScoreComponent {
+	component := ScoreComponent{Name: name, Weight: weight}
+	sum, n := 0.0, 0
+	for _, interval := range scoreIntervals {
+		tf, ok := d.Timeframes[interval]
+		if !ok || tf == nil {
+			continue
+		}
+		if v, ok := value(tf); ok {
+			sum += math.Max(-1, math.Min(1, v))
+			n++
+		}
+	}
+	if n > 0 {
+		component.Value = sum / float64(n)
+		component.Available = true
+	}
+	return component
+}
+
+// sign 符号函数：正数为1，负数为−1，0（或NaN）为0
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package market
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// scoreData 构造评分输入：dir为+1时各分项全部看多，−1时全部看空；资金费率为0
+func scoreData(dir float64) *Data {
+	tf := func() *TimeframeMetrics {
+		return &TimeframeMetrics{
+			RSI14:         50 + 25*dir,
+			MACDHistogram: dir, MACDSignal: 1,
+			Close: 100 + 2*dir, EMA20: 100 + dir, EMA60: 100,
+		}
+	}
+	return &Data{
+		Timeframes:     map[string]*TimeframeMetrics{"1h": tf(), "4h": tf()},
+		OpenInterest:   &OIData{Latest: 1000, Delta1h: 20, PriceDelta1h: dir}, // 1h增仓2%，价格同向
+		Funding:        &FundingData{Rate: 0},
+		Microstructure: &MicrostructureData{CVD3m: dir, CVD15m: dir},
+	}
+}
+
+func TestScoreOnSyntheticInputs(t *testing.T) {
+	// RSI 0.5、MACD 1、EMA排列 1、OI tanh(1)、资金费率 0、CVD 1，权重合计100
+	bullish := 20*0.5 + 20 + 25 + 15*math.Tanh(1) + 10
+	tests := []struct {
+		name string
+		data *Data
+		want float64
+	}{
+		{"bullish", scoreData(1), bullish},
+		// 增仓下跌时OI分项为负，各分项与看多对称
+		{"bearish", scoreData(-1), -bullish},
+		{"empty", &Data{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := tt.data.Score(DefaultScoreWeights)
+			if !approxEqual(score.Total, tt.want) {
+				t.Errorf("Total = %v, want %v", score.Total, tt.want)
+			}
+			sum := 0.0
+			for _, c := range score.Components {
+				sum += c.Contribution
+			}
+			if !approxEqual(sum, score.Total) {
+				t.Errorf("sum of contributions = %v, want Total %v", sum, score.Total)
+			}
+		})
+	}
+}
+
+func TestScoreComponents(t *testing.T) {
+	score := scoreData(1).Score(DefaultScoreWeights)
+	want := map[string]float64{"rsi": 0.5, "macd": 1, "ema_alignment": 1, "open_interest": math.Tanh(1), "funding": 0, "cvd": 1}
+	// 没有ticker时24小时动量不可用
+	if len(score.Components) != len(want)+1 {
+		t.Fatalf("components = %+v, want %d", score.Components, len(want)+1)
+	}
+	for _, c := range score.Components[:len(want)] {
+		if w, ok := want[c.Name]; !ok || !c.Available || !approxEqual(c.Value, w) {
+			t.Errorf("component %s = %+v, want available with value %v", c.Name, c, w)
+		}
+	}
+	if m := score.Components[6]; m.Name != "momentum_24h" || m.Available || m.Contribution != 0 {
+		t.Errorf("momentum_24h = %+v, want unavailable without a ticker", m)
+	}
+
+	// 不可用的分区不参与加权，其余分项按剩余权重（85）重新归一化
+	data := scoreData(1)
+	data.UnavailableSections = []Section{SectionOpenInterest}
+	score = data.Score(DefaultScoreWeights)
+	if want := 100 * (20*0.5 + 20 + 25 + 10) / 85; !approxEqual(score.Total, want) {
+		t.Errorf("Total without OI = %v, want %v", score.Total, want)
+	}
+	if oi := score.Components[3]; oi.Available || oi.Contribution != 0 {
+		t.Errorf("open_interest = %+v, want unavailable with no contribution", oi)
+	}
+
+	// 资金费率0.05%（多头拥挤）时分项为−tanh(1)
+	data = scoreData(1)
+	data.Funding.Rate = scoreFundingExtreme
+	if got := data.Score(DefaultScoreWeights).Components[4].Value; !approxEqual(got, -math.Tanh(1)) {
+		t.Errorf("funding component = %v, want %v", got, -math.Tanh(1))
+	}
+
+	// 只有一个分项有权重时，总分为该分项值×100
+	if got := scoreData(1).Score(ScoreWeights{RSI: 1}).Total; !approxEqual(got, 50) {
+		t.Errorf("RSI-only Total = %v, want 50", got)
+	}
+	if got := scoreData(1).Score(ScoreWeights{}).Total; got != 0 {
+		t.Errorf("zero weights Total = %v, want 0", got)
+	}
+}
+
+func TestScoreTicker24h(t *testing.T) {
+	data := scoreData(1)
+	data.Ticker24h = &Ticker24h{PriceChangePercent: -scoreMomentumPct, QuoteVolume: 2.5e9}
+	score := data.Score(DefaultScoreWeights)
+	if m := score.Components[6]; !m.Available || !approxEqual(m.Value, -math.Tanh(1)) {
+		t.Errorf("momentum_24h = %+v, want available with value %v", m, -math.Tanh(1))
+	}
+	// 权重合计110，24小时下跌5%拉低总分
+	bullish := 20*0.5 + 20 + 25 + 15*math.Tanh(1) + 10
+	if want := 100 * (bullish - 10*math.Tanh(1)) / 110; !approxEqual(score.Total, want) {
+		t.Errorf("Total = %v, want %v", score.Total, want)
+	}
+	if score.QuoteVolume24h != 2.5e9 {
+		t.Errorf("QuoteVolume24h = %v, want 2.5e9", score.QuoteVolume24h)
+	}
+
+	data.UnavailableSections = []Section{SectionTicker}
+	if m := data.Score(DefaultScoreWeights).Components[6]; m.Available {
+		t.Errorf("momentum_24h = %+v, want unavailable when the ticker section failed", m)
+	}
+}
+
+func TestScoreIsDeterministic(t *testing.T) {
+	data := scoreData(1)
+	data.Timeframes["1m"] = &TimeframeMetrics{RSI14: 31.7, MACDHistogram: -0.3, MACDSignal: 2, Close: 99, EMA20: 100.3, EMA60: 100.1}
+	data.Timeframes["15m"] = &TimeframeMetrics{RSI14: 58.3, MACDHistogram: 0.1, MACDSignal: -1, Close: 101, EMA20: 100.7, EMA60: 100.9}
+	first := data.Score(DefaultScoreWeights)
+	for range 50 {
+		if got := data.Score(DefaultScoreWeights); !reflect.DeepEqual(got, first) {
+			t.Fatalf("Score = %+v, want %+v on every call", got, first)
+		}
+	}
+
+	// 排序：看多 > 中性 > 看空
+	neutral := scoreData(0).Score(DefaultScoreWeights).Total
+	if bull, bear := scoreData(1).Score(DefaultScoreWeights).Total, scoreData(-1).Score(DefaultScoreWeights).Total; !(bull > neutral && neutral > bear) {
+		t.Errorf("scores bull %v, neutral %v, bear %v, want strictly decreasing", bull, neutral, bear)
+	}
+}
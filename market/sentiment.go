@@ -0,0 +1,360 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LongShortKind 币安多空比接口的种类，取值即/futures/data下的接口名
+type LongShortKind string
+
+const (
+	// GlobalAccountRatio 全部账户的多空人数比
+	GlobalAccountRatio LongShortKind = "globalLongShortAccountRatio"
+	// TopAccountRatio 大户（保证金余额前20%）的多空账户数比
+	TopAccountRatio LongShortKind = "topLongShortAccountRatio"
+	// TopPositionRatio 大户的多空持仓量比
+	TopPositionRatio LongShortKind = "topLongShortPositionRatio"
+)
+
+// longShortKinds 情绪数据拉取的多空比种类
+var longShortKinds = []LongShortKind{GlobalAccountRatio, TopAccountRatio, TopPositionRatio}
+
+// 情绪数据拉取的周期与每个周期的点数（5m的24个点覆盖2小时，足够计算1小时变化）
+var sentimentPeriods = []string{"5m", "1h"}
+
+const sentimentHistoryLimit = 24
+
+// takerPeriods 主动买卖量比拉取的周期
+var takerPeriods = []string{"5m", "15m"}
+
+// LongShortPoint 某一时刻的多空比
+type LongShortPoint struct {
+	Ratio     float64 `json:"ratio"` // 多/空
+	Long      float64 `json:"long"`  // 多头占比（0~1）
+	Short     float64 `json:"short"` // 空头占比（0~1）
+	Timestamp int64   `json:"timestamp_ms"`
+}
+
+// TakerVolumePoint 一个周期内全市场的主动买入与主动卖出成交量（基础资产数量）
+type TakerVolumePoint struct {
+	BuySellRatio float64 `json:"buy_sell_ratio"` // 主动买入量/主动卖出量
+	BuyVolume    float64 `json:"buy_volume"`
+	SellVolume   float64 `json:"sell_volume"`
+	Timestamp    int64   `json:"timestamp_ms"`
+}
+
+// SentimentSource 可以获取多空比与主动买卖量的数据源，币安REST数据源与FakeSource实现了该接口
+// 未实现时WithSentiment的情绪分区标记为不可用
+type SentimentSource interface {
+	// LongShortRatio 获取period周期的kind多空比历史，按时间升序
+	LongShortRatio(ctx context.Context, symbol string, kind LongShortKind, period string, limit int) ([]LongShortPoint, error)
+	// TakerVolume 获取period周期的主动买卖量历史，按时间升序
+	TakerVolume(ctx context.Context, symbol, period string, limit int) ([]TakerVolumePoint, error)
+}
+
+// ErrSentimentUnsupported 数据源不支持多空比
+var ErrSentimentUnsupported = errors.New("数据源不支持多空比")
+
+// LongShortStats 一个周期的多空比
+type LongShortStats struct {
+	Ratio       float64   `json:"ratio"`
+	Long        float64   `json:"long"`
+	Short       float64   `json:"short"`
+	Change1h    float64   `json:"change_1h"`    // 与1小时前（不晚于最新点1小时的最近一点）的比值之差，历史不足1小时时为0
+	Values      []float64 `json:"values"`       // 最近10个点的比值，从旧到新
+	TimestampMs int64     `json:"timestamp_ms"` // 最新点的时间
+}
+
+// TakerStats 一个周期的主动买卖量比
+// 来自交易所按周期汇总的全部成交，不像Microstructure的CVD只覆盖最近1000笔归集成交
+type TakerStats struct {
+	BuySellRatio float64   `json:"buy_sell_ratio"`
+	BuyVolume    float64   `json:"buy_volume"`
+	SellVolume   float64   `json:"sell_volume"`
+	Trend        float64   `json:"trend"`        // 最近10个点比值的回归斜率（每个周期），正值表示主动买盘在增强
+	Values       []float64 `json:"values"`       // 最近10个点的比值，从旧到新
+	TimestampMs  int64     `json:"timestamp_ms"` // 最新点的时间
+}
+
+// SentimentData 多空比与主动买卖量比，各map的key为周期（多空比为5m、1h，主动买卖为5m、15m），获取失败的周期缺失
+type SentimentData struct {
+	GlobalAccount map[string]*LongShortStats `json:"global_account"` // 全部账户多空人数比
+	TopAccount    map[string]*LongShortStats `json:"top_account"`    // 大户多空账户数比
+	TopPosition   map[string]*LongShortStats `json:"top_position"`   // 大户多空持仓量比
+	Taker         map[string]*TakerStats     `json:"taker"`          // 主动买卖量比
+}
+
+// byKind 返回kind对应的map
+func (s *SentimentData) byKind(kind LongShortKind) map[string]*LongShortStats {
+	switch kind {
+	case GlobalAccountRatio:
+		return s.GlobalAccount
+	case TopAccountRatio:
+		return s.TopAccount
+	default:
+		return s.TopPosition
+	}
+}
+
+// summarizeLongShort 由按时间升序的多空比历史计算最新值与1小时变化，没有数据时返回nil
+func summarizeLongShort(points []LongShortPoint) *LongShortStats {
+	if len(points) == 0 {
+		return nil
+	}
+	last := points[len(points)-1]
+	stats := &LongShortStats{
+		Ratio:       last.Ratio,
+		Long:        last.Long,
+		Short:       last.Short,
+		TimestampMs: last.Timestamp,
+	}
+	cutoff := last.Timestamp - time.Hour.Milliseconds()
+	for i := len(points) - 2; i >= 0; i-- {
+		if points[i].Timestamp <= cutoff {
+			stats.Change1h = last.Ratio - points[i].Ratio
+			break
+		}
+	}
+	start := max(len(points)-seriesPoints, 0)
+	stats.Values = make([]float64, 0, len(points)-start)
+	for _, p := range points[start:] {
+		stats.Values = append(stats.Values, p.Ratio)
+	}
+	return stats
+}
+
+// summarizeTaker 由按时间升序的主动买卖量历史计算最新比值与趋势，没有数据时返回nil
+func summarizeTaker(points []TakerVolumePoint) *TakerStats {
+	if len(points) == 0 {
+		return nil
+	}
+	last := points[len(points)-1]
+	start := max(len(points)-seriesPoints, 0)
+	values := make([]float64, 0, len(points)-start)
+	for _, p := range points[start:] {
+		values = append(values, p.BuySellRatio)
+	}
+	return &TakerStats{
+		BuySellRatio: last.BuySellRatio,
+		BuyVolume:    last.BuyVolume,
+		SellVolume:   last.SellVolume,
+		Trend:        fitLine(nil, values).slope,
+		Values:       values,
+		TimestampMs:  last.Timestamp,
+	}
+}
+
+// cachedLongShortRatio 带缓存的多空比获取，按period使用同周期K线的缓存时间
+func (c *Client) cachedLongShortRatio(ctx context.Context, o getOptions, src SentimentSource, symbol string, kind LongShortKind, period string) ([]LongShortPoint, error) {
+	key := fmt.Sprintf("lsr|%s|%s|%s|%d", symbol, kind, period, sentimentHistoryLimit)
+	return cachedFetch(c, key, c.config().cacheTTL(period), o.bypassCache, func() ([]LongShortPoint, error) {
+		return src.LongShortRatio(ctx, symbol, kind, period, sentimentHistoryLimit)
+	})
+}
+
+// cachedTakerVolume 带缓存的主动买卖量获取
+func (c *Client) cachedTakerVolume(ctx context.Context, o getOptions, src SentimentSource, symbol, period string) ([]TakerVolumePoint, error) {
+	key := fmt.Sprintf("taker|%s|%s|%d", symbol, period, sentimentHistoryLimit)
+	return cachedFetch(c, key, c.config().cacheTTL(period), o.bypassCache, func() ([]TakerVolumePoint, error) {
+		return src.TakerVolume(ctx, symbol, period, sentimentHistoryLimit)
+	})
+}
+
+// getSentimentData 获取各种多空比在5m与1h周期、主动买卖量比在5m与15m周期的数据
+// 单个子请求失败记为SectionSentiment的警告，全部失败（或数据源不支持）时返回错误，限频错误直接返回
+func (c *Client) getSentimentData(ctx context.Context, o getOptions, report *FetchReport, symbol string) (*SentimentData, error) {
+	src, ok := c.source().(SentimentSource)
+	if !ok {
+		return nil, ErrSentimentUnsupported
+	}
+	data := &SentimentData{
+		GlobalAccount: make(map[string]*LongShortStats, len(sentimentPeriods)),
+		TopAccount:    make(map[string]*LongShortStats, len(sentimentPeriods)),
+		TopPosition:   make(map[string]*LongShortStats, len(sentimentPeriods)),
+		Taker:         make(map[string]*TakerStats, len(takerPeriods)),
+	}
+	var lastErr error
+	fetched := 0
+	for _, kind := range longShortKinds {
+		for _, period := range sentimentPeriods {
+			points, err := c.cachedLongShortRatio(ctx, o, src, symbol, kind, period)
+			if err != nil {
+				if IsRateLimited(err) {
+					return nil, err
+				}
+				report.warn(SectionSentiment, string(kind)+" "+period, err)
+				lastErr = err
+				continue
+			}
+			if stats := summarizeLongShort(points); stats != nil {
+				data.byKind(kind)[period] = stats
+				fetched++
+			}
+		}
+	}
+	for _, period := range takerPeriods {
+		points, err := c.cachedTakerVolume(ctx, o, src, symbol, period)
+		if err != nil {
+			if IsRateLimited(err) {
+				return nil, err
+			}
+			report.warn(SectionSentiment, "takerlongshortRatio "+period, err)
+			lastErr = err
+			continue
+		}
+		if stats := summarizeTaker(points); stats != nil {
+			data.Taker[period] = stats
+			fetched++
+		}
+	}
+	if fetched == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("多空比接口没有返回数据")
+		}
+		return nil, lastErr
+	}
+	return data, nil
+}
+
+// getLongShortRatio 请求/futures/data下的多空比接口；币本位按标的交易对（pair）查询
+func (c *Client) getLongShortRatio(ctx context.Context, symbol string, kind LongShortKind, period string, limit int) ([]LongShortPoint, error) {
+	url := fmt.Sprintf("%s?symbol=%s&period=%s&limit=%d", c.dataEndpoint("/"+string(kind)), symbol, period, limit)
+	if c.config().market == COINM {
+		pair, _ := coinMPair(symbol)
+		url = fmt.Sprintf("%s?pair=%s&period=%s&limit=%d", c.dataEndpoint("/"+string(kind)), pair, period, limit)
+	}
+
+	// 币本位的大户持仓比使用longPosition/shortPosition字段；timestamp在部分接口中为字符串
+	var raw []struct {
+		LongShortRatio string      `json:"longShortRatio"`
+		LongAccount    string      `json:"longAccount"`
+		ShortAccount   string      `json:"shortAccount"`
+		LongPosition   string      `json:"longPosition"`
+		ShortPosition  string      `json:"shortPosition"`
+		Timestamp      interface{} `json:"timestamp"`
+	}
+	if err := c.getJSON(ctx, url, weightFuturesData, &raw); err != nil {
+		return nil, err
+	}
+
+	points := make([]LongShortPoint, 0, len(raw))
+	for _, item := range raw {
+		ratio, err := strconv.ParseFloat(item.LongShortRatio, 64)
+		if err != nil {
+			continue
+		}
+		ts, err := parseFloat(item.Timestamp)
+		if err != nil {
+			continue
+		}
+		long, short := item.LongAccount, item.ShortAccount
+		if long == "" {
+			long, short = item.LongPosition, item.ShortPosition
+		}
+		point := LongShortPoint{Ratio: ratio, Timestamp: int64(ts)}
+		point.Long, _ = strconv.ParseFloat(long, 64)
+		point.Short, _ = strconv.ParseFloat(short, 64)
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+	return points, nil
+}
+
+// getTakerVolume 请求主动买卖量：U本位为/futures/data/takerlongshortRatio；
+// 币本位为/futures/data/takerBuySellVol，使用以基础资产计价的*VolValue字段并自行计算比值
+func (c *Client) getTakerVolume(ctx context.Context, symbol, period string, limit int) ([]TakerVolumePoint, error) {
+	coinM := c.config().market == COINM
+	url := fmt.Sprintf("%s?symbol=%s&period=%s&limit=%d", c.dataEndpoint("/takerlongshortRatio"), symbol, period, limit)
+	if coinM {
+		pair, contractType := coinMPair(symbol)
+		url = fmt.Sprintf("%s?pair=%s&contractType=%s&period=%s&limit=%d", c.dataEndpoint("/takerBuySellVol"), pair, contractType, period, limit)
+	}
+
+	var raw []struct {
+		BuySellRatio      string      `json:"buySellRatio"`
+		BuyVol            string      `json:"buyVol"`
+		SellVol           string      `json:"sellVol"`
+		TakerBuyVolValue  string      `json:"takerBuyVolValue"`
+		TakerSellVolValue string      `json:"takerSellVolValue"`
+		Timestamp         interface{} `json:"timestamp"`
+	}
+	if err := c.getJSON(ctx, url, weightFuturesData, &raw); err != nil {
+		return nil, err
+	}
+
+	points := make([]TakerVolumePoint, 0, len(raw))
+	for _, item := range raw {
+		ts, err := parseFloat(item.Timestamp)
+		if err != nil {
+			continue
+		}
+		buyField, sellField := item.BuyVol, item.SellVol
+		if coinM {
+			buyField, sellField = item.TakerBuyVolValue, item.TakerSellVolValue
+		}
+		buy, err1 := strconv.ParseFloat(buyField, 64)
+		sell, err2 := strconv.ParseFloat(sellField, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		point := TakerVolumePoint{BuyVolume: buy, SellVolume: sell, Timestamp: int64(ts)}
+		if ratio, err := strconv.ParseFloat(item.BuySellRatio, 64); err == nil {
+			point.BuySellRatio = ratio
+		} else if sell > 0 {
+			point.BuySellRatio = buy / sell
+		}
+		points = append(points, point)
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+	return points, nil
+}
+
+// formatSentiment Format中的多空比与主动买卖量比：多空比为各种类的5m与1h比值及1小时变化
+func formatSentiment(s *SentimentData) string {
+	labels := map[LongShortKind]string{
+		GlobalAccountRatio: "global accounts",
+		TopAccountRatio:    "top accounts",
+		TopPositionRatio:   "top positions",
+	}
+	var parts []string
+	for _, kind := range longShortKinds {
+		byPeriod := s.byKind(kind)
+		var values []string
+		for _, period := range sentimentPeriods {
+			stats, ok := byPeriod[period]
+			if !ok {
+				values = append(values, "n/a")
+				continue
+			}
+			values = append(values, fmt.Sprintf("%.3f (Δ1h %+.3f)", stats.Ratio, stats.Change1h))
+		}
+		parts = append(parts, labels[kind]+" "+strings.Join(values, " / "))
+	}
+	out := "Long/short ratio (5m / 1h): " + strings.Join(parts, " | ") + "\n\n"
+
+	if len(s.Taker) == 0 {
+		return out
+	}
+	var taker []string
+	for _, period := range takerPeriods {
+		stats, ok := s.Taker[period]
+		if !ok {
+			taker = append(taker, "n/a")
+			continue
+		}
+		taker = append(taker, fmt.Sprintf("%.3f (trend %+.4f/bar)", stats.BuySellRatio, stats.Trend))
+	}
+	return out + "Taker buy/sell ratio (5m / 15m): " + strings.Join(taker, " / ") + "\n\n"
+}
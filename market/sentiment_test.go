@@ -0,0 +1,71 @@
+package market
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// takerVolumeBody /futures/data/takerlongshortRatio的响应：时间倒序，timestamp有数字也有字符串，
+// 第三条没有buySellRatio（由买卖量计算），最后一条成交量无法解析（跳过）
+const takerVolumeBody = `[
+	{"buySellRatio":"0.8000","buyVol":"400.00","sellVol":"500.00","timestamp":1700000600000},
+	{"buySellRatio":"1.5000","buyVol":"300.50","sellVol":"200.33","timestamp":"1700000300000"},
+	{"buySellRatio":"","buyVol":"120.00","sellVol":"80.00","timestamp":1700000000000},
+	{"buySellRatio":"1.0000","buyVol":"n/a","sellVol":"1.00","timestamp":1699999700000}
+]`
+
+func TestTakerVolumeRecordAndReplay(t *testing.T) {
+	var hits atomic.Int32
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !strings.HasSuffix(r.URL.Path, "/futures/data/takerlongshortRatio") || r.URL.Query().Get("period") != "5m" {
+			http.Error(w, `{"code":-1,"msg":"unexpected request"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(takerVolumeBody))
+	}))
+	dir := t.TempDir()
+
+	recorded, err := newRESTClient(srv, WithRecorder(dir)).getTakerVolume(context.Background(), "BTCUSDT", "5m", 30)
+	if err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	want := []TakerVolumePoint{
+		{BuySellRatio: 1.5, BuyVolume: 120, SellVolume: 80, Timestamp: 1700000000000},
+		{BuySellRatio: 1.5, BuyVolume: 300.5, SellVolume: 200.33, Timestamp: 1700000300000},
+		{BuySellRatio: 0.8, BuyVolume: 400, SellVolume: 500, Timestamp: 1700000600000},
+	}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Fatalf("parsed = %+v\nwant %+v", recorded, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 1 {
+		t.Fatalf("recorded files = %v, want 1", files)
+	}
+
+	// 回放不访问网络，解析结果与录制时完全相同
+	before := hits.Load()
+	replayed, err := newRESTClient(srv, WithReplay(dir)).getTakerVolume(context.Background(), "BTCUSDT", "5m", 30)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if hits.Load() != before {
+		t.Errorf("replay sent %d requests to the server, want 0", hits.Load()-before)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed = %+v\nwant the recorded parse %+v", replayed, recorded)
+	}
+
+	// 没有录制的周期返回错误，而不是访问网络
+	if _, err := newRESTClient(srv, WithReplay(dir)).getTakerVolume(context.Background(), "BTCUSDT", "15m", 30); err == nil {
+		t.Error("replay of an unrecorded period: err = nil, want an error")
+	}
+	if hits.Load() != before {
+		t.Errorf("replay sent %d requests to the server, want 0", hits.Load()-before)
+	}
+}
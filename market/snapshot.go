@@ -0,0 +1,51 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SnapshotSchemaVersion 快照文件的schema版本
+// 只新增字段时无需升级；删除或改变字段含义时递增，Load会拒绝比当前更新的版本
+const SnapshotSchemaVersion = 1
+
+// snapshotFile 快照文件的顶层结构
+type snapshotFile struct {
+	SchemaVersion int   `json:"schema_version"`
+	Data          *Data `json:"data"`
+	// SwingATRMultiple 获取时的WithSwingThreshold，Load后FibRetracement继续使用该阈值；0表示默认值
+	SwingATRMultiple float64 `json:"swing_atr_multiple,omitempty"`
+}
+
+// Save 将快照以JSON写入w，可用Load还原
+func (d *Data) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshotFile{
+		SchemaVersion:    SnapshotSchemaVersion,
+		Data:             d,
+		SwingATRMultiple: d.swingATRMultiple,
+	})
+}
+
+// Load 从r读取Save写入的快照
+func Load(r io.Reader) (*Data, error) {
+	var file snapshotFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析快照失败: %w", err)
+	}
+	if file.SchemaVersion <= 0 {
+		return nil, fmt.Errorf("快照缺少schema_version")
+	}
+	if file.SchemaVersion > SnapshotSchemaVersion {
+		return nil, fmt.Errorf("快照schema版本%d高于当前支持的版本%d", file.SchemaVersion, SnapshotSchemaVersion)
+	}
+	if file.Data == nil {
+		return nil, fmt.Errorf("快照缺少data")
+	}
+	// 带RawKlines的快照可以继续使用FibRetracement等需要K线的方法
+	file.Data.klines = file.Data.RawKlines
+	file.Data.swingATRMultiple = file.SwingATRMultiple
+	return file.Data, nil
+}
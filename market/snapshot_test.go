@@ -0,0 +1,114 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// snapshotData 用FakeSource拉取尽量填满各分区的Data
+func snapshotData(t *testing.T) *Data {
+	t.Helper()
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	nowMs := now.UnixMilli()
+	src := newFixtureSource("ETHUSDT", now).
+		SetKlines("BTCUSDT", "1h", fixtureKlines("1h", 500, nowMs/3600000*3600000)).
+		SetKlines("ETHUSDT", "1h", fixtureKlines("1h", 500, nowMs/3600000*3600000)).
+		SetTicker24h("ETHUSDT", &Ticker24h{Symbol: "ETHUSDT", High: 110, Low: 90, Volume: 5000, QuoteVolume: 500000, TradeCount: 42}).
+		SetPremiumIndexKlines("ETHUSDT", "5m", fixtureKlines("5m", 60, nowMs/300000*300000))
+	for _, period := range sentimentPeriods {
+		step := intervalDuration(period).Milliseconds()
+		points := make([]LongShortPoint, sentimentHistoryLimit)
+		for i := range points {
+			long := 0.5 + float64(i%5)*0.01
+			points[i] = LongShortPoint{Ratio: long / (1 - long), Long: long, Short: 1 - long, Timestamp: (nowMs/step - int64(len(points)-1-i)) * step}
+		}
+		for _, kind := range longShortKinds {
+			src.SetLongShortRatio("ETHUSDT", kind, period, points)
+		}
+	}
+	for _, period := range []string{"5m", "15m"} {
+		step := intervalDuration(period).Milliseconds()
+		points := make([]TakerVolumePoint, sentimentHistoryLimit)
+		for i := range points {
+			buy, sell := 100+float64(i%4)*10, 100.0
+			points[i] = TakerVolumePoint{BuySellRatio: buy / sell, BuyVolume: buy, SellVolume: sell, Timestamp: (nowMs/step - int64(len(points)-1-i)) * step}
+		}
+		src.SetTakerVolume("ETHUSDT", period, points)
+	}
+
+	data, err := NewClient(WithSource(src)).Get(context.Background(), "ETHUSDT",
+		WithIntervals("1m", "3m", "15m", "1h", "4h"), WithRawKlines(), WithStrict(), WithTicker24h(), WithSentiment(),
+		WithBasisHistory(), WithBTCCorrelation(), WithRelativeStrength(), WithSwingThreshold(2))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return data
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	data := snapshotData(t)
+	// 确认各分区确实被填充，否则往返比较覆盖不到对应的json tag
+	for name, v := range map[string]any{
+		"Ticker24h": data.Ticker24h, "OpenInterest": data.OpenInterest, "Funding": data.Funding,
+		"Sentiment": data.Sentiment, "BasisHistory": data.BasisHistory, "VolumeProfile": data.VolumeProfile,
+		"Microstructure": data.Microstructure, "IntradaySeries": data.IntradaySeries, "LongerTermContext": data.LongerTermContext,
+		"RelativeStrength": data.RelativeStrength,
+	} {
+		if reflect.ValueOf(v).IsNil() {
+			t.Errorf("%s is nil, want the fixture to populate it", name)
+		}
+	}
+	if len(data.Timeframes) != 5 || len(data.RawKlines) != 5 {
+		t.Errorf("%d timeframes and %d raw kline intervals, want 5", len(data.Timeframes), len(data.RawKlines))
+	}
+
+	var buf bytes.Buffer
+	if err := data.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, data) {
+		t.Error("loaded snapshot differs from the saved Data")
+	}
+	if loaded.swingATRMultiple != 2 {
+		t.Errorf("swingATRMultiple = %v after Load, want 2", loaded.swingATRMultiple)
+	}
+	want, err := data.FibRetracement("1h")
+	if err != nil {
+		t.Fatalf("FibRetracement: %v", err)
+	}
+	if got, err := loaded.FibRetracement("1h"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("FibRetracement after Load = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"malformed", `{"schema_version":`, "解析快照失败"},
+		{"missing version", `{"data":{"symbol":"BTCUSDT"}}`, "缺少schema_version"},
+		{"newer version", `{"schema_version":2,"data":{"symbol":"BTCUSDT"}}`, "高于当前支持的版本1"},
+		{"missing data", `{"schema_version":1}`, "缺少data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Load(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load = %v, %v, want an error containing %q", data, err, tt.wantErr)
+			}
+		})
+	}
+	if data, err := Load(strings.NewReader(`{"schema_version":1,"data":{"symbol":"BTCUSDT"}}`)); err != nil || data.Symbol != "BTCUSDT" {
+		t.Errorf("Load current version = %+v, %v, want BTCUSDT", data, err)
+	}
+}
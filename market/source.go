@@ -0,0 +1,106 @@
+package market
+
+import "context"
+
+// Source 行情数据源
+// 默认实现直接请求币安REST接口；可替换为其他数据源、回放数据或测试用的FakeSource
+// Client在Source之上负责缓存、合并请求与指标计算
+type Source interface {
+	// Klines 获取最近limit根K线，按时间升序
+	Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error)
+	// OpenInterest 获取当前持仓量
+	OpenInterest(ctx context.Context, symbol string) (OIPoint, error)
+	// OpenInterestHistory 获取period周期的持仓量历史，按时间升序
+	OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OIPoint, error)
+	// PremiumIndex 获取最新资金费率与下次结算时间
+	PremiumIndex(ctx context.Context, symbol string) (*PremiumIndex, error)
+	// FundingRateHistory 获取最近limit次资金费率结算，按时间升序
+	FundingRateHistory(ctx context.Context, symbol string, limit int) ([]FundingRatePoint, error)
+	// AggTrades 获取startTime（毫秒）之后的归集成交，按时间升序
+	AggTrades(ctx context.Context, symbol string, startTime int64) ([]AggTrade, error)
+	// Depth 获取limit档盘口
+	Depth(ctx context.Context, symbol string, limit int) (*OrderBook, error)
+}
+
+// KlineRangeSource 可以按时间范围获取K线的数据源，用于补齐缺失的K线（见GapRefetch）
+// 币安REST数据源实现了该接口；未实现时缺口只能以平盘K线填充
+type KlineRangeSource interface {
+	// KlinesRange 获取开盘时间在[startTime, endTime]（毫秒）内的K线，按时间升序
+	KlinesRange(ctx context.Context, symbol, interval string, startTime, endTime int64) ([]Kline, error)
+}
+
+// WithSource 替换客户端的数据源，nil表示使用币安REST接口
+func WithSource(src Source) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.source = src
+	}
+}
+
+// source 返回当前生效的数据源
+func (c *Client) source() Source {
+	if src := c.config().source; src != nil {
+		return src
+	}
+	return restSource{c: c}
+}
+
+// restSource 币安REST数据源，复用Client的HTTP配置、重试与限流
+type restSource struct {
+	c *Client
+}
+
+func (s restSource) Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	return s.c.getKlines(ctx, symbol, interval, limit)
+}
+
+func (s restSource) KlinesRange(ctx context.Context, symbol, interval string, startTime, endTime int64) ([]Kline, error) {
+	limit := maxKlinesPerRequest
+	if step := intervalDuration(interval).Milliseconds(); step > 0 {
+		limit = min(int((endTime-startTime)/step)+1, maxKlinesPerRequest)
+	}
+	return s.c.getKlinesPage(ctx, symbol, interval, limit, startTime, endTime)
+}
+
+func (s restSource) OpenInterest(ctx context.Context, symbol string) (OIPoint, error) {
+	return s.c.getLatestOpenInterest(ctx, symbol)
+}
+
+func (s restSource) OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OIPoint, error) {
+	return s.c.getOpenInterestHistory(ctx, symbol, period, limit)
+}
+
+func (s restSource) PremiumIndex(ctx context.Context, symbol string) (*PremiumIndex, error) {
+	return s.c.getPremiumIndex(ctx, symbol)
+}
+
+func (s restSource) FundingRateHistory(ctx context.Context, symbol string, limit int) ([]FundingRatePoint, error) {
+	return s.c.getFundingRateHistory(ctx, symbol, limit)
+}
+
+func (s restSource) LongShortRatio(ctx context.Context, symbol string, kind LongShortKind, period string, limit int) ([]LongShortPoint, error) {
+	return s.c.getLongShortRatio(ctx, symbol, kind, period, limit)
+}
+
+func (s restSource) TakerVolume(ctx context.Context, symbol, period string, limit int) ([]TakerVolumePoint, error) {
+	return s.c.getTakerVolume(ctx, symbol, period, limit)
+}
+
+func (s restSource) Ticker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	return s.c.getTicker24h(ctx, symbol)
+}
+
+func (s restSource) AllTickers24h(ctx context.Context) ([]*Ticker24h, error) {
+	return s.c.getAllTickers24h(ctx)
+}
+
+func (s restSource) PremiumIndexKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	return s.c.getPremiumIndexKlines(ctx, symbol, interval, limit)
+}
+
+func (s restSource) AggTrades(ctx context.Context, symbol string, startTime int64) ([]AggTrade, error) {
+	return s.c.getAggTrades(ctx, symbol, startTime)
+}
+
+func (s restSource) Depth(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
+	return s.c.getOrderBook(ctx, symbol, limit)
+}
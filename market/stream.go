@@ -0,0 +1,159 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultStreamURL 币安U本位合约WebSocket行情默认地址
+const DefaultStreamURL = "wss://fstream.binance.com"
+
+// WebSocket连接参数
+const (
+	streamReadTimeout      = 5 * time.Minute // 币安每3分钟发送一次ping，超过该时间没有任何消息视为连接失效
+	streamHandshakeTimeout = 10 * time.Second
+	streamMinBackoff       = time.Second
+	streamMaxBackoff       = 30 * time.Second
+)
+
+// WithStreamURL 指定WebSocket行情根地址（如测试网 wss://stream.binancefuture.com），空字符串表示所选市场的默认地址
+func WithStreamURL(u string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.streamURL = strings.TrimRight(strings.TrimSpace(u), "/")
+	}
+}
+
+// streamMessage 组合流（/stream）的消息封装
+type streamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// streamConn 自动重连的组合流WebSocket连接，每次（重新）连接后重新订阅全部stream
+type streamConn struct {
+	url     string
+	streams []string
+	logger  Logger
+	dialer  *websocket.Dialer
+
+	// onConnect 每次连接并订阅成功后、开始读取消息前调用，reconnect表示是否为断线重连，用于补齐断线期间的数据
+	onConnect func(ctx context.Context, reconnect bool)
+	// onDisconnect 连接断开时调用
+	onDisconnect func(err error)
+	// onMessage 收到stream消息时调用，在读取goroutine中串行执行
+	onMessage func(stream string, data json.RawMessage)
+}
+
+// run 保持连接直到ctx结束，断线后按指数退避重连
+func (s *streamConn) run(ctx context.Context) {
+	backoff := streamMinBackoff
+	connected := false
+
+	for ctx.Err() == nil {
+		conn, _, err := s.dialer.DialContext(ctx, s.url+"/stream", nil)
+		if err == nil {
+			err = s.subscribe(conn)
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Warnf("WebSocket连接失败 url=%s retry_in=%s err=%v", s.url, backoff, err)
+			if !sleepContext(ctx, backoff) {
+				return
+			}
+			backoff *= 2
+			if backoff > streamMaxBackoff {
+				backoff = streamMaxBackoff
+			}
+			continue
+		}
+
+		backoff = streamMinBackoff
+		if s.onConnect != nil {
+			s.onConnect(ctx, connected)
+		}
+		connected = true
+
+		err = s.read(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warnf("WebSocket连接断开，准备重连 url=%s err=%v", s.url, err)
+		if s.onDisconnect != nil {
+			s.onDisconnect(err)
+		}
+	}
+}
+
+// subscribe 发送SUBSCRIBE请求
+func (s *streamConn) subscribe(conn *websocket.Conn) error {
+	req := struct {
+		Method string   `json:"method"`
+		Params []string `json:"params"`
+		ID     int      `json:"id"`
+	}{Method: "SUBSCRIBE", Params: s.streams, ID: 1}
+	conn.SetWriteDeadline(time.Now().Add(streamHandshakeTimeout))
+	return conn.WriteJSON(req)
+}
+
+// read 读取消息直到连接出错或ctx结束
+func (s *streamConn) read(ctx context.Context, conn *websocket.Conn) error {
+	var once sync.Once
+	closeConn := func() { once.Do(func() { conn.Close() }) }
+	defer closeConn()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeConn()
+		case <-done:
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+	conn.SetPingHandler(func(appData string) error {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(time.Second))
+	})
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+
+		var msg streamMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			s.logger.Debugf("无法解析的WebSocket消息 err=%v", err)
+			continue
+		}
+		if msg.Stream == "" {
+			continue // 订阅请求的响应
+		}
+		s.onMessage(msg.Stream, msg.Data)
+	}
+}
+
+// sleepContext 等待d，ctx先结束时返回false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,70 @@
+package market
+
+import (
+	"context"
+	"time"
+)
+
+// defaultSubscribeInterval Subscribe的刷新间隔未指定时的默认值
+const defaultSubscribeInterval = 15 * time.Second
+
+// Subscribe 使用默认客户端订阅symbol的市场数据，见Client.Subscribe
+func Subscribe(ctx context.Context, symbol string, interval time.Duration, opts ...GetOption) (<-chan *Data, <-chan error) {
+	return defaultClient.Subscribe(ctx, symbol, interval, opts...)
+}
+
+// Subscribe 立即获取一次数据，之后每隔interval刷新一次，并把快照推送到返回的channel
+// 较长周期的K线命中客户端缓存，不会每次刷新都重新请求
+// 消费方处理不过来时丢弃中间快照，只保留最新的一份，不会阻塞刷新也不会堆积
+// 获取失败时错误推送到错误channel（同样只保留最新），订阅继续进行
+// ctx结束后两个channel都会被关闭；interval<=0时使用默认间隔
+func (c *Client) Subscribe(ctx context.Context, symbol string, interval time.Duration, opts ...GetOption) (<-chan *Data, <-chan error) {
+	if interval <= 0 {
+		interval = defaultSubscribeInterval
+	}
+	dataCh := make(chan *Data, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(dataCh)
+		defer close(errCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			data, err := c.Get(ctx, symbol, opts...)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				sendLatest(errCh, err)
+			} else {
+				sendLatest(dataCh, data)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return dataCh, errCh
+}
+
+// sendLatest 向容量为1的channel发送v，channel已满时丢弃尚未被消费的旧值
+// 仅适用于单一发送方的channel
+func sendLatest[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- v
+}
@@ -0,0 +1,52 @@
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribeDeliversSnapshotsUntilCanceled(t *testing.T) {
+	const snapshots = 3
+	src := newFixtureSource("BTCUSDT", time.Now())
+	c := NewClient(WithSource(src))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dataCh, errCh := c.Subscribe(ctx, "BTCUSDT", 10*time.Millisecond, WithoutCache())
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < snapshots; i++ {
+		select {
+		case data := <-dataCh:
+			if data == nil || data.Symbol != "BTCUSDT" {
+				t.Fatalf("snapshot %d = %+v, want BTCUSDT data", i, data)
+			}
+		case err := <-errCh:
+			t.Fatalf("snapshot %d: %v", i, err)
+		case <-timeout:
+			t.Fatalf("got %d snapshots before timeout, want %d", i, snapshots)
+		}
+	}
+	if got := src.Calls("Klines"); got < snapshots*len(defaultIntervals) {
+		t.Errorf("Klines calls = %d, want at least %d (one fetch per snapshot)", got, snapshots*len(defaultIntervals))
+	}
+
+	// 取消后两个channel都应关闭，之前可能还有一份未消费的快照
+	cancel()
+	deadline := time.After(5 * time.Second)
+	for dataCh != nil || errCh != nil {
+		select {
+		case _, ok := <-dataCh:
+			if !ok {
+				dataCh = nil
+			}
+		case _, ok := <-errCh:
+			if !ok {
+				errCh = nil
+			}
+		case <-deadline:
+			t.Fatal("channels not closed after ctx cancel")
+		}
+	}
+}
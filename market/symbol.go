@@ -0,0 +1,98 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultQuote Normalize默认补全的计价资产
+const DefaultQuote = "USDT"
+
+// KnownQuotes 可识别的计价资产后缀，带有这些后缀的symbol不再补全
+var KnownQuotes = []string{"USDT", "USDC", "BUSD", "FDUSD"}
+
+// ErrInvalidSymbol symbol格式无效（为空或包含非法字符）
+var ErrInvalidSymbol = errors.New("无效的symbol")
+
+// NormalizeQuote 将symbol标准化为以quote计价的交易对
+// 忽略大小写与首尾空白，允许 BTC/USDT、BTC-USDT 形式的分隔符；
+// symbol已以quote或KnownQuotes中的资产结尾时原样返回，否则补全quote
+// 为空、只有计价资产本身或包含字母数字与下划线以外字符时返回ErrInvalidSymbol
+func NormalizeQuote(symbol, quote string) (string, error) {
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if quote == "" || !isSymbolText(quote) {
+		return "", fmt.Errorf("%w: 计价资产 %q", ErrInvalidSymbol, quote)
+	}
+
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	s = strings.NewReplacer("/", "", "-", "").Replace(s)
+	if s == "" || !isSymbolText(s) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSymbol, symbol)
+	}
+
+	if hasQuoteSuffix(s, quote) {
+		return s, nil
+	}
+	for _, known := range KnownQuotes {
+		if hasQuoteSuffix(s, known) {
+			return s, nil
+		}
+	}
+	if s == quote {
+		return "", fmt.Errorf("%w: %q 缺少基础资产", ErrInvalidSymbol, symbol)
+	}
+	return s + quote, nil
+}
+
+// hasQuoteSuffix 判断symbol是否为 基础资产+quote 的形式（基础资产不能为空）
+func hasQuoteSuffix(symbol, quote string) bool {
+	return len(symbol) > len(quote) && strings.HasSuffix(symbol, quote)
+}
+
+// isSymbolText 只允许大写字母、数字与下划线（交割合约形如 BTCUSDT_250627）
+func isSymbolText(s string) bool {
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// multiplierPrefix 低价币合约的数量倍数前缀，如1000SHIBUSDT的一个"币"为1000个SHIB
+type multiplierPrefix struct {
+	prefix     string
+	multiplier float64
+}
+
+// multiplierPrefixes 按前缀长度从长到短排列，避免1000000被1000误匹配
+var multiplierPrefixes = []multiplierPrefix{
+	{"1000000", 1000000},
+	{"10000", 10000},
+	{"1000", 1000},
+	{"1M", 1000000},
+}
+
+// symbolMultiplier 从交易对名称解析数量倍数，没有倍数前缀时返回1
+func symbolMultiplier(symbol string) float64 {
+	for _, p := range multiplierPrefixes {
+		if len(symbol) > len(p.prefix) && strings.HasPrefix(symbol, p.prefix) {
+			return p.multiplier
+		}
+	}
+	return 1
+}
+
+// resolveAlias 在exchangeInfo中查找symbol，不存在时依次尝试带倍数前缀的合约（SHIBUSDT→1000SHIBUSDT）
+func resolveAlias(all map[string]SymbolInfo, symbol string) (string, bool) {
+	if _, ok := all[symbol]; ok {
+		return symbol, true
+	}
+	for _, p := range multiplierPrefixes {
+		if _, ok := all[p.prefix+symbol]; ok {
+			return p.prefix + symbol, true
+		}
+	}
+	return symbol, false
+}
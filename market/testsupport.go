@@ -0,0 +1,390 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNoFixture FakeSource中没有预置对应的数据
+var ErrNoFixture = errors.New("没有预置的行情数据")
+
+// FakeSource 内存数据源，返回预置的行情数据且不访问网络，用于离线测试与演示
+// symbol需使用标准化后的形式（如BTCUSDT）；未预置的数据返回ErrNoFixture，可用SetError注入错误
+type FakeSource struct {
+	mu             sync.Mutex
+	klines         map[string][]Kline // key: symbol|interval
+	openInterest   map[string]OIPoint
+	oiHistory      map[string][]OIPoint // key: symbol|period
+	premium        map[string]*PremiumIndex
+	fundingHistory map[string][]FundingRatePoint
+	aggTrades      map[string][]AggTrade
+	depth          map[string]*OrderBook
+	longShort      map[string][]LongShortPoint   // key: symbol|kind|period
+	takerVolume    map[string][]TakerVolumePoint // key: symbol|period
+	premiumKlines  map[string][]Kline            // key: symbol|interval
+	tickers        map[string]*Ticker24h         // key: symbol
+	errs           map[string]error              // key: 方法名
+	calls          map[string]int                // key: 方法名
+}
+
+// NewFakeSource 创建空的内存数据源
+func NewFakeSource() *FakeSource {
+	return &FakeSource{
+		klines:         make(map[string][]Kline),
+		openInterest:   make(map[string]OIPoint),
+		oiHistory:      make(map[string][]OIPoint),
+		premium:        make(map[string]*PremiumIndex),
+		fundingHistory: make(map[string][]FundingRatePoint),
+		aggTrades:      make(map[string][]AggTrade),
+		depth:          make(map[string]*OrderBook),
+		longShort:      make(map[string][]LongShortPoint),
+		takerVolume:    make(map[string][]TakerVolumePoint),
+		premiumKlines:  make(map[string][]Kline),
+		tickers:        make(map[string]*Ticker24h),
+		errs:           make(map[string]error),
+		calls:          make(map[string]int),
+	}
+}
+
+func fakeKey(symbol, sub string) string {
+	return symbol + "|" + sub
+}
+
+// SetKlines 预置K线（按时间升序）
+func (f *FakeSource) SetKlines(symbol, interval string, klines []Kline) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.klines[fakeKey(symbol, interval)] = klines
+	return f
+}
+
+// SetOpenInterest 预置当前持仓量
+func (f *FakeSource) SetOpenInterest(symbol string, point OIPoint) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.openInterest[symbol] = point
+	return f
+}
+
+// SetOpenInterestHistory 预置持仓量历史（按时间升序）
+func (f *FakeSource) SetOpenInterestHistory(symbol, period string, points []OIPoint) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.oiHistory[fakeKey(symbol, period)] = points
+	return f
+}
+
+// SetPremiumIndex 预置资金费率信息
+func (f *FakeSource) SetPremiumIndex(symbol string, premium *PremiumIndex) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.premium[symbol] = premium
+	return f
+}
+
+// SetFundingRateHistory 预置资金费率历史（按时间升序）
+func (f *FakeSource) SetFundingRateHistory(symbol string, points []FundingRatePoint) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fundingHistory[symbol] = points
+	return f
+}
+
+// SetAggTrades 预置归集成交（按时间升序）
+func (f *FakeSource) SetAggTrades(symbol string, trades []AggTrade) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aggTrades[symbol] = trades
+	return f
+}
+
+// SetDepth 预置盘口
+func (f *FakeSource) SetDepth(symbol string, book *OrderBook) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.depth[symbol] = book
+	return f
+}
+
+// SetLongShortRatio 预置多空比历史（按时间升序）
+func (f *FakeSource) SetLongShortRatio(symbol string, kind LongShortKind, period string, points []LongShortPoint) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.longShort[fakeKey(symbol, string(kind)+"|"+period)] = points
+	return f
+}
+
+// SetTakerVolume 预置主动买卖量历史（按时间升序）
+func (f *FakeSource) SetTakerVolume(symbol, period string, points []TakerVolumePoint) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.takerVolume[fakeKey(symbol, period)] = points
+	return f
+}
+
+// SetPremiumIndexKlines 预置溢价指数K线（按时间升序）
+func (f *FakeSource) SetPremiumIndexKlines(symbol, interval string, klines []Kline) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.premiumKlines[fakeKey(symbol, interval)] = klines
+	return f
+}
+
+// SetTicker24h 预置24小时行情统计
+func (f *FakeSource) SetTicker24h(symbol string, ticker *Ticker24h) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tickers[symbol] = ticker
+	return f
+}
+
+// SetError 让某个方法（如 "Klines"、"OpenInterest"）固定返回err，传nil取消
+func (f *FakeSource) SetError(method string, err error) *FakeSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+	} else {
+		f.errs[method] = err
+	}
+	return f
+}
+
+// Calls 返回某个方法被调用的次数
+func (f *FakeSource) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// enter 记录调用并返回注入的错误
+func (f *FakeSource) enter(ctx context.Context, method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.errs[method]
+}
+
+func noFixture(method, key string) error {
+	return fmt.Errorf("%s %s: %w", method, key, ErrNoFixture)
+}
+
+func (f *FakeSource) Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	if err := f.enter(ctx, "Klines"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	klines, ok := f.klines[fakeKey(symbol, interval)]
+	if !ok {
+		return nil, noFixture("Klines", fakeKey(symbol, interval))
+	}
+	if limit > 0 && len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// KlinesRange 返回预置K线中开盘时间在[startTime, endTime]内的部分
+func (f *FakeSource) KlinesRange(ctx context.Context, symbol, interval string, startTime, endTime int64) ([]Kline, error) {
+	if err := f.enter(ctx, "KlinesRange"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	klines, ok := f.klines[fakeKey(symbol, interval)]
+	if !ok {
+		return nil, noFixture("KlinesRange", fakeKey(symbol, interval))
+	}
+	var result []Kline
+	for _, k := range klines {
+		if k.OpenTime >= startTime && k.OpenTime <= endTime {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+func (f *FakeSource) OpenInterest(ctx context.Context, symbol string) (OIPoint, error) {
+	if err := f.enter(ctx, "OpenInterest"); err != nil {
+		return OIPoint{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	point, ok := f.openInterest[symbol]
+	if !ok {
+		return OIPoint{}, noFixture("OpenInterest", symbol)
+	}
+	return point, nil
+}
+
+func (f *FakeSource) OpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OIPoint, error) {
+	if err := f.enter(ctx, "OpenInterestHistory"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	points, ok := f.oiHistory[fakeKey(symbol, period)]
+	if !ok {
+		return nil, noFixture("OpenInterestHistory", fakeKey(symbol, period))
+	}
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+	return points, nil
+}
+
+func (f *FakeSource) PremiumIndex(ctx context.Context, symbol string) (*PremiumIndex, error) {
+	if err := f.enter(ctx, "PremiumIndex"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	premium, ok := f.premium[symbol]
+	if !ok {
+		return nil, noFixture("PremiumIndex", symbol)
+	}
+	return premium, nil
+}
+
+func (f *FakeSource) FundingRateHistory(ctx context.Context, symbol string, limit int) ([]FundingRatePoint, error) {
+	if err := f.enter(ctx, "FundingRateHistory"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	points, ok := f.fundingHistory[symbol]
+	if !ok {
+		return nil, noFixture("FundingRateHistory", symbol)
+	}
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+	return points, nil
+}
+
+func (f *FakeSource) AggTrades(ctx context.Context, symbol string, startTime int64) ([]AggTrade, error) {
+	if err := f.enter(ctx, "AggTrades"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	trades, ok := f.aggTrades[symbol]
+	if !ok {
+		return nil, noFixture("AggTrades", symbol)
+	}
+	result := make([]AggTrade, 0, len(trades))
+	for _, t := range trades {
+		if t.Timestamp >= startTime {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (f *FakeSource) Depth(ctx context.Context, symbol string, limit int) (*OrderBook, error) {
+	if err := f.enter(ctx, "Depth"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	book, ok := f.depth[symbol]
+	if !ok {
+		return nil, noFixture("Depth", symbol)
+	}
+	trimmed := &OrderBook{Bids: book.Bids, Asks: book.Asks}
+	if limit > 0 && len(trimmed.Bids) > limit {
+		trimmed.Bids = trimmed.Bids[:limit]
+	}
+	if limit > 0 && len(trimmed.Asks) > limit {
+		trimmed.Asks = trimmed.Asks[:limit]
+	}
+	return trimmed, nil
+}
+
+func (f *FakeSource) LongShortRatio(ctx context.Context, symbol string, kind LongShortKind, period string, limit int) ([]LongShortPoint, error) {
+	if err := f.enter(ctx, "LongShortRatio"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := fakeKey(symbol, string(kind)+"|"+period)
+	points, ok := f.longShort[key]
+	if !ok {
+		return nil, noFixture("LongShortRatio", key)
+	}
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+	return points, nil
+}
+
+func (f *FakeSource) TakerVolume(ctx context.Context, symbol, period string, limit int) ([]TakerVolumePoint, error) {
+	if err := f.enter(ctx, "TakerVolume"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	points, ok := f.takerVolume[fakeKey(symbol, period)]
+	if !ok {
+		return nil, noFixture("TakerVolume", fakeKey(symbol, period))
+	}
+	if limit > 0 && len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+	return points, nil
+}
+
+func (f *FakeSource) PremiumIndexKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	if err := f.enter(ctx, "PremiumIndexKlines"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	klines, ok := f.premiumKlines[fakeKey(symbol, interval)]
+	if !ok {
+		return nil, noFixture("PremiumIndexKlines", fakeKey(symbol, interval))
+	}
+	if limit > 0 && len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+func (f *FakeSource) Ticker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	if err := f.enter(ctx, "Ticker24h"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ticker, ok := f.tickers[symbol]
+	if !ok {
+		return nil, noFixture("Ticker24h", symbol)
+	}
+	return ticker, nil
+}
+
+// AllTickers24h 返回全部预置的24小时行情统计，按symbol排序
+func (f *FakeSource) AllTickers24h(ctx context.Context) ([]*Ticker24h, error) {
+	if err := f.enter(ctx, "AllTickers24h"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	symbols := make([]string, 0, len(f.tickers))
+	for symbol := range f.tickers {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	tickers := make([]*Ticker24h, 0, len(symbols))
+	for _, symbol := range symbols {
+		tickers = append(tickers, f.tickers[symbol])
+	}
+	return tickers, nil
+}
@@ -0,0 +1,89 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetWithFakeSource(t *testing.T) {
+	now := time.Now()
+	src := newFixtureSource("BTCUSDT", now)
+	c := NewClient(WithSource(src))
+
+	data, err := c.Get(context.Background(), "btc")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data.Symbol != "BTCUSDT" {
+		t.Errorf("Symbol = %q, want BTCUSDT", data.Symbol)
+	}
+	step := intervalDuration("3m").Milliseconds()
+	if want := fixtureKline(now.UnixMilli()/step*step, intervalDuration("3m")).Close; data.CurrentPrice != want {
+		t.Errorf("CurrentPrice = %v, want last 3m close %v", data.CurrentPrice, want)
+	}
+	for _, interval := range defaultIntervals {
+		if data.Timeframes[interval] == nil {
+			t.Errorf("Timeframes[%s] missing", interval)
+		}
+	}
+	if data.OpenInterest == nil || data.OpenInterest.Latest != 1000 {
+		t.Errorf("OpenInterest = %+v, want Latest 1000", data.OpenInterest)
+	}
+	if data.Funding == nil || data.Funding.Rate != 0.0001 {
+		t.Errorf("Funding = %+v, want Rate 0.0001", data.Funding)
+	}
+	if data.Microstructure == nil {
+		t.Error("Microstructure = nil")
+	}
+	if got, want := src.Calls("Klines"), len(defaultIntervals); got != want {
+		t.Errorf("Klines calls = %d, want %d", got, want)
+	}
+
+	// 第二次Get命中客户端缓存，不再访问数据源
+	if _, err := c.Get(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("second Get: %v", err)
+	}
+	if got, want := src.Calls("Klines"), len(defaultIntervals); got != want {
+		t.Errorf("Klines calls after cached Get = %d, want %d", got, want)
+	}
+}
+
+func TestGetWithFakeSourceErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name        string
+		methods     []string
+		wantErr     bool
+		unavailable Section
+	}{
+		{"klines", []string{"Klines"}, true, ""},
+		{"open interest", []string{"OpenInterest"}, false, SectionOpenInterest},
+		{"funding", []string{"PremiumIndex"}, false, SectionFunding},
+		{"microstructure", []string{"AggTrades", "Depth"}, false, SectionMicrostructure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newFixtureSource("BTCUSDT", time.Now())
+			for _, method := range tt.methods {
+				src.SetError(method, errBoom)
+			}
+			c := NewClient(WithSource(src))
+
+			data, err := c.Get(context.Background(), "BTCUSDT")
+			if tt.wantErr {
+				if !errors.Is(err, errBoom) {
+					t.Fatalf("err = %v, want wrapping %v", err, errBoom)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if !data.Unavailable(tt.unavailable) {
+				t.Errorf("UnavailableSections = %v, want %s", data.UnavailableSections, tt.unavailable)
+			}
+		})
+	}
+}
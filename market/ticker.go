@@ -0,0 +1,203 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Ticker24h 最近24小时的滚动行情统计（/ticker/24hr）
+type Ticker24h struct {
+	Symbol             string  `json:"symbol"`
+	High               float64 `json:"high"`
+	Low                float64 `json:"low"`
+	Volume             float64 `json:"volume"`               // 基础资产成交量
+	QuoteVolume        float64 `json:"quote_volume"`         // 计价资产成交额，币本位为合约面值之和（美元）
+	PriceChangePercent float64 `json:"price_change_percent"` // 如 2.5 表示上涨2.5%
+	WeightedAvgPrice   float64 `json:"weighted_avg_price"`
+	TradeCount         int64   `json:"trade_count"`
+	OpenTimeMs         int64   `json:"open_time_ms"`
+	CloseTimeMs        int64   `json:"close_time_ms"`
+}
+
+// TickerSource 可以获取24小时行情统计的数据源，币安REST数据源与FakeSource实现了该接口
+// 未实现时Data.Ticker24h为nil，不标记为失败
+type TickerSource interface {
+	// Ticker24h 获取最近24小时的滚动行情统计
+	Ticker24h(ctx context.Context, symbol string) (*Ticker24h, error)
+}
+
+// ErrTickerUnsupported 数据源不支持24小时行情统计
+var ErrTickerUnsupported = errors.New("数据源不支持24小时行情统计")
+
+// GetTicker24h 使用默认客户端获取24小时行情统计，见Client.Ticker24h
+func GetTicker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	return defaultClient.Ticker24h(ctx, symbol)
+}
+
+// Ticker24h 获取symbol最近24小时的行情统计，与Get共用缓存，筛选、排序等批量场景应使用该方法而不是完整的Get
+func (c *Client) Ticker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	o := c.newGetOptions(nil)
+	normalized, err := o.normalize(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return c.cachedTicker24h(ctx, o, normalized)
+}
+
+// cachedTicker24h 带缓存的24小时行情获取，缓存时间与1m K线相同
+func (c *Client) cachedTicker24h(ctx context.Context, o getOptions, symbol string) (*Ticker24h, error) {
+	src, ok := c.source().(TickerSource)
+	if !ok {
+		return nil, ErrTickerUnsupported
+	}
+	return cachedFetch(c, tickerCacheKey(symbol), c.config().cacheTTL("1m"), o.bypassCache, func() (*Ticker24h, error) {
+		return src.Ticker24h(ctx, symbol)
+	})
+}
+
+// AllTickersSource 可以一次获取全部交易对24小时行情统计的数据源，币安REST数据源与FakeSource实现了该接口
+// GetMany批量获取ticker时使用，未实现时各symbol单独请求
+type AllTickersSource interface {
+	// AllTickers24h 获取全部交易对最近24小时的滚动行情统计
+	AllTickers24h(ctx context.Context) ([]*Ticker24h, error)
+}
+
+// tickerCacheKey Ticker24h的缓存key，Get、Client.Ticker24h与GetMany的批量预取共用
+func tickerCacheKey(symbol string) string {
+	return fmt.Sprintf("ticker24h|%s", symbol)
+}
+
+// tickerResponse /ticker/24hr 响应中的一项
+type tickerResponse struct {
+	Symbol             string `json:"symbol"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	WeightedAvgPrice   string `json:"weightedAvgPrice"`
+	HighPrice          string `json:"highPrice"`
+	LowPrice           string `json:"lowPrice"`
+	Volume             string `json:"volume"`
+	QuoteVolume        string `json:"quoteVolume"`
+	BaseVolume         string `json:"baseVolume"`
+	OpenTime           int64  `json:"openTime"`
+	CloseTime          int64  `json:"closeTime"`
+	Count              int64  `json:"count"`
+}
+
+// getTicker24h 请求24小时行情统计
+// 币本位接口即使指定了symbol也返回数组，且没有quoteVolume：volume为合约张数，baseVolume为基础资产数量
+func (c *Client) getTicker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	cfg := c.config()
+	url := fmt.Sprintf("%s?symbol=%s", c.apiEndpoint("/ticker/24hr"), symbol)
+	weight := weightTicker24h
+	if cfg.market == SPOT {
+		weight = weightSpotTicker24h
+	}
+
+	var body json.RawMessage
+	if err := c.getJSON(ctx, url, weight, &body); err != nil {
+		return nil, err
+	}
+
+	var result tickerResponse
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var results []tickerResponse
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return nil, newRequestError(url, fmt.Errorf("解析响应失败: %w", err))
+		}
+		found := false
+		for _, r := range results {
+			if r.Symbol == symbol {
+				result, found = r, true
+				break
+			}
+		}
+		if !found {
+			return nil, newRequestError(url, fmt.Errorf("响应中没有 %s", symbol))
+		}
+	} else if err := json.Unmarshal(body, &result); err != nil {
+		return nil, newRequestError(url, fmt.Errorf("解析响应失败: %w", err))
+	}
+	return parseTicker24h(result, cfg.market), nil
+}
+
+// getAllTickers24h 不指定symbol请求全部交易对的24小时行情统计
+func (c *Client) getAllTickers24h(ctx context.Context) ([]*Ticker24h, error) {
+	cfg := c.config()
+	url := c.apiEndpoint("/ticker/24hr")
+	weight := weightAllTickers24h
+	if cfg.market == SPOT {
+		weight = weightSpotAllTickers24h
+	}
+
+	var results []tickerResponse
+	if err := c.getJSON(ctx, url, weight, &results); err != nil {
+		return nil, err
+	}
+	tickers := make([]*Ticker24h, 0, len(results))
+	for _, r := range results {
+		tickers = append(tickers, parseTicker24h(r, cfg.market))
+	}
+	return tickers, nil
+}
+
+// parseTicker24h 把响应中的一项转换为Ticker24h，币本位的成交额按合约面值换算
+func parseTicker24h(result tickerResponse, market MarketType) *Ticker24h {
+	ticker := &Ticker24h{
+		Symbol:      result.Symbol,
+		TradeCount:  result.Count,
+		OpenTimeMs:  result.OpenTime,
+		CloseTimeMs: result.CloseTime,
+	}
+	ticker.High, _ = parseFloat(result.HighPrice)
+	ticker.Low, _ = parseFloat(result.LowPrice)
+	ticker.PriceChangePercent, _ = parseFloat(result.PriceChangePercent)
+	ticker.WeightedAvgPrice, _ = parseFloat(result.WeightedAvgPrice)
+	if market == COINM {
+		contracts, _ := parseFloat(result.Volume)
+		ticker.Volume, _ = parseFloat(result.BaseVolume)
+		ticker.QuoteVolume = coinMContractValue(result.Symbol)(contracts, 0)
+	} else {
+		ticker.Volume, _ = parseFloat(result.Volume)
+		ticker.QuoteVolume, _ = parseFloat(result.QuoteVolume)
+	}
+	return ticker
+}
+
+// tickerBatchMinSymbols GetMany中symbol数量超过该值时改为一次请求全部交易对的ticker，
+// 此时一次批量请求的权重低于逐个请求的权重之和（合约40对1，现货80对2）
+const tickerBatchMinSymbols = weightAllTickers24h / weightTicker24h
+
+// prefetchTickers 一次请求全部交易对的ticker，把symbols中的部分写入缓存，GetMany的各worker随后直接命中
+// 数据源不支持或请求失败时什么也不做，由各worker单独请求
+func (c *Client) prefetchTickers(ctx context.Context, symbols map[string]bool) {
+	src, ok := c.source().(AllTickersSource)
+	if !ok {
+		return
+	}
+	tickers, err := src.AllTickers24h(ctx)
+	if err != nil {
+		return
+	}
+	ttl := c.config().cacheTTL("1m")
+	for _, ticker := range tickers {
+		if symbols[ticker.Symbol] {
+			c.cache.set(tickerCacheKey(ticker.Symbol), ticker, ttl)
+		}
+	}
+}
+
+// formatTicker24h Format中的一行24小时统计
+func formatTicker24h(t *Ticker24h, decimals int) string {
+	parts := []string{
+		fmt.Sprintf("%+.2f%%", t.PriceChangePercent),
+		"high " + formatFloat(t.High, decimals),
+		"low " + formatFloat(t.Low, decimals),
+		"VWAP " + formatFloat(t.WeightedAvgPrice, decimals),
+		"quote volume " + formatUSD(t.QuoteVolume),
+		fmt.Sprintf("%d trades", t.TradeCount),
+	}
+	return "24h: " + strings.Join(parts, " | ") + "\n\n"
+}
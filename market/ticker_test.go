@@ -0,0 +1,118 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTicker24hIsOptIn(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+
+	// 默认不请求ticker：没有预置行情数据的数据源在WithStrict下也能成功
+	src := newFixtureSource("BTCUSDT", now)
+	data, report, err := NewClient(WithSource(src)).GetPartial(context.Background(), "BTCUSDT", WithIntervals("3m"), WithStrict())
+	if err != nil {
+		t.Fatalf("GetPartial: %v", err)
+	}
+	if len(report.Warnings) != 0 || data.Ticker24h != nil || data.Unavailable(SectionTicker) {
+		t.Errorf("warnings = %v, Ticker24h = %+v, want no ticker and no warning", report.Warnings, data.Ticker24h)
+	}
+	if n := src.Calls("Ticker24h"); n != 0 {
+		t.Errorf("Ticker24h called %d times, want 0", n)
+	}
+
+	// WithTicker24h时缺少行情数据标记为不可用，严格模式下返回错误
+	if _, err := NewClient(WithSource(src)).Get(context.Background(), "BTCUSDT", WithIntervals("3m"), WithTicker24h(), WithStrict()); err == nil {
+		t.Error("WithTicker24h and WithStrict without a ticker fixture: err = nil, want an error")
+	}
+
+	ticker := &Ticker24h{Symbol: "BTCUSDT", Volume: 12345}
+	src = newFixtureSource("BTCUSDT", now).SetTicker24h("BTCUSDT", ticker)
+	data, err = NewClient(WithSource(src)).Get(context.Background(), "BTCUSDT", WithIntervals("3m"), WithTicker24h(), WithStrict())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data.Ticker24h == nil || *data.Ticker24h != *ticker {
+		t.Errorf("Ticker24h = %+v, want %+v", data.Ticker24h, ticker)
+	}
+	if data.OpenInterest == nil || data.OpenInterest.Volume24h != ticker.Volume {
+		t.Errorf("OpenInterest = %+v, want Volume24h from the ticker", data.OpenInterest)
+	}
+}
+
+func TestAllTickers24hREST(t *testing.T) {
+	fake := &fakeBinance{}
+	srv := newTestServer(t, fake)
+	src, ok := newRESTClient(srv).source().(AllTickersSource)
+	if !ok {
+		t.Fatal("REST source does not implement AllTickersSource")
+	}
+	tickers, err := src.AllTickers24h(context.Background())
+	if err != nil {
+		t.Fatalf("AllTickers24h: %v", err)
+	}
+	if len(tickers) != 2 || tickers[0].Symbol != "BTCUSDT" || tickers[1].QuoteVolume != 500000 || tickers[1].TradeCount != 1200 {
+		t.Errorf("tickers = %+v, want BTCUSDT and ETHUSDT parsed", tickers)
+	}
+	if got := fake.Requests(); len(got) != 1 || got[0] != "/fapi/v1/ticker/24hr" {
+		t.Errorf("requests = %v, want a single /fapi/v1/ticker/24hr without symbol", got)
+	}
+}
+
+func TestGetManyBatchesTickers(t *testing.T) {
+	now := time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC)
+	step := intervalDuration("3m").Milliseconds()
+	klines := fixtureKlines("3m", 100, now.UnixMilli()/step*step)
+	newSource := func(n int) (*FakeSource, []string) {
+		src := NewFakeSource()
+		symbols := make([]string, n)
+		for i := range symbols {
+			symbols[i] = fmt.Sprintf("C%dUSDT", i)
+			src.SetKlines(symbols[i], "3m", klines).
+				SetTicker24h(symbols[i], &Ticker24h{Symbol: symbols[i], QuoteVolume: float64(i+1) * 1e6})
+		}
+		// 不在本次批量中的交易对不写入缓存
+		src.SetTicker24h("OTHERUSDT", &Ticker24h{Symbol: "OTHERUSDT"})
+		return src, symbols
+	}
+	opts := []GetOption{WithIntervals("3m"), WithTicker24h(), WithStrict(), WithoutOpenInterest(), WithoutFunding(), WithoutMicrostructure()}
+
+	tests := []struct {
+		name        string
+		symbols     int
+		getOpts     []GetOption
+		wantAll     int // AllTickers24h的请求次数
+		wantPerItem int // Ticker24h的请求次数
+	}{
+		// 超过40个币种时一次批量请求的权重更低
+		{"batched", tickerBatchMinSymbols + 1, nil, 1, 0},
+		{"few symbols", tickerBatchMinSymbols, nil, 0, tickerBatchMinSymbols},
+		{"WithoutCache", tickerBatchMinSymbols + 1, []GetOption{WithoutCache()}, 0, tickerBatchMinSymbols + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, symbols := newSource(tt.symbols)
+			c := NewClient(WithSource(src))
+			results, err := c.GetMany(context.Background(), symbols, append(opts, tt.getOpts...)...)
+			if err != nil {
+				t.Fatalf("GetMany: %v", err)
+			}
+			if src.Calls("AllTickers24h") != tt.wantAll || src.Calls("Ticker24h") != tt.wantPerItem {
+				t.Errorf("AllTickers24h = %d, Ticker24h = %d calls, want %d and %d",
+					src.Calls("AllTickers24h"), src.Calls("Ticker24h"), tt.wantAll, tt.wantPerItem)
+			}
+			// 排序所需的成交额来自同一次获取
+			for i, symbol := range symbols {
+				data := results[symbol]
+				if data == nil || data.Ticker24h == nil {
+					t.Fatalf("%s: data = %+v, want a ticker", symbol, data)
+				}
+				if got := data.Score(DefaultScoreWeights).QuoteVolume24h; got != float64(i+1)*1e6 {
+					t.Errorf("%s: QuoteVolume24h = %v, want %v", symbol, got, float64(i+1)*1e6)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package market
+
+import (
+	"context"
+	"net/url"
+)
+
+// Tracer 链路追踪接口，用于在不依赖OpenTelemetry的情况下接入任意追踪系统
+// Start返回的ctx需携带新建的span，后续子span与HTTP请求都会使用该ctx，从而正确挂接父子关系
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span 一个追踪区间
+type Span interface {
+	// SetError 标记区间失败
+	SetError(err error)
+	// End 结束区间
+	End()
+}
+
+// Attribute 追踪区间的键值属性
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// WithTracer 设置链路追踪，nil表示不追踪
+// Get会创建父span "market.Get"，每个HTTP请求创建子span（名称为接口路径，带symbol、interval等属性）
+func WithTracer(t Tracer) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.tracer = t
+	}
+}
+
+// SetTracer 设置默认客户端的链路追踪，传nil关闭
+func SetTracer(t Tracer) {
+	defaultClient.apply(WithTracer(t))
+}
+
+// nopSpan 未配置Tracer时使用的空区间
+type nopSpan struct{}
+
+func (nopSpan) SetError(error) {}
+func (nopSpan) End()           {}
+
+// startSpan 未配置Tracer时原样返回ctx
+func (cfg clientConfig) startSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if cfg.tracer == nil {
+		return ctx, nopSpan{}
+	}
+	return cfg.tracer.Start(ctx, name, attrs...)
+}
+
+// requestAttributes 从请求地址中提取追踪属性
+func requestAttributes(endpoint, rawURL string) []Attribute {
+	attrs := []Attribute{{Key: "endpoint", Value: endpoint}}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return attrs
+	}
+	query := u.Query()
+	for _, key := range []string{"symbol", "interval", "period", "limit"} {
+		if v := query.Get(key); v != "" {
+			attrs = append(attrs, Attribute{Key: key, Value: v})
+		}
+	}
+	return attrs
+}
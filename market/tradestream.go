@@ -0,0 +1,215 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxFlowWindow 成交流累加器支持的最长窗口
+const MaxFlowWindow = 60 * time.Minute
+
+// tradeBucketCount 每个symbol保留的分钟桶数量：60个完整分钟加当前分钟
+const tradeBucketCount = int(MaxFlowWindow/time.Minute) + 1
+
+// TradeStream 通过WebSocket（<symbol>@aggTrade）按分钟累加主动买卖成交量
+// 任意不超过60分钟窗口的CVD/OFI都可以直接从分钟桶计算，不需要重新下载成交；
+// 连续接收的时长覆盖窗口后Get优先使用累加器，否则回退到REST
+type TradeStream struct {
+	client *Client
+	done   chan struct{}
+
+	mu    sync.RWMutex
+	flows map[string]*tradeFlow
+}
+
+// tradeFlow 单个symbol的分钟桶环形缓冲
+type tradeFlow struct {
+	buckets [tradeBucketCount]tradeBucket
+	since   time.Time // 本次连续接收的起点，断线后重置
+}
+
+// tradeBucket 一分钟内的主动买入与主动卖出量
+type tradeBucket struct {
+	minute  int64 // Unix分钟数，用于判断桶是否已被新的分钟覆盖
+	buyVol  float64
+	sellVol float64
+}
+
+// StartTradeStream 使用默认客户端开启成交流订阅，见Client.StartTradeStream
+func StartTradeStream(ctx context.Context, symbols []string) (*TradeStream, error) {
+	return defaultClient.StartTradeStream(ctx, symbols)
+}
+
+// StartTradeStream 订阅symbols的归集成交，并在后台保持连接直到ctx结束
+func (c *Client) StartTradeStream(ctx context.Context, symbols []string) (*TradeStream, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("至少需要订阅一个symbol")
+	}
+
+	s := &TradeStream{
+		client: c,
+		done:   make(chan struct{}),
+		flows:  make(map[string]*tradeFlow),
+	}
+	var streams []string
+	for _, symbol := range symbols {
+		streams = append(streams, strings.ToLower(c.normalizeSymbol(symbol))+"@aggTrade")
+	}
+
+	cfg := c.config()
+	conn := &streamConn{
+		url:     cfg.wsBaseURL(),
+		streams: streams,
+		logger:  cfg.logger,
+		dialer:  cfg.wsDialer(),
+		onDisconnect: func(error) {
+			s.reset()
+		},
+		onMessage: s.handle,
+	}
+
+	c.addTradeStream(s)
+	go func() {
+		defer close(s.done)
+		defer c.removeTradeStream(s)
+		conn.run(ctx)
+	}()
+	return s, nil
+}
+
+// Done 订阅结束（ctx结束且连接已关闭）后关闭
+func (s *TradeStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Flow 计算symbol最近window内的CVD与OFI
+// window超过MaxFlowWindow或连续接收时长不足window时返回false
+func (s *TradeStream) Flow(symbol string, window time.Duration) (cvd, ofi float64, ok bool) {
+	return s.flowAt(s.client.normalizeSymbol(symbol), window, time.Now())
+}
+
+// flowAt 以now为窗口终点计算CVD与OFI
+// 完全落在窗口内的分钟桶整体计入，窗口起点所在的分钟桶按落在窗口内的时间比例计入（假设分钟内成交均匀）
+func (s *TradeStream) flowAt(symbol string, window time.Duration, now time.Time) (cvd, ofi float64, ok bool) {
+	if window <= 0 || window > MaxFlowWindow {
+		return 0, 0, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flow, exists := s.flows[symbol]
+	if !exists || now.Sub(flow.since) < window {
+		return 0, 0, false
+	}
+
+	start := now.Add(-window).UnixMilli()
+	startMinute := start / 60000
+	nowMinute := now.UnixMilli() / 60000
+	buyVol, sellVol := 0.0, 0.0
+	for minute := startMinute; minute <= nowMinute; minute++ {
+		bucket := flow.buckets[minute%int64(tradeBucketCount)]
+		if bucket.minute != minute {
+			continue
+		}
+		weight := 1.0
+		if minute == startMinute {
+			weight = float64((minute+1)*60000-start) / 60000
+		}
+		buyVol += bucket.buyVol * weight
+		sellVol += bucket.sellVol * weight
+	}
+
+	cvd = buyVol - sellVol
+	if total := buyVol + sellVol; total > 0 {
+		ofi = cvd / total
+	}
+	return cvd, ofi, true
+}
+
+// add 将一笔成交计入所在分钟的桶，桶属于更早的分钟时先清空（环形淘汰）
+func (s *TradeStream) add(symbol string, trade AggTrade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.flows[symbol]
+	if !ok {
+		flow = &tradeFlow{since: time.UnixMilli(trade.Timestamp)}
+		s.flows[symbol] = flow
+	}
+	minute := trade.Timestamp / 60000
+	bucket := &flow.buckets[minute%int64(tradeBucketCount)]
+	if bucket.minute != minute {
+		if bucket.minute > minute {
+			return // 迟到超过一小时的成交，对应的桶已被覆盖
+		}
+		*bucket = tradeBucket{minute: minute}
+	}
+	if trade.BuyerIsMaker {
+		bucket.sellVol += trade.Quantity
+	} else {
+		bucket.buyVol += trade.Quantity
+	}
+}
+
+// reset 断线后丢弃全部累加结果，断线期间的成交无法补齐
+func (s *TradeStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flows = make(map[string]*tradeFlow)
+}
+
+// wsAggTradeEvent 归集成交推送
+type wsAggTradeEvent struct {
+	Symbol       string `json:"s"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	BuyerIsMaker bool   `json:"m"`
+}
+
+func (s *TradeStream) handle(stream string, data json.RawMessage) {
+	var event wsAggTradeEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		s.client.config().logger.Debugf("无法解析的成交推送 stream=%s err=%v", stream, err)
+		return
+	}
+	price, _ := parseFloat(event.Price)
+	qty, _ := parseFloat(event.Quantity)
+	if s.client.config().market == COINM {
+		qty = contractsToBase(event.Symbol, qty, price)
+	}
+	s.add(event.Symbol, AggTrade{
+		Quantity:     qty,
+		Price:        price,
+		BuyerIsMaker: event.BuyerIsMaker,
+		Timestamp:    event.TradeTime,
+	})
+}
+
+// addTradeStream 登记成交流订阅，供Get查询
+func (c *Client) addTradeStream(s *TradeStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.tradeStreams = append(c.tradeStreams, s)
+}
+
+// removeTradeStream 注销成交流订阅
+func (c *Client) removeTradeStream(s *TradeStream) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+	c.tradeStreams = removeStream(c.tradeStreams, s)
+}
+
+// liveFlow 从成交流订阅中取window内的CVD与OFI
+func (c *Client) liveFlow(symbol string, window time.Duration) (cvd, ofi float64, ok bool) {
+	c.streamMu.RLock()
+	defer c.streamMu.RUnlock()
+	for _, s := range c.tradeStreams {
+		if cvd, ofi, ok := s.Flow(symbol, window); ok {
+			return cvd, ofi, true
+		}
+	}
+	return 0, 0, false
+}
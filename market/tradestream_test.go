@@ -0,0 +1,92 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// newTestTradeStream 不建立连接的成交流，通过handle喂入成交
+func newTestTradeStream(c *Client) *TradeStream {
+	return &TradeStream{client: c, done: make(chan struct{}), flows: make(map[string]*tradeFlow)}
+}
+
+// feedMinutes 从start起每分钟的第30秒喂入一笔主动买入2与一笔主动卖出1，共minutes分钟
+func feedMinutes(s *TradeStream, symbol string, start time.Time, minutes int) {
+	for m := range minutes {
+		ts := start.Add(time.Duration(m)*time.Minute + 30*time.Second).UnixMilli()
+		s.handle("aggTrade", []byte(fmt.Sprintf(`{"s":%q,"p":"100","q":"2","T":%d,"m":false}`, symbol, ts)))
+		s.handle("aggTrade", []byte(fmt.Sprintf(`{"s":%q,"p":"100","q":"1","T":%d,"m":true}`, symbol, ts)))
+	}
+}
+
+func TestTradeStreamWindowedCVD(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestTradeStream(NewClient())
+	// 70分钟的成交，最早的10分钟已被环形缓冲淘汰
+	feedMinutes(s, "BTCUSDT", base, 70)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		window  time.Duration
+		wantCVD float64
+		wantOK  bool
+	}{
+		{"1m", base.Add(70 * time.Minute), time.Minute, 1, true},
+		{"15m", base.Add(70 * time.Minute), 15 * time.Minute, 15, true},
+		{"60m after eviction", base.Add(70 * time.Minute), 60 * time.Minute, 60, true},
+		// 窗口起点所在的分钟按比例计入：68分的桶计入1/4，69分的桶全部计入
+		{"partial first minute", base.Add(69*time.Minute + 45*time.Second), time.Minute, 1.25, true},
+		{"window over MaxFlowWindow", base.Add(70 * time.Minute), 61 * time.Minute, 0, false},
+		{"history shorter than window", base.Add(10 * time.Minute), 15 * time.Minute, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cvd, ofi, ok := s.flowAt("BTCUSDT", tt.window, tt.now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(cvd-tt.wantCVD) > 1e-9 {
+				t.Errorf("cvd = %v, want %v", cvd, tt.wantCVD)
+			}
+			// 每分钟买2卖1，OFI恒为1/3
+			if math.Abs(ofi-1.0/3) > 1e-9 {
+				t.Errorf("ofi = %v, want 1/3", ofi)
+			}
+		})
+	}
+}
+
+func TestGetPrefersTradeStream(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		history       int // 成交流已连续接收的分钟数
+		wantAggTrades int // 回退到REST的窗口数
+	}{
+		{"covers all windows", 20, 0},
+		{"covers 1m only", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newFixtureSource("BTCUSDT", now)
+			c := NewClient(WithSource(src))
+			s := newTestTradeStream(c)
+			feedMinutes(s, "BTCUSDT", now.Truncate(time.Minute).Add(-time.Duration(tt.history)*time.Minute), tt.history+1)
+			c.addTradeStream(s)
+
+			if _, err := c.Get(context.Background(), "BTCUSDT"); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got := src.Calls("AggTrades"); got != tt.wantAggTrades {
+				t.Errorf("AggTrades calls = %d, want %d", got, tt.wantAggTrades)
+			}
+		})
+	}
+}
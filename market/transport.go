@@ -0,0 +1,123 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DialContextFunc 建立TCP连接的拨号函数（如经由跳板、绑定出口网卡）
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithProxy 经由代理访问币安，支持 http://、https:// 与 socks5://（可带用户名密码），REST与WebSocket都生效
+// 代理只设置在客户端专用的Transport上，不影响http.DefaultTransport与进程的环境变量；空字符串表示不使用代理
+// 地址无效时该客户端的所有请求都返回错误
+func WithProxy(proxyURL string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.proxy = parseProxy(proxyURL)
+	}
+}
+
+// WithDialContext 指定建立连接的拨号函数，REST与WebSocket都生效，nil表示默认拨号
+func WithDialContext(fn DialContextFunc) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.dialContext = fn
+	}
+}
+
+// parseProxy 解析代理地址，返回供Transport使用的Proxy函数
+func parseProxy(raw string) func(*http.Request) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err == nil {
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+			if u.Host == "" {
+				err = fmt.Errorf("缺少主机地址")
+			}
+		default:
+			err = fmt.Errorf("不支持的协议 %q", u.Scheme)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("无效的代理地址 %q: %w", raw, err)
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// buildHTTPClient 在全部选项应用后构建实际使用的HTTP客户端
+// 未指定httpClient时使用带连接/TLS/响应头超时的专用Transport；
+// 指定了httpClient且配置了代理或拨号函数时，复制该客户端并换上克隆出的Transport；
+// 自定义的非*http.Transport无法设置代理，此时原样使用并记录日志
+func (cfg *clientConfig) buildHTTPClient() {
+	if cfg.httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{
+			Timeout:   cfg.timeouts.Dial,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		transport.TLSHandshakeTimeout = cfg.timeouts.TLSHandshake
+		transport.ResponseHeaderTimeout = cfg.timeouts.ResponseHeader
+		cfg.applyDialSettings(transport)
+		cfg.client = &http.Client{Transport: transport}
+		return
+	}
+
+	cfg.client = cfg.httpClient
+	if cfg.proxy == nil && cfg.dialContext == nil {
+		return
+	}
+
+	var transport *http.Transport
+	switch base := cfg.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = base.Clone()
+	default:
+		cfg.logger.Warnf("自定义Transport类型 %T 不支持代理与拨号设置，已忽略", base)
+		return
+	}
+	cfg.applyDialSettings(transport)
+
+	client := *cfg.httpClient
+	client.Transport = transport
+	cfg.client = &client
+}
+
+// applyDialSettings 在transport上设置代理与拨号函数
+func (cfg *clientConfig) applyDialSettings(transport *http.Transport) {
+	if cfg.proxy != nil {
+		transport.Proxy = cfg.proxy
+	}
+	if cfg.dialContext != nil {
+		transport.DialContext = cfg.dialContext
+	}
+}
+
+// wsDialer WebSocket拨号器，与REST共用代理与拨号设置，未配置代理时沿用环境变量
+func (cfg clientConfig) wsDialer() *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: streamHandshakeTimeout,
+	}
+	if cfg.proxy != nil {
+		dialer.Proxy = cfg.proxy
+	}
+	if cfg.dialContext != nil {
+		dialer.NetDialContext = cfg.dialContext
+	} else if cfg.timeouts.Dial > 0 {
+		dialer.NetDialContext = (&net.Dialer{Timeout: cfg.timeouts.Dial}).DialContext
+	}
+	return dialer
+}
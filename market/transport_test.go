@@ -0,0 +1,56 @@
+package market
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestWithProxyRoutesAllRequests(t *testing.T) {
+	const upstream = "binance.invalid"
+	fake := &fakeBinance{}
+	var mu sync.Mutex
+	var direct []string
+	// 转发代理收到的是绝对地址；目标主机无法解析，不经过代理的请求必然失败
+	proxy := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != upstream {
+			mu.Lock()
+			direct = append(direct, r.RequestURI)
+			mu.Unlock()
+			http.Error(w, "not a proxied request", http.StatusBadGateway)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+
+	c := NewClient(WithBaseURL("http://"+upstream), WithProxy(proxy.URL), WithRetry(RetryPolicy{}))
+	data, err := c.Get(context.Background(), "BTCUSDT", WithStrict())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(direct) > 0 {
+		t.Errorf("requests reached the proxy without an absolute URL: %v", direct)
+	}
+	if data.SymbolInfo == nil {
+		t.Error("SymbolInfo = nil, exchangeInfo did not go through the proxy")
+	}
+	for _, endpoint := range []string{"/exchangeInfo", "/klines", "/openInterest", "/openInterestHist", "/premiumIndex", "/fundingRate", "/aggTrades", "/depth"} {
+		if fake.Count(endpoint) == 0 {
+			t.Errorf("no proxied request to %s", endpoint)
+		}
+	}
+}
+
+func TestWithProxyInvalidURLFailsRequests(t *testing.T) {
+	fake := &fakeBinance{}
+	srv := newTestServer(t, fake)
+	c := newRESTClient(srv, WithProxy("ftp://proxy.example"))
+
+	if _, err := c.Get(context.Background(), "BTCUSDT"); err == nil {
+		t.Fatal("Get succeeded with an invalid proxy URL")
+	}
+	if n := len(fake.Requests()); n != 0 {
+		t.Errorf("%d requests bypassed the invalid proxy", n)
+	}
+}
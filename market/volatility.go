@@ -0,0 +1,123 @@
+package market
+
+import (
+	"math"
+	"time"
+)
+
+// volatilityWindow 波动率估计使用的K线数量
+const volatilityWindow = 20
+
+// tradingYear 加密货币全年无休，按365天年化
+const tradingYear = 365 * 24 * time.Hour
+
+// annualizationFactor 按周期对应的每年K线数计算的年化系数 √(每年K线数)，周期无法解析时为0
+func annualizationFactor(interval string) float64 {
+	step := intervalDuration(interval)
+	if step <= 0 {
+		return 0
+	}
+	return math.Sqrt(float64(tradingYear) / float64(step))
+}
+
+// calculateParkinsonVol Parkinson波动率（每根K线）：√(Σ ln(H/L)² / (4·ln2·n))，只用最高最低价，
+// 比收盘价对数收益率的标准差噪声小；使用最近period根K线，跳过价格非正的K线，没有可用K线时为0
+func calculateParkinsonVol(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period {
+		return 0
+	}
+	sum := 0.0
+	n := 0
+	for _, k := range klines[len(klines)-period:] {
+		if k.High <= 0 || k.Low <= 0 {
+			continue
+		}
+		hl := math.Log(k.High / k.Low)
+		sum += hl * hl
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / (4 * math.Ln2 * float64(n)))
+}
+
+// calculateGarmanKlassVol Garman-Klass波动率（每根K线）：√(Σ [½·ln(H/L)² − (2·ln2−1)·ln(C/O)²] / n)，
+// 同时使用开高低收；使用最近period根K线，跳过价格非正的K线，方差为负（极端数据）时为0
+func calculateGarmanKlassVol(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period {
+		return 0
+	}
+	sum := 0.0
+	n := 0
+	for _, k := range klines[len(klines)-period:] {
+		if k.High <= 0 || k.Low <= 0 || k.Open <= 0 || k.Close <= 0 {
+			continue
+		}
+		hl := math.Log(k.High / k.Low)
+		co := math.Log(k.Close / k.Open)
+		sum += 0.5*hl*hl - (2*math.Ln2-1)*co*co
+		n++
+	}
+	if n == 0 || sum <= 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// RealizedVolSeries 滚动period根K线对数收益率的总体标准差（每根K线，未年化），前period个值为NaN
+// 跳过前收盘价非正的K线，窗口内没有可用收益率时为NaN
+func RealizedVolSeries(klines []Kline, period int) []float64 {
+	result := nanSeries(len(klines))
+	if period <= 0 {
+		return result
+	}
+	returns := make([]float64, 0, period)
+	for end := period; end < len(klines); end++ {
+		returns = returns[:0]
+		for i := end - period + 1; i <= end; i++ {
+			if prev := klines[i-1].Close; prev > 0 {
+				returns = append(returns, math.Log(klines[i].Close/prev))
+			}
+		}
+		if len(returns) == 0 {
+			continue
+		}
+
+		mean := 0.0
+		for _, v := range returns {
+			mean += v
+		}
+		mean /= float64(len(returns))
+
+		variance := 0.0
+		for _, v := range returns {
+			diff := v - mean
+			variance += diff * diff
+		}
+		result[end] = math.Sqrt(variance / float64(len(returns)))
+	}
+	return result
+}
+
+// PercentileRank value在series中的百分位：不大于value的值所占比例×100，忽略NaN
+// series没有有效值或value为NaN时为0
+func PercentileRank(series []float64, value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+	total, below := 0, 0
+	for _, v := range series {
+		if math.IsNaN(v) {
+			continue
+		}
+		total++
+		if v <= value {
+			below++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(below) / float64(total) * 100
+}
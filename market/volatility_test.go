@@ -0,0 +1,86 @@
+package market
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVolatilityEstimatorsHandComputed(t *testing.T) {
+	ln2 := math.Ln2
+	// 第一根：H/L=2、开收相同；第二根：H/L=4、C/O=2
+	klines := []Kline{
+		{Open: 1.5, High: 2, Low: 1, Close: 1.5},
+		{Open: 1, High: 4, Low: 1, Close: 2},
+	}
+	// Parkinson：Σln(H/L)² = ln²2 + 4ln²2 = 5ln²2，√(5ln²2/(4·ln2·2)) = √(5ln2/8)
+	if got, want := calculateParkinsonVol(klines, 2), math.Sqrt(5*ln2/8); !approxEqual(got, want) {
+		t.Errorf("Parkinson = %v, want %v", got, want)
+	}
+	// Garman-Klass：½ln²2 + [½·4ln²2 − (2ln2−1)·ln²2] = (3.5−2ln2)·ln²2，除以2后开方
+	if got, want := calculateGarmanKlassVol(klines, 2), ln2*math.Sqrt((3.5-2*ln2)/2); !approxEqual(got, want) {
+		t.Errorf("Garman-Klass = %v, want %v", got, want)
+	}
+	// 收盘价1→2→4→2：收益率ln2、ln2、−ln2，均值ln2/3，总体方差(4+4+16)/9·ln²2/3 = 8/9·ln²2
+	closes := []Kline{{Close: 1}, {Close: 2}, {Close: 4}, {Close: 2}}
+	assertSeries(t, "realized vol", RealizedVolSeries(closes, 3), map[int]float64{2: math.NaN(), 3: 2 * math.Sqrt2 / 3 * ln2})
+
+	// K线不足period根时为0
+	if got := calculateParkinsonVol(klines[:1], 2); got != 0 {
+		t.Errorf("Parkinson with 1 bar = %v, want 0", got)
+	}
+	if got := calculateGarmanKlassVol(klines[:1], 2); got != 0 {
+		t.Errorf("Garman-Klass with 1 bar = %v, want 0", got)
+	}
+}
+
+func TestVolatilityEstimatorsReference(t *testing.T) {
+	klines := referenceKlines()
+	for _, tt := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Parkinson", calculateParkinsonVol(klines, volatilityWindow), 0.020050421913},
+		{"Garman-Klass", calculateGarmanKlassVol(klines, volatilityWindow), 0.021447530324},
+		{"realized vol", RealizedVolSeries(klines, volatilityWindow)[59], 0.015545629928},
+		{"realized vol first", RealizedVolSeries(klines, volatilityWindow)[20], 0.018303329341},
+	} {
+		if !approxEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestAnnualizationFactor(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     float64
+	}{
+		{"1m", math.Sqrt(365 * 24 * 60)},
+		{"1h", math.Sqrt(365 * 24)},
+		{"4h", math.Sqrt(365 * 6)},
+		{"1d", math.Sqrt(365)},
+		{"bogus", 0},
+	}
+	for _, tt := range tests {
+		if got := annualizationFactor(tt.interval); !approxEqual(got, tt.want) {
+			t.Errorf("annualizationFactor(%q) = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+
+	// 各周期的年化值为每根K线的波动率×√(每年K线数)
+	c := NewClient(WithSource(newFixtureSource("BTCUSDT", time.Date(2024, 6, 1, 13, 37, 0, 0, time.UTC))))
+	data, err := c.Get(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	for interval, m := range data.Timeframes {
+		if m.RealizedVol20 == 0 {
+			t.Errorf("%s RealizedVol20 = 0, want a non-trivial fixture", interval)
+		}
+		if want := m.RealizedVol20 * annualizationFactor(interval); !approxEqual(m.RealizedVolAnnualized, want) {
+			t.Errorf("%s RealizedVolAnnualized = %v, want %v", interval, m.RealizedVolAnnualized, want)
+		}
+	}
+}
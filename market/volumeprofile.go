@@ -0,0 +1,173 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// VolumeDistribution 成交量分布剖面中单根K线成交量在价格上的分配方式
+// K线只有开高低收，无法知道K线内部的真实成交分布，两种方式都是近似
+type VolumeDistribution int
+
+const (
+	// VolumeAtTypicalPrice 整根K线的成交量计入典型价格（(高+低+收)/3）所在的价格桶（默认）
+	VolumeAtTypicalPrice VolumeDistribution = iota
+	// VolumeAcrossRange 成交量按高低价区间与各价格桶的重叠长度均匀分摊，适合振幅较大的K线
+	VolumeAcrossRange
+)
+
+// 成交量分布剖面的默认参数
+const (
+	DefaultVolumeProfileLookback = 24 * time.Hour
+	volumeProfileBuckets         = 50   // 价格桶数量，等分窗口内的最高最低价区间
+	valueAreaShare               = 0.70 // 价值区包含的成交量占比
+)
+
+// volumeProfileSources 成交量分布剖面依次尝试的K线周期
+var volumeProfileSources = []string{"1m", "3m"}
+
+// VolumeProfile 成交量分布剖面（按价格统计的成交量）
+type VolumeProfile struct {
+	Interval     string  `json:"interval"`       // 所用K线周期
+	StartTime    int64   `json:"start_time"`     // 窗口内第一根K线的开盘时间；K线不足以覆盖回看时长时晚于预期
+	Bars         int     `json:"bars"`           // 参与统计的K线数量
+	BucketSize   float64 `json:"bucket_size"`    // 价格桶宽度
+	POC          float64 `json:"poc"`            // 成交量最大的价格桶中点（Point of Control）
+	VAH          float64 `json:"vah"`            // 价值区上沿（包含70%成交量）
+	VAL          float64 `json:"val"`            // 价值区下沿
+	PriceVsPOC   int     `json:"price_vs_poc"`   // 当前价格在POC之上为+1，之下为−1，落在POC所在价格桶内（含边界）为0
+	PriceInValue bool    `json:"price_in_value"` // 当前价格是否处于价值区内
+}
+
+// BuildVolumeProfile 用klines构建成交量分布剖面：把最高最低价区间等分为50个价格桶，按distribution分配每根K线的成交量，
+// 成交量最大的桶为POC；价值区从POC开始，每次向成交量较大的一侧扩展一个桶，直到覆盖70%的成交量
+// 当前价格取最后一根K线的收盘价；没有K线或总成交量为0时返回包装了ErrInsufficientData的错误
+func BuildVolumeProfile(klines []Kline, distribution VolumeDistribution) (*VolumeProfile, error) {
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("%w: 成交量分布剖面没有K线", ErrInsufficientData)
+	}
+	low, high := klines[0].Low, klines[0].High
+	for _, k := range klines {
+		low = math.Min(low, k.Low)
+		high = math.Max(high, k.High)
+	}
+
+	buckets := volumeProfileBuckets
+	size := (high - low) / float64(buckets)
+	if size <= 0 {
+		// 窗口内价格没有变化，全部成交量集中在一个价格上
+		buckets, size = 1, 0
+	}
+	bucketOf := func(price float64) int {
+		if size == 0 {
+			return 0
+		}
+		return min(max(int((price-low)/size), 0), buckets-1)
+	}
+
+	volumes := make([]float64, buckets)
+	total := 0.0
+	for _, k := range klines {
+		if k.Volume <= 0 {
+			continue
+		}
+		total += k.Volume
+		if distribution == VolumeAcrossRange && k.High > k.Low && size > 0 {
+			span := k.Range()
+			for b := bucketOf(k.Low); b <= bucketOf(k.High); b++ {
+				bucketLow := low + float64(b)*size
+				overlap := math.Min(k.High, bucketLow+size) - math.Max(k.Low, bucketLow)
+				if overlap > 0 {
+					volumes[b] += k.Volume * overlap / span
+				}
+			}
+			continue
+		}
+		volumes[bucketOf(k.TypicalPrice())] += k.Volume
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("%w: 窗口内成交量为0", ErrInsufficientData)
+	}
+
+	poc := 0
+	for b, v := range volumes {
+		if v > volumes[poc] {
+			poc = b
+		}
+	}
+	lowBucket, highBucket := poc, poc
+	covered := volumes[poc]
+	for covered < valueAreaShare*total && (lowBucket > 0 || highBucket < buckets-1) {
+		below, above := -1.0, -1.0
+		if lowBucket > 0 {
+			below = volumes[lowBucket-1]
+		}
+		if highBucket < buckets-1 {
+			above = volumes[highBucket+1]
+		}
+		if above >= below {
+			highBucket++
+			covered += above
+		} else {
+			lowBucket--
+			covered += below
+		}
+	}
+
+	price := klines[len(klines)-1].Close
+	profile := &VolumeProfile{
+		StartTime:  klines[0].OpenTime,
+		Bars:       len(klines),
+		BucketSize: size,
+		POC:        low + (float64(poc)+0.5)*size,
+		VAH:        low + float64(highBucket+1)*size,
+		VAL:        low + float64(lowBucket)*size,
+	}
+	pocLow, pocHigh := low+float64(poc)*size, low+float64(poc+1)*size
+	switch {
+	case price > pocHigh:
+		profile.PriceVsPOC = 1
+	case price < pocLow:
+		profile.PriceVsPOC = -1
+	}
+	profile.PriceInValue = price >= profile.VAL && price <= profile.VAH
+	return profile, nil
+}
+
+// calculateVolumeProfile 用1m（不足以覆盖回看时长时改用覆盖更长的3m）K线最近lookback内的部分构建成交量分布剖面，
+// 两个周期都没有时为nil；K线不足以覆盖lookback时使用全部K线，实际起点见StartTime
+func calculateVolumeProfile(klinesByInterval map[string][]Kline, lookback time.Duration, distribution VolumeDistribution) *VolumeProfile {
+	var best []Kline
+	bestInterval := ""
+	for _, interval := range volumeProfileSources {
+		klines := klinesByInterval[interval]
+		if len(klines) == 0 {
+			continue
+		}
+		if best == nil || klines[0].OpenTime < best[0].OpenTime {
+			best, bestInterval = klines, interval
+		}
+		if klines[len(klines)-1].CloseTime-klines[0].OpenTime >= lookback.Milliseconds() {
+			break
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	start := best[len(best)-1].CloseTime - lookback.Milliseconds()
+	window := best
+	for i, k := range best {
+		if k.OpenTime >= start {
+			window = best[i:]
+			break
+		}
+	}
+	profile, err := BuildVolumeProfile(window, distribution)
+	if err != nil {
+		return nil
+	}
+	profile.Interval = bestInterval
+	return profile
+}
@@ -0,0 +1,113 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// VWAPMode VWAP的累计区间
+type VWAPMode int
+
+const (
+	VWAPSession VWAPMode = iota // 从UTC当日0点起累计，按OpenTime跨日时重置
+	VWAPRolling                 // 最近period根K线
+)
+
+// VWAPSeries 成交量加权平均价（典型价 (H+L+C)/3 按成交量加权）
+// 会话模式下每个UTC日的第一根K线重新开始累计，刚过0点时只包含当日已有的K线；
+// 滚动模式前period-1个值为NaN；区间内成交量为0时为NaN
+func VWAPSeries(klines []Kline, mode VWAPMode, period int) []float64 {
+	result := nanSeries(len(klines))
+	if mode == VWAPRolling && period <= 0 {
+		return result
+	}
+
+	var pv, vol float64
+	for i, k := range klines {
+		if mode == VWAPSession && i > 0 && utcDay(k.OpenTime) != utcDay(klines[i-1].OpenTime) {
+			pv, vol = 0, 0
+		}
+		pv += k.TypicalPrice() * k.Volume
+		vol += k.Volume
+		if mode == VWAPRolling {
+			if i >= period {
+				old := klines[i-period]
+				pv -= old.TypicalPrice() * old.Volume
+				vol -= old.Volume
+			}
+			if i < period-1 {
+				continue
+			}
+		}
+		if vol > 0 {
+			result[i] = pv / vol
+		}
+	}
+	return result
+}
+
+// calculateVWAP 计算最后一根K线的VWAP，无法计算时为0
+func calculateVWAP(klines []Kline, mode VWAPMode, period int) float64 {
+	return lastValue(VWAPSeries(klines, mode, period))
+}
+
+// utcDay 毫秒时间戳所在的UTC日序号
+func utcDay(ms int64) int64 {
+	return int64(math.Floor(float64(ms) / float64(24*time.Hour/time.Millisecond)))
+}
+
+// vwapDistancePct 价格相对VWAP的偏离百分比，VWAP为0时为0
+func vwapDistancePct(price, vwap float64) float64 {
+	if vwap == 0 {
+		return 0
+	}
+	return (price - vwap) / vwap * 100
+}
+
+// ErrAnchorOutOfRange 锚点早于第一根K线或晚于最后一根K线，已有K线无法覆盖锚定区间
+var ErrAnchorOutOfRange = errors.New("锚点超出K线范围")
+
+// AnchoredVWAPSeries 从锚点所在的K线起累计的VWAP序列，锚点之前为NaN
+// 锚点早于第一根K线的开盘时间或晚于最后一根K线的收盘时间时返回ErrAnchorOutOfRange
+func AnchoredVWAPSeries(klines []Kline, anchorMs int64) ([]float64, error) {
+	start, err := anchorIndex(klines, anchorMs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := nanSeries(len(klines))
+	var pv, vol float64
+	for i := start; i < len(klines); i++ {
+		pv += klines[i].TypicalPrice() * klines[i].Volume
+		vol += klines[i].Volume
+		if vol > 0 {
+			result[i] = pv / vol
+		}
+	}
+	return result, nil
+}
+
+// AnchoredVWAP 从锚点（毫秒时间戳，如波段低点或上市时间）到最后一根K线的VWAP
+// 锚点超出K线范围时返回ErrAnchorOutOfRange；区间内成交量为0时返回NaN
+func AnchoredVWAP(klines []Kline, anchorMs int64) (float64, error) {
+	series, err := AnchoredVWAPSeries(klines, anchorMs)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return series[len(series)-1], nil
+}
+
+// anchorIndex 锚点所在K线（收盘时间不早于锚点的第一根）的下标
+func anchorIndex(klines []Kline, anchorMs int64) (int, error) {
+	if len(klines) == 0 || anchorMs < klines[0].OpenTime {
+		return 0, fmt.Errorf("%w: 锚点 %s 早于第一根K线", ErrAnchorOutOfRange, time.UnixMilli(anchorMs).UTC().Format(time.RFC3339))
+	}
+	for i, k := range klines {
+		if k.CloseTime >= anchorMs {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: 锚点 %s 晚于最后一根K线", ErrAnchorOutOfRange, time.UnixMilli(anchorMs).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,47 @@
+package market
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// midnightKlines 22:00到次日02:00（UTC）的5根1h K线，高低收相同，典型价即收盘价
+func midnightKlines() []Kline {
+	start := time.Date(2024, 3, 9, 22, 0, 0, 0, time.UTC)
+	prices := []float64{100, 110, 200, 300, 100}
+	volumes := []float64{1, 3, 1, 3, 4}
+	klines := make([]Kline, len(prices))
+	for i, p := range prices {
+		open := start.Add(time.Duration(i) * time.Hour).UnixMilli()
+		klines[i] = Kline{OpenTime: open, Open: p, High: p, Low: p, Close: p, Volume: volumes[i], CloseTime: open + time.Hour.Milliseconds() - 1}
+	}
+	return klines
+}
+
+func TestVWAPSeriesAcrossUTCMidnight(t *testing.T) {
+	klines := midnightKlines()
+	nan := math.NaN()
+
+	// 00:00的K线重新开始累计，不带入前一日的成交
+	assertSeries(t, "session", VWAPSeries(klines, VWAPSession, 0),
+		map[int]float64{0: 100, 1: 107.5, 2: 200, 3: 275, 4: 187.5})
+	// 滚动窗口不受日界影响
+	assertSeries(t, "rolling", VWAPSeries(klines, VWAPRolling, 3),
+		map[int]float64{0: nan, 1: nan, 2: 126, 3: 1430.0 / 7, 4: 187.5})
+
+	anchored, err := AnchoredVWAPSeries(klines, klines[1].OpenTime+30*time.Minute.Milliseconds())
+	if err != nil {
+		t.Fatalf("AnchoredVWAPSeries: %v", err)
+	}
+	assertSeries(t, "anchored", anchored, map[int]float64{0: nan, 1: 110, 2: 132.5, 3: 1430.0 / 7, 4: 1830.0 / 11})
+
+	if _, err := AnchoredVWAP(klines, klines[0].OpenTime-1); !errors.Is(err, ErrAnchorOutOfRange) {
+		t.Errorf("anchor before first kline: err = %v, want ErrAnchorOutOfRange", err)
+	}
+
+	// 新的一天第一根K线没有成交量时为NaN，而不是沿用前一日的值
+	klines[2].Volume = 0
+	assertSeries(t, "session with empty first bar", VWAPSeries(klines, VWAPSession, 0), map[int]float64{2: nan, 3: 300})
+}